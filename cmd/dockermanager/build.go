@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// runBuild builds an image from a local directory, printing the build output as it arrives
+func runBuild(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("build")
+	var tags, buildArgs, labels stringList
+	flags.Var(&tags, "t", "name and optionally tag (name:tag) of the image (can be repeated)")
+	dockerfile := flags.String("f", "", "path of the Dockerfile, relative to the build context (default Dockerfile)")
+	flags.Var(&buildArgs, "buildarg", "value of an ARG of the Dockerfile as KEY=value (can be repeated)")
+	flags.Var(&labels, "label", "label of the image as key=value (can be repeated)")
+	target := flags.String("target", "", "stage of a multi-stage Dockerfile to build")
+	noCache := flags.Bool("nocache", false, "don't use the build cache")
+	quiet := flags.Bool("q", false, "only print the image ID")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("build needs the directory holding the build context")
+	}
+
+	options := dockerclient.BuildOptions{Tags: tags, Dockerfile: *dockerfile, Target: *target, NoCache: *noCache}
+	var err error
+	if options.BuildArgs, err = parseKeyValues(buildArgs, "build arg"); err != nil {
+		return err
+	}
+	if options.Labels, err = parseKeyValues(labels, "label"); err != nil {
+		return err
+	}
+
+	progress := func(message models.JSONMessage) {
+		switch {
+		case *quiet:
+		case message.Stream != "":
+			fmt.Print(message.Stream)
+		case message.Status != "":
+			fmt.Println(strings.TrimSpace(message.ID + " " + message.Status))
+		}
+	}
+	imageID, err := dockerClient.BuildImage(ctx, flags.Arg(0), options, progress)
+	if err != nil {
+		return err
+	}
+	if *quiet {
+		fmt.Println(imageID)
+	}
+	return nil
+}
+
+// parseKeyValues parses a list of key=value pairs given as flags. what names the pairs in error messages
+func parseKeyValues(pairs []string, what string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("bad format of %s %q, expected key=value", what, pair)
+		}
+		parsed[parts[0]] = parts[1]
+	}
+	return parsed, nil
+}
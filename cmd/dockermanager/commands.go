@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// command is a dockermanager subcommand
+type command struct {
+	// name is what the user types to run the command
+	name string
+	// usage describes the command arguments
+	usage string
+	// description is a one line summary of what the command does
+	description string
+	// run executes the command given its arguments
+	run func(ctx context.Context, dockerClient dockerclient.Docker, args []string) error
+}
+
+// commands lists every subcommand available
+var commands []command
+
+// commands is filled at init time, since commands refer to the list themselves when printing their usage
+func init() {
+	commands = []command{
+		{
+			name:        "build",
+			usage:       "build [-t name:tag] [-f dockerfile] [-buildarg KEY=value] [-label key=value] [-target stage] [-nocache] [-q] directory",
+			description: "build an image from a directory, leaving out the paths its .dockerignore excludes",
+			run:         runBuild,
+		},
+		{
+			name: "cp",
+			usage: `cp [-a] container:path localpath|-
+       dockermanager cp [-a] localpath|- container:path`,
+			description: "copy files or directories between a container and the local filesystem",
+			run:         runCp,
+		},
+		{
+			name:        "exec",
+			usage:       "exec [-i] [-t] [-it] [-u user] [-w dir] [-env KEY=value] container command [args...]",
+			description: "run a command inside a running container",
+			run:         runExec,
+		},
+		{
+			name:        "export",
+			usage:       "export [-o file] container",
+			description: "write the filesystem of a container as a tar archive",
+			run:         runExport,
+		},
+		{
+			name: "images",
+			usage: `images [ls] [-a] [-digests] [-q] [-f key=value]
+       dockermanager images inspect image [image...]
+       dockermanager images tag image repository[:tag]
+       dockermanager images rm [-f] [-noprune] image [image...]
+       dockermanager images history [-notrunc] image
+       dockermanager images prune [-a] [-f key=value]`,
+			description: "list, inspect, tag, remove and prune images",
+			run:         runImages,
+		},
+		{
+			name:        "import",
+			usage:       "import [-c instruction] [-m message] file|- [repository[:tag]]",
+			description: "create an image from a filesystem tar archive",
+			run:         runImport,
+		},
+		{
+			name:        "kill",
+			usage:       "kill [-s signal] container [container...]",
+			description: "send a signal (SIGKILL by default) to running containers",
+			run:         runKill,
+		},
+		{
+			name:        "load",
+			usage:       "load [-i file] [-q]",
+			description: "load the images of a tar archive made by save",
+			run:         runLoad,
+		},
+		{
+			name:        "logs",
+			usage:       "logs [-f] [-t] [-n lines] [-since time] [-until time] container",
+			description: "print the logs of a container",
+			run:         runLogs,
+		},
+		{
+			name: "network",
+			usage: `network [ls] [-q] [-f key=value]
+       dockermanager network create [-driver driver] [-subnet cidr] [-iprange cidr] [-gateway ip] [-internal] [-attachable] [-label key=value] [-opt key=value] name
+       dockermanager network inspect network [network...]
+       dockermanager network rm network [network...]
+       dockermanager network prune [-f key=value]
+       dockermanager network connect [-alias name] [-ip address] [-ip6 address] network container
+       dockermanager network disconnect [-f] network container`,
+			description: "create, list, inspect, remove and prune networks, and attach containers to them",
+			run:         runNetwork,
+		},
+		{
+			name:        "pause",
+			usage:       "pause container [container...]",
+			description: "suspend every process of running containers",
+			run:         runPause,
+		},
+		{
+			name:        "prune",
+			usage:       "prune [-f key=value]",
+			description: "remove every stopped container",
+			run:         runPrune,
+		},
+		{
+			name:        "ps",
+			usage:       "ps [-a] [-n limit] [-s] [-q] [-f key=value]",
+			description: "list containers",
+			run:         runPs,
+		},
+		{
+			name:        "pull",
+			usage:       "pull [-platform arch] image[:tag]",
+			description: "pull an image, using the credentials stored by docker login",
+			run:         runPull,
+		},
+		{
+			name:        "push",
+			usage:       "push [-a] image[:tag]",
+			description: "push an image to its registry, using the credentials stored by docker login",
+			run:         runPush,
+		},
+		{
+			name:        "restart",
+			usage:       "restart [-t seconds] [-s signal] container [container...]",
+			description: "restart containers",
+			run:         runRestart,
+		},
+		{
+			name:        "rm",
+			usage:       "rm [-f] [-v] [-l] container [container...]",
+			description: "remove containers",
+			run:         runRm,
+		},
+		{
+			name:        "save",
+			usage:       "save [-o file] image [image...]",
+			description: "write one or more images as a tar archive",
+			run:         runSave,
+		},
+		{
+			name:        "stop",
+			usage:       "stop [-t seconds] [-s signal] container [container...]",
+			description: "stop running containers",
+			run:         runStop,
+		},
+		{
+			name:        "unpause",
+			usage:       "unpause container [container...]",
+			description: "resume every process of paused containers",
+			run:         runUnpause,
+		},
+	}
+}
+
+// exitCodeError is returned by commands that need the program to finish with a given exit code
+type exitCodeError int
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", int(e))
+}
+
+// runCommand looks for the subcommand in args[0] and runs it with the rest of the arguments
+func runCommand(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			err := cmd.run(ctx, dockerClient, args[1:])
+			if errors.Is(err, flag.ErrHelp) {
+				return nil // The usage has already been printed
+			}
+			return err
+		}
+	}
+	return fmt.Errorf("unknown command %q, run dockermanager -h for the list of commands", args[0])
+}
+
+// printCommands writes the list of commands to stderr
+func printCommands() {
+	writer := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "  %s\t%s\n", cmd.name, cmd.description)
+	}
+	writer.Flush()
+}
+
+// newFlagSet returns the flag set of a command, printing its usage on parsing errors
+func newFlagSet(cmd string) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	flags.Usage = func() {
+		for _, c := range commands {
+			if c.name == cmd {
+				fmt.Fprintf(os.Stderr, "Usage: dockermanager %s\n\n%s\n\nOptions:\n", c.usage, c.description)
+			}
+		}
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// stringList is a flag that can be repeated, gathering every value given
+type stringList []string
+
+func (s *stringList) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// runCp copies files or directories between a container and the local filesystem, in either direction
+func runCp(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("cp")
+	archiveMode := flags.Bool("a", false, "keep the owners (uid:gid) of the files copied into the container")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("cp needs a source and a destination, one of them being container:path")
+	}
+
+	srcContainer, srcPath := splitCopyArg(flags.Arg(0))
+	dstContainer, dstPath := splitCopyArg(flags.Arg(1))
+	switch {
+	case srcContainer != "" && dstContainer != "":
+		return errors.New("copying between containers is not supported")
+	case srcContainer != "":
+		return copyFromContainer(ctx, dockerClient, srcContainer, srcPath, dstPath)
+	case dstContainer != "":
+		return copyToContainer(ctx, dockerClient, srcPath, dstContainer, dstPath, *archiveMode)
+	default:
+		return errors.New("either the source or the destination must be container:path")
+	}
+}
+
+/* splitCopyArg splits a cp argument into container and path. Arguments are container paths when they hold
+a colon with no slash before it, so local paths with colons can still be given as ./file:name */
+func splitCopyArg(arg string) (string, string) {
+	i := strings.Index(arg, ":")
+	if i <= 0 || strings.Contains(arg[:i], "/") {
+		return "", arg
+	}
+	return arg[:i], arg[i+1:]
+}
+
+// copiesContents tells whether a source path ends with /. asking to copy the contents of a directory, not the directory itself
+func copiesContents(srcPath string) bool {
+	return strings.HasSuffix(srcPath, "/.") || srcPath == "."
+}
+
+/* copyFromContainer copies a path of a container to the local filesystem, or as a tar archive to stdout if dstPath is -.
+As docker cp does, an existing directory receives a copy of the source, while a missing destination is created as a copy of it */
+func copyFromContainer(ctx context.Context, dockerClient dockerclient.Docker, containerID string, srcPath string, dstPath string) error {
+	if dstPath == "-" {
+		output, closeOutput, err := openOutput("")
+		if err != nil {
+			return err
+		}
+		if _, err := dockerClient.CopyFromContainer(ctx, containerID, srcPath, output); err != nil {
+			closeOutput()
+			return err
+		}
+		return closeOutput()
+	}
+
+	stat, err := dockerClient.StatContainerPath(ctx, containerID, srcPath)
+	if err != nil {
+		return err
+	}
+
+	dstDir, rootName := dstPath, ""
+	info, err := os.Stat(dstPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if !stat.Mode.IsDir() && strings.HasSuffix(dstPath, string(filepath.Separator)) {
+			return fmt.Errorf("destination directory %s does not exist", dstPath)
+		}
+		dstDir, rootName = filepath.Dir(dstPath), filepath.Base(dstPath)
+	case err != nil:
+		return err
+	case !info.IsDir():
+		if stat.Mode.IsDir() {
+			return fmt.Errorf("cannot copy directory %s to file %s", srcPath, dstPath)
+		}
+		dstDir, rootName = filepath.Dir(dstPath), filepath.Base(dstPath)
+	case copiesContents(srcPath):
+		rootName = "."
+	}
+
+	// The archive is extracted as it is received
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := dockerClient.CopyFromContainer(ctx, containerID, srcPath, writer)
+		writer.CloseWithError(err)
+	}()
+	err = dockerclient.ExtractArchive(reader, dstDir, rootName)
+	reader.CloseWithError(err)
+	return err
+}
+
+/* copyToContainer copies a local path into a container, or the tar archive read from stdin if srcPath is -.
+As docker cp does, an existing directory receives a copy of the source, while a missing destination is created as a copy of it */
+func copyToContainer(ctx context.Context, dockerClient dockerclient.Docker, srcPath string, containerID string, dstPath string, archiveMode bool) error {
+	options := dockerclient.CopyToContainerOptions{CopyUIDGID: archiveMode}
+	if srcPath == "-" {
+		return dockerClient.CopyToContainer(ctx, containerID, dstPath, os.Stdin, options)
+	}
+
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	dstDir, rootName := dstPath, ""
+	stat, err := dockerClient.StatContainerPath(ctx, containerID, dstPath)
+	switch {
+	case errors.Is(err, dockerclient.ErrContainerPathDoesNotExist):
+		if !info.IsDir() && strings.HasSuffix(dstPath, "/") {
+			return fmt.Errorf("destination directory %s does not exist", dstPath)
+		}
+		dstDir, rootName = path.Dir(dstPath), path.Base(dstPath)
+	case err != nil:
+		return err
+	case !stat.Mode.IsDir():
+		if info.IsDir() {
+			return fmt.Errorf("cannot copy directory %s to file %s", srcPath, dstPath)
+		}
+		dstDir, rootName = path.Dir(dstPath), path.Base(dstPath)
+	case copiesContents(filepath.ToSlash(srcPath)):
+		rootName = "."
+	}
+
+	archive, err := dockerclient.ArchivePath(srcPath, rootName)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return dockerClient.CopyToContainer(ctx, containerID, dstDir, archive, options)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// runExec runs a command inside a running container, optionally wiring the local terminal to it
+func runExec(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("exec")
+	interactive := flags.Bool("i", false, "keep stdin open and send it to the command")
+	tty := flags.Bool("t", false, "allocate a pseudo-TTY")
+	interactiveTTY := flags.Bool("it", false, "same as -i -t")
+	user := flags.String("u", "", "user (and optionally group) to run the command as")
+	workingDir := flags.String("w", "", "working directory inside the container")
+	var env stringList
+	flags.Var(&env, "env", "environment variable KEY=value to set (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("exec needs a container and a command")
+	}
+	if *interactiveTTY {
+		*interactive, *tty = true, true
+	}
+
+	containerID, cmd := flags.Arg(0), flags.Args()[1:]
+	execID, err := dockerClient.GenerateExecInstanceWithOptions(ctx, containerID, dockerclient.ExecOptions{
+		Cmd:         cmd,
+		Tty:         *tty,
+		AttachStdin: *interactive,
+		Env:         env,
+		WorkingDir:  *workingDir,
+		User:        *user,
+	})
+	if err != nil {
+		return err
+	}
+
+	conn, err := dockerClient.StartInteractiveExecInstance(ctx, execID, *tty)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The local terminal goes raw, so every key stroke (including Ctrl+C) reaches the process inside the container.
+	// Without -i nothing is read from it, so it is left as it is
+	if *interactive && *tty && isTerminal(os.Stdin) {
+		restore, err := makeRaw(os.Stdin)
+		if err != nil {
+			return err
+		}
+		defer restore()
+
+		stopResizing := forwardResizes(ctx, os.Stdin, func(height uint, width uint) {
+			dockerClient.ResizeExecInstance(ctx, execID, height, width) // A failed resize is not worth stopping the session
+		})
+		defer stopResizing()
+	}
+
+	if *interactive {
+		go func() {
+			io.Copy(conn, os.Stdin)
+			conn.CloseWrite()
+		}()
+	}
+
+	if *tty {
+		_, err = io.Copy(os.Stdout, conn)
+	} else {
+		_, err = dockerclient.StdCopy(os.Stdout, os.Stderr, conn)
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	execInstance, err := dockerclient.WaitForExecInstance(ctx, dockerClient, execID)
+	if err != nil {
+		return err
+	}
+	if execInstance.ExitCode != 0 {
+		return exitCodeError(execInstance.ExitCode)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// runImages runs the image subcommand given in args[0], listing images if there is none
+func runImages(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runImagesList(ctx, dockerClient, args)
+	}
+
+	switch args[0] {
+	case "ls":
+		return runImagesList(ctx, dockerClient, args[1:])
+	case "inspect":
+		return runImagesInspect(ctx, dockerClient, args[1:])
+	case "tag":
+		return runImagesTag(ctx, dockerClient, args[1:])
+	case "rm":
+		return runImagesRm(ctx, dockerClient, args[1:])
+	case "history":
+		return runImagesHistory(ctx, dockerClient, args[1:])
+	case "prune":
+		return runImagesPrune(ctx, dockerClient, args[1:])
+	default:
+		newFlagSet("images").Usage()
+		return fmt.Errorf("unknown images subcommand %q", args[0])
+	}
+}
+
+// runImagesList lists images as a table, one row per reference
+func runImagesList(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("images")
+	all := flags.Bool("a", false, "show intermediate images as well")
+	digests := flags.Bool("digests", false, "show digests")
+	quiet := flags.Bool("q", false, "only show image IDs")
+	var filterList stringList
+	flags.Var(&filterList, "f", "filter output as key=value, with key being dangling, reference, label, before or since (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := dockerclient.ListImagesOptions{All: *all, Digests: *digests}
+	var err error
+	if options.Filters, err = parseFilters(filterList); err != nil {
+		return err
+	}
+
+	images, err := dockerClient.ListImages(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		for _, image := range images {
+			fmt.Println(shortID(image.ID))
+		}
+		return nil
+	}
+
+	writer := newTableWriter(os.Stdout)
+	if *digests {
+		fmt.Fprintln(writer, "REPOSITORY\tTAG\tDIGEST\tIMAGE ID\tCREATED\tSIZE")
+	} else {
+		fmt.Fprintln(writer, "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tSIZE")
+	}
+	for _, image := range images {
+		for _, ref := range image.RepoTags {
+			repository, tag := splitReference(ref)
+			row := repository + "\t" + tag
+			if *digests {
+				row += "\t" + repositoryDigest(image.RepoDigests, repository)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", row, shortID(image.ID), sinceUnix(image.Created), humanSize(image.Size))
+		}
+	}
+	return writer.Flush()
+}
+
+// runImagesInspect prints the low-level information of one or more images as JSON
+func runImagesInspect(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("images")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("images inspect needs at least one image")
+	}
+
+	images := make([]*models.InspectImageResponseBody, 0, flags.NArg())
+	for _, name := range flags.Args() {
+		image, err := dockerClient.InspectImage(ctx, name)
+		if err != nil {
+			return err
+		}
+		images = append(images, image)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(images)
+}
+
+// runImagesTag adds a reference to an image
+func runImagesTag(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("images")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("images tag needs a source image and a target reference")
+	}
+
+	repository, tag := splitReference(flags.Arg(1))
+	return dockerClient.TagImage(ctx, flags.Arg(0), repository, tag)
+}
+
+// runImagesRm removes one or more images, printing the references untagged and the images deleted
+func runImagesRm(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("images")
+	force := flags.Bool("f", false, "remove images used by stopped containers or referenced in multiple repositories")
+	noPrune := flags.Bool("noprune", false, "keep the untagged parents of the images")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("images rm needs at least one image")
+	}
+
+	failed := false
+	for _, name := range flags.Args() {
+		items, err := dockerClient.RemoveImage(ctx, name, dockerclient.RemoveImageOptions{Force: *force, NoPrune: *noPrune})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot remove image %s: %s\n", name, err)
+			failed = true
+			continue
+		}
+		printDeletedImages(items)
+	}
+	if failed {
+		return errors.New("images rm failed for some images")
+	}
+	return nil
+}
+
+// runImagesHistory prints the layers of an image as a table
+func runImagesHistory(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("images")
+	noTrunc := flags.Bool("notrunc", false, "don't truncate the commands that created the layers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("images history needs an image")
+	}
+
+	history, err := dockerClient.ImageHistory(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	writer := newTableWriter(os.Stdout)
+	fmt.Fprintln(writer, "IMAGE\tCREATED\tCREATED BY\tSIZE\tCOMMENT")
+	for _, layer := range history {
+		id := layer.ID
+		if id != "<missing>" {
+			id = shortID(id)
+		}
+		createdBy := strings.Join(strings.Fields(layer.CreatedBy), " ")
+		if !*noTrunc {
+			createdBy = truncate(createdBy, 45)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", id, sinceUnix(layer.Created), createdBy, humanSize(layer.Size), layer.Comment)
+	}
+	return writer.Flush()
+}
+
+// runImagesPrune removes the dangling images, or every unused image with -a, printing the space reclaimed
+func runImagesPrune(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("images")
+	all := flags.Bool("a", false, "remove every image not used by a container, not only dangling ones")
+	var filterList stringList
+	flags.Var(&filterList, "f", "filter images as key=value, with key being until, label or label! (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filters, err := parseFilters(filterList)
+	if err != nil {
+		return err
+	}
+	if *all {
+		filters.Add("dangling", "false")
+	}
+
+	report, err := dockerClient.PruneImages(ctx, filters)
+	if err != nil {
+		return err
+	}
+	if len(report.ImagesDeleted) > 0 {
+		fmt.Println("Deleted Images:")
+		printDeletedImages(report.ImagesDeleted)
+		fmt.Println()
+	}
+	fmt.Printf("Total reclaimed space: %s\n", humanSize(int64(report.SpaceReclaimed)))
+	return nil
+}
+
+// repositoryDigest returns the digest an image has in a repository, or <none> if it has none there
+func repositoryDigest(repoDigests []string, repository string) string {
+	for _, repoDigest := range repoDigests {
+		if strings.HasPrefix(repoDigest, repository+"@") {
+			return strings.TrimPrefix(repoDigest, repository+"@")
+		}
+	}
+	return "<none>"
+}
+
+// printDeletedImages prints the references untagged and the images deleted, the way docker does
+func printDeletedImages(items []models.ImageDeleteResponseItem) {
+	for _, item := range items {
+		if item.Untagged != "" {
+			fmt.Printf("Untagged: %s\n", item.Untagged)
+		}
+		if item.Deleted != "" {
+			fmt.Printf("Deleted: %s\n", item.Deleted)
+		}
+	}
+}
+
+// splitReference splits an image reference into repository and tag, the tag defaulting to latest
+func splitReference(ref string) (string, string) {
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, "latest"
+}
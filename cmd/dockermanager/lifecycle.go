@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// runStop stops one or more containers
+func runStop(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("stop")
+	timeout := flags.Int("t", -1, "seconds to wait before killing the container (the container default if not set)")
+	signal := flags.String("s", "", "signal sent to stop the container (the container stop signal if not set)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := stopOptions(*timeout, *signal)
+	return forEachContainer(flags, "stop", func(containerID string) error {
+		_, err := dockerClient.StopContainerWithOptions(ctx, containerID, options)
+		return err
+	})
+}
+
+// runRestart restarts one or more containers
+func runRestart(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("restart")
+	timeout := flags.Int("t", -1, "seconds to wait before killing the container (the container default if not set)")
+	signal := flags.String("s", "", "signal sent to stop the container (the container stop signal if not set)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := stopOptions(*timeout, *signal)
+	return forEachContainer(flags, "restart", func(containerID string) error {
+		return dockerClient.RestartContainer(ctx, containerID, options)
+	})
+}
+
+// runKill sends a signal to one or more running containers
+func runKill(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("kill")
+	signal := flags.String("s", "SIGKILL", "signal sent to the container, by name or number")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return forEachContainer(flags, "kill", func(containerID string) error {
+		return dockerClient.KillContainer(ctx, containerID, *signal)
+	})
+}
+
+// runPause suspends every process of one or more containers
+func runPause(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("pause")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return forEachContainer(flags, "pause", func(containerID string) error {
+		return dockerClient.PauseContainer(ctx, containerID)
+	})
+}
+
+// runUnpause resumes every process of one or more paused containers
+func runUnpause(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("unpause")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return forEachContainer(flags, "unpause", func(containerID string) error {
+		return dockerClient.UnpauseContainer(ctx, containerID)
+	})
+}
+
+// runRm removes one or more containers
+func runRm(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("rm")
+	force := flags.Bool("f", false, "kill and remove running containers")
+	volumes := flags.Bool("v", false, "remove the anonymous volumes of the containers")
+	link := flags.Bool("l", false, "remove the legacy link given instead of the container")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := dockerclient.RemoveContainerOptions{Force: *force, RemoveVolumes: *volumes, RemoveLinks: *link}
+	return forEachContainer(flags, "rm", func(containerID string) error {
+		return dockerClient.RemoveContainerWithOptions(ctx, containerID, options)
+	})
+}
+
+// runPrune removes every stopped container matching the filters given
+func runPrune(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("prune")
+	var filterList stringList
+	flags.Var(&filterList, "f", "filter containers as key=value, with key being until, label or label! (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filters, err := parseFilters(filterList)
+	if err != nil {
+		return err
+	}
+
+	report, err := dockerClient.PruneContainers(ctx, filters)
+	if err != nil {
+		return err
+	}
+	if len(report.ContainersDeleted) > 0 {
+		fmt.Println("Deleted Containers:")
+		for _, id := range report.ContainersDeleted {
+			fmt.Println(id)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Total reclaimed space: %s\n", humanSize(int64(report.SpaceReclaimed)))
+	return nil
+}
+
+// stopOptions builds the stop options from the command flags. A negative timeout leaves the container default
+func stopOptions(timeout int, signal string) dockerclient.StopOptions {
+	options := dockerclient.StopOptions{Signal: signal}
+	if timeout >= 0 {
+		options.Timeout = &timeout
+	}
+	return options
+}
+
+/* forEachContainer runs operation on every container given as argument, printing the ones it succeeded for.
+Failures are printed as well, without stopping the rest of the containers, and make the command fail */
+func forEachContainer(flags *flag.FlagSet, cmd string, operation func(containerID string) error) error {
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("%s needs at least one container", cmd)
+	}
+
+	failed := false
+	for _, containerID := range flags.Args() {
+		if err := operation(containerID); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot %s container %s: %s\n", cmd, containerID, err)
+			failed = true
+			continue
+		}
+		fmt.Println(containerID)
+	}
+	if failed {
+		return errors.New(cmd + " failed for some containers")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// runLogs prints the logs of a container, optionally following them
+func runLogs(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("logs")
+	follow := flags.Bool("f", false, "follow the logs until the container stops")
+	timestamps := flags.Bool("t", false, "show timestamps")
+	tail := flags.Int("n", -1, "number of lines to show from the end of the logs (all if negative)")
+	since := flags.String("since", "", "show logs since a timestamp (e.g. 2021-06-01T10:00:00Z) or a relative time (e.g. 30m)")
+	until := flags.String("until", "", "show logs before a timestamp (e.g. 2021-06-01T10:00:00Z) or a relative time (e.g. 30m)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("logs needs a container")
+	}
+
+	options := dockerclient.LogsOptions{Follow: *follow, Timestamps: *timestamps}
+	if *tail >= 0 {
+		options.Tail = tail
+	}
+	var err error
+	if options.Since, err = parseTime(*since); err != nil {
+		return err
+	}
+	if options.Until, err = parseTime(*until); err != nil {
+		return err
+	}
+
+	err = dockerClient.ContainerLogs(ctx, flags.Arg(0), options, os.Stdout, os.Stderr)
+	if errors.Is(err, context.Canceled) {
+		return nil // Following was interrupted by the user
+	}
+	return err
+}
+
+/* parseTime parses a time given either as RFC3339 timestamp, seconds since epoch or a duration relative to now
+(e.g. 30m means 30 minutes ago). Empty values return the zero time */
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Unix(0, int64(seconds*float64(time.Second))), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a timestamp (e.g. 2021-06-01T10:00:00Z) or a duration (e.g. 30m)", value)
+}
@@ -2,14 +2,20 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
 	"github.com/mikeletux/go-docker-manager/pkg/httpclient"
 
 	"github.com/gosuri/uilive" // library for updating terminal in real time :)
@@ -21,17 +27,35 @@ const (
 	DockerImageArch     = "x86-64"
 	DockerContainerName = "ubuntu2004"
 
-	DefaultDockerEndpoint = "http://localhost:2375"
+	DefaultDockerEndpoint = httpclient.UnixSocketScheme + httpclient.DefaultUnixSocketPath
 )
 
 var (
 	printHelp      bool
 	dockerEndpoint string
+	useTLS         bool
+	tlsVerify      bool
+	tlsCertPath    string
+	tlsCACert      string
+	tlsCert        string
+	tlsKey         string
+	healthCmd      string
+	healthInterval time.Duration
+	networkName    string
 )
 
 func init() {
 	flag.BoolVar(&printHelp, "h", false, "shows help")
 	flag.StringVar(&dockerEndpoint, "e", DefaultDockerEndpoint, "docker endpoint to connect")
+	flag.BoolVar(&useTLS, "tls", false, "use TLS without verifying the docker daemon certificate")
+	flag.BoolVar(&tlsVerify, "tlsverify", false, "use TLS and verify the docker daemon certificate (implies -tls)")
+	flag.StringVar(&tlsCertPath, "tlscertpath", defaultCertPath(), "directory holding ca.pem, cert.pem and key.pem")
+	flag.StringVar(&tlsCACert, "tlscacert", "", "CA certificate used to verify the docker daemon (overrides -tlscertpath)")
+	flag.StringVar(&tlsCert, "tlscert", "", "client certificate (overrides -tlscertpath)")
+	flag.StringVar(&tlsKey, "tlskey", "", "client key (overrides -tlscertpath)")
+	flag.StringVar(&healthCmd, "healthcmd", "", "shell command checking the health of the monitored container, which is waited to be healthy")
+	flag.DurationVar(&healthInterval, "healthinterval", 5*time.Second, "time between two runs of -healthcmd")
+	flag.StringVar(&networkName, "network", "", "network the monitored container is attached to (the default bridge if empty)")
 	flag.Usage = usage
 }
 
@@ -47,125 +71,225 @@ func main() {
 		dockerEndpoint = envVar
 	}
 
-	simpleHttpClient := httpclient.NewSimpleHttpClient()
+	clientTLSOptions, err := tlsOptions()
+	if err != nil {
+		log.Fatal(err)
+	}
+	simpleHttpClient, baseURL, err := httpclient.NewSimpleHttpClientForEndpoint(dockerEndpoint, clientTLSOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dockerClient := dockerclient.NewSimpeDocker(baseURL, simpleHttpClient)
+
+	// ctx is cancelled when the program receives SIGINT or SIGTERM, aborting any in-flight query
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Any argument left is a subcommand, otherwise the container monitor is launched
+	if flag.NArg() > 0 {
+		err = runCommand(ctx, dockerClient, flag.Args())
+		var exitErr exitCodeError
+		if errors.As(err, &exitErr) {
+			stop()
+			os.Exit(int(exitErr))
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	runMonitor(ctx, dockerClient)
+}
 
+/* runMonitor spans a container, shows its live resource usage until the user types "e",
+and destroys it afterwards */
+func runMonitor(ctx context.Context, dockerClient dockerclient.Docker) {
 	log.Printf("docker manager set to %s", dockerEndpoint)
-	dockerClient := dockerclient.NewSimpeDocker(dockerEndpoint, simpleHttpClient)
 
-	exists, err := dockerClient.CheckIfImageAlreadyExists(DockerImage, DockerImageTag)
+	exists, err := dockerClient.CheckIfImageAlreadyExistsContext(ctx, DockerImage, DockerImageTag)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	if !exists {
 		log.Printf("couldn't find image %s:%s locally, downloading...", DockerImage, DockerImageTag)
-		err = dockerClient.PullImageFromRegistry(DockerImage, DockerImageTag, DockerImageArch)
+		auth, err := registryAuth(DockerImage)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printer := newProgressPrinter(os.Stdout)
+		err = dockerClient.PullImageWithAuth(ctx, DockerImage, DockerImageTag, DockerImageArch, auth, printer.Print)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 
 	log.Printf("initiating container %s from image %s:%s", DockerContainerName, DockerImage, DockerImageTag)
-	containerID, err := dockerClient.CreateContainer(DockerContainerName, DockerImage, DockerImageTag, []string{"sleep", "infinity"})
+	options := dockerclient.ContainerOptions{
+		Image:       fmt.Sprintf("%s:%s", DockerImage, DockerImageTag),
+		Cmd:         []string{"sleep", "infinity"},
+		NetworkMode: networkName,
+	}
+	readyState := dockerclient.StateRunning
+	if healthCmd != "" {
+		options.Healthcheck = &models.HealthConfig{Test: []string{"CMD-SHELL", healthCmd}, Interval: healthInterval}
+		readyState = dockerclient.StateHealthy
+	}
+	containerID, err := dockerClient.CreateContainerWithOptions(ctx, DockerContainerName, options)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = dockerClient.RunContainer(containerID)
+	err = dockerClient.RunContainerContext(ctx, containerID)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Print("waiting for container to be in running state...")
-	timeoutTicket := time.NewTicker(180 * time.Second)
-	checkTicket := time.NewTicker(time.Second)
-out:
-	for {
-		select {
-		case <-timeoutTicket.C:
-			log.Fatal("the container didn't get into running status for 180s")
-		case <-checkTicket.C:
-			status, err := dockerClient.CheckIfContainerIsReady(containerID)
-			if err != nil {
-				log.Fatal(err)
-			}
-			if status {
-				break out
-			}
-		}
-	}
+	log.Printf("waiting for container to be %s...", readyState)
+	err = waitForContainer(ctx, dockerClient, containerID, readyState, 180*time.Second)
+	if err != nil {
+		log.Print(err)
+		printLastLogs(dockerClient, containerID)
+	} else {
+		// monitorCtx is cancelled either when the user types "e" or when ctx is done
+		monitorCtx, cancelMonitor := context.WithCancel(ctx)
 
-	var wg sync.WaitGroup
+		var wg sync.WaitGroup
 
-	done := make(chan bool) // This channel allow communication between executeCommandAndPrint and readKeyboardEvent go routines
+		// go routine that outputs the container stats
+		wg.Add(1)
+		go printStats(monitorCtx, &wg, dockerClient, containerID)
 
-	// go routine that outputs the container commands
-	wg.Add(1)
-	go executeCommandAndPrint(&wg, dockerClient, containerID, done)
+		// go routine that listens to keyboard event to finish. It is not waited for, since reading stdin cannot be interrupted
+		go readKeyboardEvent(cancelMonitor)
 
-	// go routine that listens to keyboard event to finish
-	wg.Add(1)
-	go readKeyboardEvent(&wg, done)
+		wg.Wait()
+		cancelMonitor()
+	}
 
-	wg.Wait()
+	// ctx may be already cancelled at this point, so cleanup runs with its own deadline
+	cleanupCtx, cancelCleanup := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelCleanup()
 
-	log.Println("Stopping the container, please wait...")
-	_, err = dockerClient.StopContainer(containerID)
+	// The container is killed and removed in one go, alongside its anonymous volumes
+	log.Println("Removing the container, please wait...")
+	err = dockerClient.RemoveContainerWithOptions(cleanupCtx, containerID, dockerclient.RemoveContainerOptions{Force: true, RemoveVolumes: true})
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	log.Println("Removing the container, please wait...")
-	err = dockerClient.RemoveContainer(containerID)
+// waitForContainer waits until the container is in the state given (running or healthy), ctx is done or the timeout expires
+func waitForContainer(ctx context.Context, dockerClient dockerclient.Docker, containerID string, state dockerclient.ReadyState, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := dockerclient.WaitForState(ctx, dockerClient, containerID, state, dockerclient.DefaultBackoff)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("the container didn't get into %s status for %s", state, timeout)
+	}
+	return err
+}
+
+// printLastLogs prints the last lines written by a container, which usually tell why it is not running
+func printLastLogs(dockerClient dockerclient.Docker, containerID string) {
+	// The logs are printed even if the user interrupted the program
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log.Print("last logs of the container:")
+	tail := 20
+	err := dockerClient.ContainerLogs(ctx, containerID, dockerclient.LogsOptions{Tail: &tail}, os.Stderr, os.Stderr)
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
 	}
 }
 
-func executeCommandAndPrint(wg *sync.WaitGroup, dockerClient dockerclient.Docker, containerID string, done <-chan bool) {
+// printStats streams the resource usage of the container and prints it in place until ctx is done or the stream fails
+func printStats(ctx context.Context, wg *sync.WaitGroup, dockerClient dockerclient.Docker, containerID string) {
 	defer wg.Done()
 
-	ticker := time.NewTicker(800 * time.Millisecond)
 	writer := uilive.New()
 	writer.Start()
+	defer writer.Stop()
 
-	for {
-		select {
-		case <-done:
-			writer.Stop()
-			return
-		case <-ticker.C:
-			execID, err := dockerClient.GenerateExecInstance(containerID, []string{"/bin/sh", "-c", "top -b -n 1 | head -4 | tail -2"})
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			output, err := dockerClient.StartExecInstance(execID)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			fmt.Fprintf(writer, "Type \"e\" and press ENTER to finish\n%s", output)
-		}
+	err := dockerClient.StreamStats(ctx, containerID, func(stats dockerclient.ContainerStats) {
+		fmt.Fprintf(writer, "Type \"e\" and press ENTER to finish\n"+
+			"CPU %.2f%%   MEM %s / %s (%.2f%%)   NET I/O %s / %s   BLOCK I/O %s / %s   PIDS %d\n",
+			stats.CPUPercentage,
+			humanSize(int64(stats.MemoryUsage)), humanSize(int64(stats.MemoryLimit)), stats.MemoryPercentage,
+			humanSize(int64(stats.NetworkRx)), humanSize(int64(stats.NetworkTx)),
+			humanSize(int64(stats.BlockRead)), humanSize(int64(stats.BlockWrite)),
+			stats.PIDs)
+	})
+	if err != nil && ctx.Err() == nil {
+		// The error is not fatal, so the container is still removed once the monitor finishes
+		log.Printf("cannot stream the container stats - %s", err)
 	}
 }
 
-func readKeyboardEvent(wg *sync.WaitGroup, done chan bool) {
-	defer wg.Done()
+func readKeyboardEvent(done context.CancelFunc) {
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		if scanner.Text() == "e" {
-			done <- true
+			done()
 			return
 		}
 	}
 }
 
+// defaultCertPath returns the directory docker looks for certificates in by default
+func defaultCertPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".docker")
+}
+
+/* tlsOptions builds the TLS configuration from flags and env vars, following the docker CLI conventions.
+It returns nil if TLS is not enabled, or an error if certificates are given without enabling it, since they would be ignored */
+func tlsOptions() (*httpclient.TLSOptions, error) {
+	// ENV vars have higher priority than flags if set
+	if envVar, present := os.LookupEnv("DOCKER_TLS_VERIFY"); present && envVar != "" {
+		tlsVerify = true
+	}
+	if envVar, present := os.LookupEnv("DOCKER_CERT_PATH"); present && envVar != "" {
+		tlsCertPath = envVar
+	}
+
+	if !useTLS && !tlsVerify {
+		if tlsCACert != "" || tlsCert != "" || tlsKey != "" {
+			return nil, errors.New("-tlscacert, -tlscert and -tlskey need -tls or -tlsverify")
+		}
+		return nil, nil
+	}
+
+	options := httpclient.NewTLSOptionsFromCertPath(tlsCertPath, tlsVerify)
+	if tlsCACert != "" {
+		options.CAFile = tlsCACert
+	}
+	if tlsCert != "" {
+		options.CertFile = tlsCert
+	}
+	if tlsKey != "" {
+		options.KeyFile = tlsKey
+	}
+	return options, nil
+}
+
 func usage() {
 	fmt.Fprintf(os.Stderr, `Go Docker Manager v0.1.0
-Usage: dockermanager [-e endpoint]
+Usage: dockermanager [-e endpoint] [-tls | -tlsverify] [-tlscertpath dir] [-tlscacert file] [-tlscert file] [-tlskey file]
+                     [-healthcmd command] [-healthinterval duration] [command]
+
+Without command, a container is spanned and its CPU/Memory usage is shown live.
 
-Options:
+Commands:
 `)
+	printCommands()
+	fmt.Fprintf(os.Stderr, "\nOptions:\n")
 	flag.PrintDefaults()
 }
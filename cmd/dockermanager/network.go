@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// runNetwork runs the network subcommand given in args[0], listing networks if there is none
+func runNetwork(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runNetworkList(ctx, dockerClient, args)
+	}
+
+	switch args[0] {
+	case "ls":
+		return runNetworkList(ctx, dockerClient, args[1:])
+	case "create":
+		return runNetworkCreate(ctx, dockerClient, args[1:])
+	case "inspect":
+		return runNetworkInspect(ctx, dockerClient, args[1:])
+	case "rm":
+		return runNetworkRm(ctx, dockerClient, args[1:])
+	case "prune":
+		return runNetworkPrune(ctx, dockerClient, args[1:])
+	case "connect":
+		return runNetworkConnect(ctx, dockerClient, args[1:])
+	case "disconnect":
+		return runNetworkDisconnect(ctx, dockerClient, args[1:])
+	default:
+		newFlagSet("network").Usage()
+		return fmt.Errorf("unknown network subcommand %q", args[0])
+	}
+}
+
+// runNetworkList lists networks as a table
+func runNetworkList(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	quiet := flags.Bool("q", false, "only show network IDs")
+	var filterList stringList
+	flags.Var(&filterList, "f", "filter output as key=value, with key being name, id, driver, label, scope or type (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filters, err := parseFilters(filterList)
+	if err != nil {
+		return err
+	}
+	networks, err := dockerClient.ListNetworks(ctx, filters)
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		for _, network := range networks {
+			fmt.Println(shortID(network.ID))
+		}
+		return nil
+	}
+
+	writer := newTableWriter(os.Stdout)
+	fmt.Fprintln(writer, "NETWORK ID\tNAME\tDRIVER\tSCOPE")
+	for _, network := range networks {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", shortID(network.ID), network.Name, network.Driver, network.Scope)
+	}
+	return writer.Flush()
+}
+
+// runNetworkCreate creates a network, printing its ID
+func runNetworkCreate(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	var options dockerclient.CreateNetworkOptions
+	var labels, driverOptions stringList
+	flags.StringVar(&options.Driver, "driver", "", "driver managing the network (bridge by default)")
+	flags.StringVar(&options.Subnet, "subnet", "", "subnet of the network in CIDR notation, e.g. 10.1.0.0/16")
+	flags.StringVar(&options.IPRange, "iprange", "", "range of the subnet containers get their addresses from")
+	flags.StringVar(&options.Gateway, "gateway", "", "gateway of the subnet")
+	flags.BoolVar(&options.Internal, "internal", false, "restrict external access to the network")
+	flags.BoolVar(&options.Attachable, "attachable", false, "let standalone containers attach to a swarm network")
+	flags.Var(&labels, "label", "label of the network as key=value (can be repeated)")
+	flags.Var(&driverOptions, "opt", "driver option as key=value (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("network create needs a network name")
+	}
+
+	var err error
+	if options.Labels, err = parseKeyValues(labels, "label"); err != nil {
+		return err
+	}
+	if options.Options, err = parseKeyValues(driverOptions, "driver option"); err != nil {
+		return err
+	}
+
+	networkID, err := dockerClient.CreateNetwork(ctx, flags.Arg(0), options)
+	if err != nil {
+		return err
+	}
+	fmt.Println(networkID)
+	return nil
+}
+
+// runNetworkInspect prints the low-level information of one or more networks as JSON
+func runNetworkInspect(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("network inspect needs at least one network")
+	}
+
+	networks := make([]*models.NetworkResource, 0, flags.NArg())
+	for _, name := range flags.Args() {
+		network, err := dockerClient.InspectNetwork(ctx, name)
+		if err != nil {
+			return err
+		}
+		networks = append(networks, network)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(networks)
+}
+
+// runNetworkRm removes one or more networks, printing the name of each network removed
+func runNetworkRm(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("network rm needs at least one network")
+	}
+
+	failed := false
+	for _, name := range flags.Args() {
+		if err := dockerClient.RemoveNetwork(ctx, name); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot remove network %s: %s\n", name, err)
+			failed = true
+			continue
+		}
+		fmt.Println(name)
+	}
+	if failed {
+		return errors.New("network rm failed for some networks")
+	}
+	return nil
+}
+
+// runNetworkPrune removes the user-defined networks no container is attached to, printing their names
+func runNetworkPrune(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	var filterList stringList
+	flags.Var(&filterList, "f", "filter networks as key=value, with key being until, label or label! (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filters, err := parseFilters(filterList)
+	if err != nil {
+		return err
+	}
+	deleted, err := dockerClient.PruneNetworks(ctx, filters)
+	if err != nil {
+		return err
+	}
+	if len(deleted) > 0 {
+		fmt.Println("Deleted Networks:")
+		for _, name := range deleted {
+			fmt.Println(name)
+		}
+	}
+	return nil
+}
+
+// runNetworkConnect attaches a container to a network
+func runNetworkConnect(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	var options dockerclient.EndpointOptions
+	var aliases stringList
+	flags.Var(&aliases, "alias", "name the container can be reached by in the network (can be repeated)")
+	flags.StringVar(&options.IPv4Address, "ip", "", "static IPv4 address of the container in the network")
+	flags.StringVar(&options.IPv6Address, "ip6", "", "static IPv6 address of the container in the network")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("network connect needs a network and a container")
+	}
+
+	options.Aliases = aliases
+	return dockerClient.ConnectNetwork(ctx, flags.Arg(0), flags.Arg(1), options)
+}
+
+// runNetworkDisconnect detaches a container from a network
+func runNetworkDisconnect(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("network")
+	force := flags.Bool("f", false, "disconnect the container even if the network is no longer available")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("network disconnect needs a network and a container")
+	}
+
+	return dockerClient.DisconnectNetwork(ctx, flags.Arg(0), flags.Arg(1), *force)
+}
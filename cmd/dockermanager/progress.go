@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gosuri/uilive"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+/* progressPrinter renders docker JSON progress streams (pull, push) in real time, keeping one line
+per layer the way the docker CLI does */
+type progressPrinter struct {
+	writer *uilive.Writer
+	ids    []string          // layer IDs in order of appearance
+	lines  map[string]string // latest line of every layer
+	status string            // latest message not tied to a layer
+}
+
+// newProgressPrinter returns a progressPrinter that writes to out
+func newProgressPrinter(out io.Writer) *progressPrinter {
+	writer := uilive.New()
+	writer.Out = out
+
+	return &progressPrinter{writer: writer, lines: make(map[string]string)}
+}
+
+// Print updates the output with the message given. It complies with dockerclient.ProgressFunc
+func (p *progressPrinter) Print(message models.JSONMessage) {
+	line := message.Status
+	switch {
+	case message.Error != "":
+		line = "error: " + message.Error
+	case message.ErrorDetail != nil && message.ErrorDetail.Message != "":
+		line = "error: " + message.ErrorDetail.Message
+	case message.Progress != "":
+		line = fmt.Sprintf("%s %s", message.Status, message.Progress)
+	case message.ProgressDetail.Total > 0:
+		line = fmt.Sprintf("%s %d%% of %s", message.Status,
+			message.ProgressDetail.Current*100/message.ProgressDetail.Total, humanSize(message.ProgressDetail.Total))
+	}
+	if line == "" {
+		return // Nothing to show, so the lines already printed are kept
+	}
+
+	if message.ID == "" {
+		p.status = line
+	} else {
+		if _, ok := p.lines[message.ID]; !ok {
+			p.ids = append(p.ids, message.ID)
+		}
+		p.lines[message.ID] = line
+	}
+
+	var output strings.Builder
+	for _, id := range p.ids {
+		fmt.Fprintf(&output, "%s: %s\n", id, p.lines[id])
+	}
+	if p.status != "" {
+		fmt.Fprintln(&output, p.status)
+	}
+
+	fmt.Fprint(p.writer, output.String())
+	p.writer.Flush()
+}
+
+// humanSize formats a size in bytes using binary units
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
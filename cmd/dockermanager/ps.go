@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// runPs lists containers as a table
+func runPs(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("ps")
+	all := flags.Bool("a", false, "show all containers (only running ones are shown by default)")
+	limit := flags.Int("n", 0, "show only the last n containers created, running or not")
+	size := flags.Bool("s", false, "show the size of the containers")
+	quiet := flags.Bool("q", false, "only show container IDs")
+	var filters stringList
+	flags.Var(&filters, "f", "filter output as key=value, with key being label, status, name or ancestor (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := dockerclient.ListContainersOptions{All: *all, Limit: *limit, Size: *size}
+	var err error
+	if options.Filters, err = parseFilters(filters); err != nil {
+		return err
+	}
+
+	containers, err := dockerClient.ListContainers(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		for _, container := range containers {
+			fmt.Println(shortID(container.ID))
+		}
+		return nil
+	}
+
+	writer := newTableWriter(os.Stdout)
+	header := "CONTAINER ID\tIMAGE\tCOMMAND\tCREATED\tSTATUS\tNAMES"
+	if *size {
+		header += "\tSIZE"
+	}
+	fmt.Fprintln(writer, header)
+	for _, container := range containers {
+		names := make([]string, 0, len(container.Names))
+		for _, name := range container.Names {
+			names = append(names, strings.TrimPrefix(name, "/"))
+		}
+		row := fmt.Sprintf("%s\t%s\t%q\t%s\t%s\t%s", shortID(container.ID), container.Image, truncate(container.Command, 20),
+			sinceUnix(container.Created), container.Status, strings.Join(names, ","))
+		if *size {
+			row += fmt.Sprintf("\t%s (virtual %s)", humanSize(container.SizeRw), humanSize(container.SizeRootFs))
+		}
+		fmt.Fprintln(writer, row)
+	}
+	return writer.Flush()
+}
+
+// parseFilters parses filters given as key=value into the filters of list and prune queries
+func parseFilters(filters []string) (dockerclient.Filters, error) {
+	parsed := dockerclient.Filters{}
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("bad format of filter %q, expected key=value", filter)
+		}
+		parsed.Add(parts[0], parts[1])
+	}
+	return parsed, nil
+}
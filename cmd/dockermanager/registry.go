@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// runPull pulls an image, using the credentials of its registry stored by the docker CLI if any
+func runPull(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("pull")
+	platform := flags.String("platform", DockerImageArch, "architecture of the image to pull")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("pull needs an image")
+	}
+
+	repository, tag := splitReference(flags.Arg(0))
+	auth, err := registryAuth(repository)
+	if err != nil {
+		return err
+	}
+
+	printer := newProgressPrinter(os.Stdout)
+	if err := dockerClient.PullImageWithAuth(ctx, repository, tag, *platform, auth, printer.Print); err != nil {
+		return err
+	}
+	fmt.Printf("%s:%s\n", repository, tag)
+	return nil
+}
+
+// runPush pushes an image to its registry, using the credentials of the registry stored by the docker CLI if any
+func runPush(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("push")
+	allTags := flags.Bool("a", false, "push every tag of the repository")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("push needs an image")
+	}
+
+	repository, tag := splitReference(flags.Arg(0))
+	if *allTags {
+		tag = ""
+	}
+	auth, err := registryAuth(repository)
+	if err != nil {
+		return err
+	}
+
+	printer := newProgressPrinter(os.Stdout)
+	return dockerClient.PushImage(ctx, repository, tag, auth, printer.Print)
+}
+
+/* registryAuth returns the credentials of the registry of a repository, as stored by docker login in
+the docker CLI configuration file (credential helpers included). It returns nil if there are none */
+func registryAuth(repository string) (*models.AuthConfig, error) {
+	configFile, err := dockerclient.LoadConfigFile("")
+	if err != nil {
+		return nil, err
+	}
+	return configFile.AuthConfig(dockerclient.RegistryForImage(repository))
+}
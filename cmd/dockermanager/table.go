@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/units"
+)
+
+// shortIDLength is the amount of characters IDs are truncated to in tables, as docker does
+const shortIDLength = 12
+
+// newTableWriter returns a writer that aligns tab separated columns. It must be flushed once done
+func newTableWriter(out io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+}
+
+// shortID truncates an ID to its first characters, dropping the algorithm prefix if any (e.g. sha256:)
+func shortID(id string) string {
+	if i := strings.Index(id, ":"); i >= 0 {
+		id = id[i+1:]
+	}
+	if len(id) > shortIDLength {
+		return id[:shortIDLength]
+	}
+	return id
+}
+
+// truncate shortens s to max characters, marking the cut with an ellipsis. Characters are runes, so multi-byte ones are never split
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// sinceUnix formats the time elapsed since a unix timestamp (e.g. "5 minutes ago")
+func sinceUnix(timestamp int64) string {
+	return units.HumanDuration(time.Since(time.Unix(timestamp, 0))) + " ago"
+}
@@ -0,0 +1,9 @@
+package main
+
+import "syscall"
+
+// ioctl requests to get and set the terminal attributes
+const (
+	ioctlReadTermios  = syscall.TIOCGETA
+	ioctlWriteTermios = syscall.TIOCSETA
+)
@@ -0,0 +1,25 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// isTerminal tells if f is a terminal. Terminals are not supported on this platform
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+// makeRaw is not supported on this platform
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminals are not supported on this platform")
+}
+
+// forwardResizes is not supported on this platform, so it does nothing
+func forwardResizes(ctx context.Context, f *os.File, resize func(height uint, width uint)) func() {
+	return func() {}
+}
@@ -0,0 +1,96 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// ioctl performs an ioctl system call on fd passing a pointer argument
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal tells if f is a terminal
+func isTerminal(f *os.File) bool {
+	var termios syscall.Termios
+	return ioctl(f.Fd(), ioctlReadTermios, unsafe.Pointer(&termios)) == nil
+}
+
+/* makeRaw puts the terminal f in raw mode (no echo, no line buffering, no signals generated from key strokes),
+the way cfmakeraw does. It returns a function that restores the previous state */
+func makeRaw(f *os.File) (func(), error) {
+	var previous syscall.Termios
+	if err := ioctl(f.Fd(), ioctlReadTermios, unsafe.Pointer(&previous)); err != nil {
+		return nil, err
+	}
+
+	raw := previous
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(f.Fd(), ioctlWriteTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ioctl(f.Fd(), ioctlWriteTermios, unsafe.Pointer(&previous))
+	}, nil
+}
+
+// terminalSize returns the height and width in characters of the terminal f
+func terminalSize(f *os.File) (uint, uint, error) {
+	var winsize struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	if err := ioctl(f.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&winsize)); err != nil {
+		return 0, 0, err
+	}
+	return uint(winsize.Row), uint(winsize.Col), nil
+}
+
+/* forwardResizes calls resize with the current size of the terminal f, and again every time the terminal is resized,
+until ctx is done or the returned function is called */
+func forwardResizes(ctx context.Context, f *os.File, resize func(height uint, width uint)) func() {
+	sendSize := func() {
+		if height, width, err := terminalSize(f); err == nil {
+			resize(height, width)
+		}
+	}
+	sendSize()
+
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-resized:
+				sendSize()
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(resized)
+		close(done)
+	}
+}
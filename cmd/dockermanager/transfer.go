@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient"
+)
+
+// runSave writes one or more images as a tar archive to a file, or to stdout
+func runSave(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("save")
+	outputPath := flags.String("o", "", "file to write the archive to, instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("save needs at least one image")
+	}
+
+	output, closeOutput, err := openOutput(*outputPath)
+	if err != nil {
+		return err
+	}
+	if err := dockerClient.SaveImages(ctx, flags.Args(), output); err != nil {
+		closeOutput()
+		return err
+	}
+	return closeOutput()
+}
+
+// runLoad loads the images of a tar archive read from a file, or from stdin
+func runLoad(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("load")
+	inputPath := flags.String("i", "", "file to read the archive from, instead of stdin")
+	quiet := flags.Bool("q", false, "don't print the images loaded")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := openInput(*inputPath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	loaded, err := dockerClient.LoadImage(ctx, input, nil)
+	if err != nil {
+		return err
+	}
+	if !*quiet {
+		for _, image := range loaded {
+			fmt.Printf("Loaded image: %s\n", image)
+		}
+	}
+	return nil
+}
+
+// runExport writes the filesystem of a container as a tar archive to a file, or to stdout
+func runExport(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("export")
+	outputPath := flags.String("o", "", "file to write the archive to, instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("export needs a container")
+	}
+
+	output, closeOutput, err := openOutput(*outputPath)
+	if err != nil {
+		return err
+	}
+	if err := dockerClient.ExportContainer(ctx, flags.Arg(0), output); err != nil {
+		closeOutput()
+		return err
+	}
+	return closeOutput()
+}
+
+// runImport creates an image from a filesystem tar archive read from a file, or from stdin with -, printing its ID
+func runImport(ctx context.Context, dockerClient dockerclient.Docker, args []string) error {
+	flags := newFlagSet("import")
+	var changes stringList
+	flags.Var(&changes, "c", "Dockerfile instruction to apply to the image, such as CMD or ENV (can be repeated)")
+	message := flags.String("m", "", "commit message of the image")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return errors.New("import needs a file (- for stdin) and optionally a repository[:tag]")
+	}
+
+	inputPath := flags.Arg(0)
+	if inputPath == "-" {
+		inputPath = ""
+	}
+	input, err := openInput(inputPath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	options := dockerclient.ImportImageOptions{Changes: changes, Message: *message}
+	if flags.NArg() == 2 {
+		options.Repository, options.Tag = splitReference(flags.Arg(1))
+	}
+	imageID, err := dockerClient.ImportImage(ctx, input, options, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Println(imageID)
+	return nil
+}
+
+/* openOutput opens the file given for writing, or returns stdout if path is empty. Archives are not written to
+a terminal, as docker refuses to. The function returned closes the file, reporting errors flushing it */
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "" {
+		if isTerminal(os.Stdout) {
+			return nil, nil, errors.New("refusing to write an archive to a terminal, use -o or redirect stdout")
+		}
+		return os.Stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// openInput opens the file given for reading, or returns stdin if path is empty
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
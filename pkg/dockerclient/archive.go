@@ -0,0 +1,235 @@
+package dockerclient
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+/* ArchivePath streams a local file or directory as a tar archive, ready to be copied into a container with CopyToContainer.
+The entries are rooted at rootName (the base name of srcPath if empty, or . to archive the contents of a directory).
+Permissions, modification times and owners are kept, and symbolic links are archived as links, not followed.
+The archive must be closed by the caller */
+func ArchivePath(srcPath string, rootName string) (io.ReadCloser, error) {
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot archive %s - %s", srcPath, err)
+	}
+	if rootName == "" {
+		rootName = filepath.Base(srcPath)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		archive := tar.NewWriter(writer)
+		err := addTarEntry(archive, srcPath, rootName, info, true)
+		if err == nil && info.IsDir() {
+			err = filepath.Walk(srcPath, func(filePath string, info os.FileInfo, err error) error {
+				if err != nil || filePath == srcPath {
+					return err
+				}
+				relPath, err := filepath.Rel(srcPath, filePath)
+				if err != nil {
+					return err
+				}
+				return addTarEntry(archive, filePath, path.Join(rootName, filepath.ToSlash(relPath)), info, true)
+			})
+		}
+		if err == nil {
+			err = archive.Close()
+		}
+		if err != nil {
+			err = fmt.Errorf("cannot archive %s - %s", srcPath, err)
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
+
+/* addTarEntry writes the file given to a tar archive under the name given: its header, and its content for regular files.
+Symbolic links are written as links. Owners are reset to root unless keepOwner is set */
+func addTarEntry(archive *tar.Writer, filePath string, name string, info os.FileInfo, keepOwner bool) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(filePath); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() && !strings.HasSuffix(name, "/") {
+		header.Name += "/"
+	}
+	if !keepOwner {
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(archive, file)
+	return err
+}
+
+/* ExtractArchive extracts a tar archive, such as the ones written by CopyFromContainer, into the local directory dstDir.
+If rootName is not empty, it replaces the first component of every entry name (. extracts the contents of the archived
+directory straight into dstDir). Permissions and modification times are kept, and symbolic and hard links are created
+as links. Entries that would be written out of dstDir, hard links to files out of it, and entries that are not
+directories and would replace dstDir itself, are rejected. Devices and FIFOs are skipped */
+func ExtractArchive(archive io.Reader, dstDir string, rootName string) error {
+	// Directories get their permissions and modification times once every entry is extracted, as docker cp does:
+	// read-only directories can still be filled, and their times are not changed by the entries written into them
+	type extractedDir struct {
+		target string
+		header *tar.Header
+	}
+	var dirs []extractedDir
+
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read archive - %s", err)
+		}
+
+		name, ok := entryPath(dstDir, header.Name, rootName)
+		if !ok {
+			return fmt.Errorf("archive entry %s is out of %s", header.Name, dstDir)
+		}
+		if name == "." && header.Typeflag != tar.TypeDir {
+			// Only a directory can stand for dstDir itself, anything else would replace it
+			return fmt.Errorf("archive entry %s would replace %s", header.Name, dstDir)
+		}
+		target := filepath.Join(dstDir, filepath.FromSlash(name))
+
+		// Hard links name the file they link to the same way entries are named, so they are resolved alike
+		var linkTarget string
+		if header.Typeflag == tar.TypeLink {
+			linkName, ok := entryPath(dstDir, header.Linkname, rootName)
+			if !ok || linkName == "." {
+				return fmt.Errorf("archive entry %s links to %s, which is out of %s", header.Name, header.Linkname, dstDir)
+			}
+			linkTarget = filepath.Join(dstDir, filepath.FromSlash(linkName))
+		}
+
+		if err := extractEntry(reader, header, target, linkTarget); err != nil {
+			return fmt.Errorf("cannot extract %s - %s", header.Name, err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			dirs = append(dirs, extractedDir{target: target, header: header})
+		}
+	}
+
+	// Children come after their parents in archives, so going backwards sets the times of a directory after its children's
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := setModeAndTimes(dirs[i].target, dirs[i].header); err != nil {
+			return fmt.Errorf("cannot extract %s - %s", dirs[i].header.Name, err)
+		}
+	}
+	return nil
+}
+
+/* entryPath returns the path an archive entry name stands for, relative to dstDir and slash separated, once its first
+component is replaced by rootName (if not empty). It returns false if the path is out of dstDir */
+func entryPath(dstDir string, entryName string, rootName string) (string, bool) {
+	name := path.Clean(strings.TrimPrefix(entryName, "/"))
+	if rootName != "" {
+		parts := strings.SplitN(name, "/", 2)
+		parts[0] = rootName
+		name = path.Join(parts...)
+	}
+	if name == ".." || strings.HasPrefix(name, "../") || linkInPath(dstDir, path.Dir(name)) {
+		return "", false
+	}
+	return name, true
+}
+
+// linkInPath tells whether any directory of a path relative to dir is a symbolic link, which could lead writes out of dir
+func linkInPath(dir string, relPath string) bool {
+	current := dir
+	for _, part := range strings.Split(relPath, "/") {
+		if part == "." {
+			continue
+		}
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if err != nil {
+			return false // Missing directories are created by the extraction
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/* extractEntry creates the file, directory, symbolic link or hard link (to linkTarget) of a tar entry at target. Files get
+the permissions and modification time of the entry, while directories are left writable for the entries beneath them */
+func extractEntry(reader io.Reader, header *tar.Header, target string, linkTarget string) error {
+	mode := os.FileMode(header.Mode).Perm()
+
+	// Existing files are replaced rather than written through, so links in the way are never followed
+	if existing, err := os.Lstat(target); err == nil && (header.Typeflag != tar.TypeDir || !existing.IsDir()) {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0755)
+	case tar.TypeReg:
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, reader)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		// Links are not followed, so their timestamps and permissions are left as created
+		return os.Symlink(header.Linkname, target)
+	case tar.TypeLink:
+		// Hard links share the permissions and timestamps of the file they link to
+		return os.Link(linkTarget, target)
+	default:
+		return nil // Devices and FIFOs are not copied
+	}
+
+	return setModeAndTimes(target, header)
+}
+
+// setModeAndTimes gives target the permissions and modification time of a tar entry
+func setModeAndTimes(target string, header *tar.Header) error {
+	if err := os.Chmod(target, os.FileMode(header.Mode).Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
@@ -0,0 +1,220 @@
+package dockerclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestArchivePath_ExtractArchive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions and symbolic links are not kept on windows")
+	}
+
+	// The source directory holds an executable script, a read-only file, a nested directory and a symbolic link
+	srcDir := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(filepath.Join(srcDir, "conf"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]os.FileMode{"run.sh": 0755, "conf/app.conf": 0400}
+	for name, mode := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("conf/app.conf", filepath.Join(srcDir, "app.conf")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		rootName string
+		wantRoot string // wantRoot is where the source directory is expected to be extracted, relative to the destination
+	}{
+		{name: "Keep the base name", rootName: "", wantRoot: "app"},
+		{name: "Rename the directory", rootName: "renamed", wantRoot: "renamed"},
+		{name: "Extract the contents", rootName: ".", wantRoot: "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := ArchivePath(srcDir, tt.rootName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer archive.Close()
+
+			dstDir := t.TempDir()
+			if err := ExtractArchive(archive, dstDir, ""); err != nil {
+				t.Fatalf("ExtractArchive() error = %v", err)
+			}
+
+			root := filepath.Join(dstDir, tt.wantRoot)
+			for name, mode := range files {
+				info, err := os.Stat(filepath.Join(root, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != mode {
+					t.Errorf("mode of %s = %v, want %v", name, info.Mode().Perm(), mode)
+				}
+			}
+			if info, err := os.Stat(filepath.Join(root, "conf")); err != nil || info.Mode().Perm() != 0750 {
+				t.Errorf("conf directory = %v, %v, want mode %v", info, err, os.FileMode(0750))
+			}
+			if link, err := os.Readlink(filepath.Join(root, "app.conf")); err != nil || link != "conf/app.conf" {
+				t.Errorf("app.conf = %q, %v, want a link to conf/app.conf", link, err)
+			}
+		})
+	}
+}
+
+func TestExtractArchive_DirectoryModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not kept on windows")
+	}
+
+	// A read-only directory holding a file, both with a modification time in the past
+	modTime := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	writer.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "readonly/", Mode: 0555, ModTime: modTime})
+	writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "readonly/file", Mode: 0444, Size: 4, ModTime: modTime})
+	writer.Write([]byte("data"))
+	writer.Close()
+
+	dstDir := t.TempDir()
+	err := ExtractArchive(&archive, dstDir, "")
+	defer os.Chmod(filepath.Join(dstDir, "readonly"), 0755) // Lets the temporary directory be removed
+	if err != nil {
+		t.Fatalf("ExtractArchive() error = %v", err)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dstDir, "readonly", "file")); err != nil || string(content) != "data" {
+		t.Errorf("readonly/file = %q, %v, want data", content, err)
+	}
+	info, err := os.Stat(filepath.Join(dstDir, "readonly"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0555 || !info.ModTime().Equal(modTime) {
+		t.Errorf("readonly directory mode = %v, modification time = %v, want %v and %v", info.Mode().Perm(), info.ModTime(), os.FileMode(0555), modTime)
+	}
+}
+
+func TestExtractArchive_HardLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links are not kept on windows")
+	}
+
+	// A directory holding a file, a hard link to it and a FIFO, which is skipped
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	writer.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "app/", Mode: 0755})
+	writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "app/file", Mode: 0644, Size: 4})
+	writer.Write([]byte("data"))
+	writer.WriteHeader(&tar.Header{Typeflag: tar.TypeLink, Name: "app/link", Linkname: "app/file", Mode: 0644})
+	writer.WriteHeader(&tar.Header{Typeflag: tar.TypeFifo, Name: "app/fifo", Mode: 0644})
+	writer.Close()
+
+	tests := []struct {
+		name     string
+		rootName string
+		wantRoot string // wantRoot is where the directory is expected to be extracted, relative to the destination
+	}{
+		{name: "Keep the base name", rootName: "", wantRoot: "app"},
+		{name: "Rename the directory", rootName: "renamed", wantRoot: "renamed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dstDir := t.TempDir()
+			if err := ExtractArchive(bytes.NewReader(archive.Bytes()), dstDir, tt.rootName); err != nil {
+				t.Fatalf("ExtractArchive() error = %v", err)
+			}
+
+			root := filepath.Join(dstDir, tt.wantRoot)
+			file, err := os.Stat(filepath.Join(root, "file"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			link, err := os.Stat(filepath.Join(root, "link"))
+			if err != nil {
+				t.Fatalf("ExtractArchive() did not extract the hard link - %v", err)
+			}
+			if !os.SameFile(file, link) {
+				t.Errorf("ExtractArchive() extracted link as a file of its own, want a hard link to file")
+			}
+			if _, err := os.Lstat(filepath.Join(root, "fifo")); err == nil {
+				t.Errorf("ExtractArchive() extracted the FIFO, want it skipped")
+			}
+		})
+	}
+}
+
+func TestExtractArchive_OutOfDestination(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{
+			name:    "Parent directory in the entry name",
+			headers: []*tar.Header{{Typeflag: tar.TypeReg, Name: "../escaped", Mode: 0644}},
+		},
+		{
+			name: "Entry written through a symbolic link",
+			headers: []*tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "..", Mode: 0777},
+				{Typeflag: tar.TypeReg, Name: "link/escaped", Mode: 0644},
+			},
+		},
+		{
+			name:    "Hard link to a file out of the destination",
+			headers: []*tar.Header{{Typeflag: tar.TypeLink, Name: "escaped", Linkname: "../dst/kept", Mode: 0644}},
+		},
+		{
+			name:    "File named after the destination",
+			headers: []*tar.Header{{Typeflag: tar.TypeReg, Name: ".", Mode: 0644}},
+		},
+		{
+			name:    "File named after the root",
+			headers: []*tar.Header{{Typeflag: tar.TypeReg, Name: "/.", Mode: 0644}},
+		},
+		{
+			name:    "Symbolic link with an empty name",
+			headers: []*tar.Header{{Typeflag: tar.TypeSymlink, Name: "", Linkname: "..", Mode: 0777}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive bytes.Buffer
+			writer := tar.NewWriter(&archive)
+			for _, header := range tt.headers {
+				if err := writer.WriteHeader(header); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writer.Close()
+
+			parentDir := t.TempDir()
+			dstDir := filepath.Join(parentDir, "dst")
+			if err := os.Mkdir(dstDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dstDir, "kept"), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ExtractArchive(&archive, dstDir, ""); err == nil {
+				t.Errorf("ExtractArchive() error = nil, want an error")
+			}
+			if _, err := os.Lstat(filepath.Join(parentDir, "escaped")); err == nil {
+				t.Errorf("ExtractArchive() wrote escaped out of the destination")
+			}
+			if _, err := os.Lstat(filepath.Join(dstDir, "kept")); err != nil {
+				t.Errorf("ExtractArchive() removed the contents of the destination - %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package dockerclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+const (
+	// DefaultRegistry is the registry images without registry host are pulled from (Docker Hub)
+	DefaultRegistry = "docker.io"
+	// defaultRegistryAddress is the key Docker Hub credentials are stored under in the docker CLI configuration
+	defaultRegistryAddress = "https://index.docker.io/v1/"
+	// identityTokenUsername is the username credential helpers return when the secret is an identity token
+	identityTokenUsername = "<token>"
+	// credentialsNotFound is the message credential helpers fail with when they have no credentials for a registry
+	credentialsNotFound = "credentials not found in native keychain"
+)
+
+// encodeAuthConfig encodes registry credentials the way the X-Registry-Auth header expects them: base64url-encoded JSON
+func encodeAuthConfig(auth models.AuthConfig) (string, error) {
+	encoded, err := json.Marshal(auth)
+	if err != nil {
+		return "", fmt.Errorf("json marshalling issue when encoding registry credentials - %s", err)
+	}
+	return base64.URLEncoding.EncodeToString(encoded), nil
+}
+
+// registryAuthHeaders returns the headers sending the registry credentials given, or no headers if auth is nil
+func registryAuthHeaders(auth *models.AuthConfig) (map[string]string, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	encoded, err := encodeAuthConfig(*auth)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"X-Registry-Auth": encoded}, nil
+}
+
+/* RegistryForImage returns the registry host of an image reference (e.g. registry.example.com:5000 for
+registry.example.com:5000/team/app:1.0), or DefaultRegistry if the reference has no registry host */
+func RegistryForImage(image string) string {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return DefaultRegistry
+	}
+	host := image[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return DefaultRegistry
+	}
+	return host
+}
+
+/* ConfigFile is the docker CLI configuration file (~/.docker/config.json), as far as registry credentials are concerned.
+Credentials are looked for in the credential helper of the registry, then in the credentials store, and finally
+in the auths stored in the file itself */
+type ConfigFile struct {
+	// Auths are the credentials stored in the file, by registry address
+	Auths map[string]ConfigAuth `json:"auths"`
+	// CredsStore is the credential helper (e.g. desktop, osxkeychain, pass) storing the credentials of every registry
+	CredsStore string `json:"credsStore,omitempty"`
+	// CredHelpers are the credential helpers (e.g. ecr-login) of some registries, by registry host
+	CredHelpers map[string]string `json:"credHelpers,omitempty"`
+}
+
+// ConfigAuth are the credentials of a registry as stored in the docker CLI configuration file
+type ConfigAuth struct {
+	// Auth is the base64-encoded username:password pair
+	Auth string `json:"auth,omitempty"`
+	// IdentityToken is a refresh token the registry issued on login
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+/* LoadConfigFile reads the docker CLI configuration file given. If path is empty, config.json is read from
+the directory in the DOCKER_CONFIG env var, or from ~/.docker. A missing file returns an empty configuration */
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	if path == "" {
+		dir := os.Getenv("DOCKER_CONFIG")
+		if dir == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("cannot find the docker configuration directory - %s", err)
+			}
+			dir = filepath.Join(homeDir, ".docker")
+		}
+		path = filepath.Join(dir, "config.json")
+	}
+
+	config := &ConfigFile{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read docker configuration file %s - %s", path, err)
+	}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("json unmarshalling issue when reading docker configuration file %s - %s", path, err)
+	}
+	return config, nil
+}
+
+/* AuthConfig returns the credentials of a registry given its host (as returned by RegistryForImage).
+It returns nil if there are no credentials for the registry, so images are pulled anonymously */
+func (c *ConfigFile) AuthConfig(registry string) (*models.AuthConfig, error) {
+	address := registry
+	if registry == DefaultRegistry {
+		address = defaultRegistryAddress
+	}
+
+	if helper := c.CredHelpers[registry]; helper != "" {
+		return credentialHelperAuth(helper, address)
+	}
+	if c.CredsStore != "" {
+		auth, err := credentialHelperAuth(c.CredsStore, address)
+		if err != nil || auth != nil {
+			return auth, err
+		}
+	}
+
+	key, ok := c.authKey(address)
+	if !ok {
+		return nil, nil
+	}
+	configAuth := c.Auths[key]
+	auth := &models.AuthConfig{ServerAddress: address, IdentityToken: configAuth.IdentityToken}
+	if configAuth.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(configAuth.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode the credentials of registry %s - %s", registry, err)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("cannot decode the credentials of registry %s - expected username:password", registry)
+		}
+		auth.Username, auth.Password = parts[0], parts[1]
+	}
+	return auth, nil
+}
+
+/* authKey returns the key of auths holding the credentials of a registry address: the address itself if present,
+otherwise the first key in alphabetical order with the same host (e.g. https://registry.example.com for registry.example.com),
+so the credentials picked do not depend on the order of the map */
+func (c *ConfigFile) authKey(address string) (string, bool) {
+	if _, ok := c.Auths[address]; ok {
+		return address, true
+	}
+
+	var keys []string
+	for key := range c.Auths {
+		if registryHost(key) == registryHost(address) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return keys[0], true
+}
+
+/* credentialHelperAuth asks the credential helper given (docker-credential-<helper>, which must be in the PATH)
+for the credentials of a registry address. It returns nil if the helper has none */
+func credentialHelperAuth(helper string, address string) (*models.AuthConfig, error) {
+	program := "docker-credential-" + helper
+	cmd := exec.Command(program, "get")
+	cmd.Stdin = strings.NewReader(address)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(output, credentialsNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot get the credentials of %s from %s - %s: %s", address, program, err, output)
+	}
+
+	var credentials struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &credentials); err != nil {
+		return nil, fmt.Errorf("json unmarshalling issue when reading the output of %s - %s", program, err)
+	}
+
+	auth := &models.AuthConfig{ServerAddress: address}
+	if credentials.Username == identityTokenUsername {
+		auth.IdentityToken = credentials.Secret
+	} else {
+		auth.Username, auth.Password = credentials.Username, credentials.Secret
+	}
+	return auth, nil
+}
+
+// registryHost strips the scheme and path out of a registry address (e.g. https://index.docker.io/v1/ becomes index.docker.io)
+func registryHost(address string) string {
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	if i := strings.Index(address, "/"); i >= 0 {
+		address = address[:i]
+	}
+	return address
+}
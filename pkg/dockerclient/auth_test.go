@@ -0,0 +1,161 @@
+package dockerclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+func TestEncodeAuthConfig(t *testing.T) {
+	auth := models.AuthConfig{Username: "user", Password: "p?ss>word", ServerAddress: "registry.example.com"}
+	got, err := encodeAuthConfig(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The header must be base64url-encoded JSON, with the lowercase keys the daemon expects
+	decoded, err := base64.URLEncoding.DecodeString(got)
+	if err != nil {
+		t.Fatalf("encodeAuthConfig() = %s, not base64url-encoded - %s", got, err)
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(decoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"username": "user", "password": "p?ss>word", "serveraddress": "registry.example.com"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("encodeAuthConfig() = %v, want %v", fields, want)
+	}
+}
+
+func TestRegistryForImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "ubuntu", want: "docker.io"},
+		{image: "library/ubuntu", want: "docker.io"},
+		{image: "registry.example.com/team/app", want: "registry.example.com"},
+		{image: "localhost:5000/app", want: "localhost:5000"},
+		{image: "localhost/app", want: "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := RegistryForImage(tt.image); got != tt.want {
+				t.Errorf("RegistryForImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFile_AuthConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := `{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass")) + `"},
+			"registry.example.com": {"identitytoken": "refresh-token"},
+			"helper.example.com": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("stale:stale")) + `"},
+			"https://exact.example.com": {"identitytoken": "scheme-token"},
+			"exact.example.com": {"identitytoken": "exact-token"},
+			"https://schemes.example.com": {"identitytoken": "https-token"},
+			"http://schemes.example.com": {"identitytoken": "http-token"}
+		},
+		"credHelpers": {"helper.example.com": "fake"}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake credential helper knows helper.example.com only, as a real one would answer for unknown registries
+	if runtime.GOOS != "windows" {
+		helper := `#!/bin/sh
+read server
+if [ "$server" = "helper.example.com" ]; then
+	echo '{"ServerURL": "helper.example.com", "Username": "helperuser", "Secret": "helperpass"}'
+else
+	echo "credentials not found in native keychain"
+	exit 1
+fi
+`
+		if err := os.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(helper), 0700); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Setenv("PATH", os.Getenv("PATH"))
+		os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
+	// DOCKER_CONFIG points to the directory holding config.json
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", dir)
+	configFile, err := LoadConfigFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		registry string
+		helper   bool // This flag tells if the test needs the fake credential helper
+		want     *models.AuthConfig
+		wantErr  bool
+	}{
+		{
+			name:     "Credentials of Docker Hub",
+			registry: "docker.io",
+			want:     &models.AuthConfig{Username: "hubuser", Password: "hubpass", ServerAddress: "https://index.docker.io/v1/"},
+		},
+		{
+			name:     "Identity token of a private registry",
+			registry: "registry.example.com",
+			want:     &models.AuthConfig{IdentityToken: "refresh-token", ServerAddress: "registry.example.com"},
+		},
+		{
+			name:     "Credentials from a credential helper",
+			registry: "helper.example.com",
+			helper:   true,
+			want:     &models.AuthConfig{Username: "helperuser", Password: "helperpass", ServerAddress: "helper.example.com"},
+		},
+		{
+			name:     "Exact address preferred over the same host with a scheme",
+			registry: "exact.example.com",
+			want:     &models.AuthConfig{IdentityToken: "exact-token", ServerAddress: "exact.example.com"},
+		},
+		{
+			name:     "Same host under several schemes",
+			registry: "schemes.example.com",
+			want:     &models.AuthConfig{IdentityToken: "http-token", ServerAddress: "schemes.example.com"},
+		},
+		{
+			name:     "Registry without credentials",
+			registry: "other.example.com",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.helper && runtime.GOOS == "windows" {
+				t.Skip("the fake credential helper is a shell script")
+			}
+			got, err := configFile.AuthConfig(tt.registry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConfigFile.AuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConfigFile.AuthConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// A missing configuration file is an empty configuration
+	configFile, err = LoadConfigFile(filepath.Join(dir, "missing.json"))
+	if err != nil || len(configFile.Auths) != 0 {
+		t.Errorf("LoadConfigFile() = %+v, %v, want an empty configuration", configFile, err)
+	}
+}
@@ -0,0 +1,223 @@
+package dockerclient
+
+import (
+	"archive/tar"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// dockerignoreFile is the file listing the paths of a build context that are not sent to the daemon
+const dockerignoreFile = ".dockerignore"
+
+/* ignorePattern is a single line of a .dockerignore file. Patterns use the filepath.Match syntax plus **,
+which matches any number of directories, and patterns starting with ! re-include paths excluded before */
+type ignorePattern struct {
+	regexp    *regexp.Regexp
+	exclusion bool
+}
+
+/* ignoreMatcher tells which paths of a build context are left out of it. As with docker, the last pattern
+matching a path (or any of its parent directories) wins */
+type ignoreMatcher struct {
+	patterns      []ignorePattern
+	hasExclusions bool
+	// alwaysSent lists the paths sent whatever the patterns say, such as the Dockerfile
+	alwaysSent []string
+}
+
+// readDockerignore reads the .dockerignore file of a build context. A missing file ignores nothing
+func readDockerignore(contextDir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(contextDir, dockerignoreFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// newIgnoreMatcher compiles the .dockerignore patterns given
+func newIgnoreMatcher(patterns []string) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+	for _, pattern := range patterns {
+		exclusion := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimSpace(strings.TrimPrefix(pattern, "!"))
+		pattern = strings.TrimPrefix(path.Clean(filepath.ToSlash(pattern)), "/")
+		if pattern == "." {
+			pattern = "*" // Ignoring the context root ignores everything in it
+		}
+
+		compiled, err := compileIgnorePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid .dockerignore pattern %q - %s", pattern, err)
+		}
+		matcher.patterns = append(matcher.patterns, ignorePattern{regexp: compiled, exclusion: exclusion})
+		matcher.hasExclusions = matcher.hasExclusions || exclusion
+	}
+	return matcher, nil
+}
+
+// compileIgnorePattern turns a .dockerignore pattern into the regular expression matching the same paths
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				expr.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, errors.New("unterminated character class")
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			expr.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// ignored tells whether a path of the build context (slash separated, relative to its root) is left out of it
+func (m *ignoreMatcher) ignored(relPath string) bool {
+	for _, sent := range m.alwaysSent {
+		if relPath == sent {
+			return false
+		}
+	}
+
+	ignored := false
+	for _, pattern := range m.patterns {
+		if pattern.exclusion == !ignored {
+			continue // The pattern cannot change the outcome
+		}
+		if matchPathOrParent(pattern.regexp, relPath) {
+			ignored = !pattern.exclusion
+		}
+	}
+	return ignored
+}
+
+/* skipsDir tells whether an ignored directory can be left out without walking it, which is only the case if no
+exclusion pattern could re-include a path beneath it and no path always sent is beneath it */
+func (m *ignoreMatcher) skipsDir(relPath string) bool {
+	if m.hasExclusions {
+		return false
+	}
+	for _, sent := range m.alwaysSent {
+		if strings.HasPrefix(sent, relPath+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPathOrParent tells whether a path or any of its parent directories match the regular expression given
+func matchPathOrParent(expr *regexp.Regexp, relPath string) bool {
+	for p := relPath; p != "."; p = path.Dir(p) {
+		if expr.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+/* archiveBuildContext streams the directory given as a tar archive, leaving out the paths its .dockerignore file
+excludes. The Dockerfile given (relative to the directory) and the .dockerignore file are always sent, as docker does.
+Files are archived as owned by root. The archive must be closed by the caller */
+func archiveBuildContext(contextDir string, dockerfile string) (io.ReadCloser, error) {
+	info, err := os.Stat(contextDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read build context - %s", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("build context %s is not a directory", contextDir)
+	}
+
+	patterns, err := readDockerignore(contextDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s of build context - %s", dockerignoreFile, err)
+	}
+	matcher, err := newIgnoreMatcher(patterns)
+	if err != nil {
+		return nil, err
+	}
+	matcher.alwaysSent = []string{strings.TrimPrefix(path.Clean(filepath.ToSlash(dockerfile)), "/"), dockerignoreFile}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeBuildContext(writer, contextDir, matcher))
+	}()
+	return reader, nil
+}
+
+// writeBuildContext writes the tar archive of a build context to w
+func writeBuildContext(w io.Writer, contextDir string, matcher *ignoreMatcher) error {
+	archive := tar.NewWriter(w)
+	err := filepath.Walk(contextDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(contextDir, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath == "." {
+			return nil
+		}
+
+		if matcher.ignored(relPath) {
+			if info.IsDir() && matcher.skipsDir(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return addTarEntry(archive, filePath, relPath, info, false)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot archive build context - %s", err)
+	}
+	return archive.Close()
+}
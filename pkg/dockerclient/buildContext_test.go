@@ -0,0 +1,123 @@
+package dockerclient
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{name: "File matched by name", patterns: []string{"secret.txt"}, path: "secret.txt", want: true},
+		{name: "File inside an ignored directory", patterns: []string{"node_modules"}, path: "node_modules/pkg/index.js", want: true},
+		{name: "Wildcard does not cross directories", patterns: []string{"*.log"}, path: "logs/app.log", want: false},
+		{name: "Double star crosses directories", patterns: []string{"**/*.log"}, path: "logs/2021/app.log", want: true},
+		{name: "Leading slash is the context root", patterns: []string{"/build"}, path: "build/out.bin", want: true},
+		{name: "Exclusion re-includes a file", patterns: []string{"*.md", "!README.md"}, path: "README.md", want: false},
+		{name: "Last matching pattern wins", patterns: []string{"!README.md", "*.md"}, path: "README.md", want: true},
+		{name: "Character class", patterns: []string{"tmp[0-9]"}, path: "tmp1", want: true},
+		{name: "Unmatched path", patterns: []string{"secret.txt"}, path: "src/main.go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := newIgnoreMatcher(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matcher.ignored(tt.path); got != tt.want {
+				t.Errorf("ignoreMatcher.ignored(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcher_skipsDir(t *testing.T) {
+	tests := []struct {
+		name       string
+		patterns   []string
+		alwaysSent []string
+		dir        string
+		want       bool
+	}{
+		{name: "Ignored directory", patterns: []string{"node_modules"}, alwaysSent: []string{"Dockerfile", ".dockerignore"}, dir: "node_modules", want: true},
+		{name: "Exclusion patterns may re-include paths", patterns: []string{"node_modules", "!*.md"}, dir: "node_modules", want: false},
+		{name: "Dockerfile beneath the directory", patterns: []string{"build"}, alwaysSent: []string{"build/Dockerfile"}, dir: "build", want: false},
+		{name: "Directory named as a prefix of the Dockerfile", patterns: []string{"build"}, alwaysSent: []string{"buildfiles/Dockerfile"}, dir: "build", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := newIgnoreMatcher(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matcher.alwaysSent = tt.alwaysSent
+			if got := matcher.skipsDir(tt.dir); got != tt.want {
+				t.Errorf("ignoreMatcher.skipsDir(%s) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":                "FROM ubuntu:20.04\n",
+		".dockerignore":             "# Local files\n*.log\nnode_modules\nDockerfile\n.dockerignore\n\n!keep.log\n",
+		"main.go":                   "package main\n",
+		"debug.log":                 "debug\n",
+		"keep.log":                  "keep\n",
+		"node_modules/pkg/index.js": "module.exports = {}\n",
+		"src/app.go":                "package src\n",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive, err := archiveBuildContext(dir, "Dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	var got []string
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Uid != 0 || header.Gid != 0 {
+			t.Errorf("archiveBuildContext() %s owned by %d:%d, want 0:0", header.Name, header.Uid, header.Gid)
+		}
+		got = append(got, header.Name)
+	}
+	sort.Strings(got)
+
+	// The Dockerfile and .dockerignore are always sent, even if .dockerignore lists them
+	want := []string{".dockerignore", "Dockerfile", "keep.log", "main.go", "src/", "src/app.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("archiveBuildContext() = %v, want %v", got, want)
+	}
+
+	if _, err := archiveBuildContext(filepath.Join(dir, "missing"), "Dockerfile"); err == nil {
+		t.Errorf("archiveBuildContext() error = nil, want an error for a missing directory")
+	}
+}
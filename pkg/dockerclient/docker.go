@@ -1,37 +1,238 @@
 package dockerclient
 
-// Docker is the interface any docker client must comply with
+import (
+	"context"
+	"io"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+/* Docker is the interface any docker client must comply with.
+Methods taking a context are bound to it, so callers can set deadlines or cancel in-flight queries. Errors caused by the
+context wrap its error, so errors.Is(err, context.DeadlineExceeded) or errors.Is(err, context.Canceled) tell them apart */
 type Docker interface {
 	/* CheckIfImageAlreadyExists figures out if an image is already in the local repository.
 	Returns true if it is available in the local registry, false otherwise.*/
 	CheckIfImageAlreadyExists(dockerImage string, tag string) (bool, error)
 
+	// CheckIfImageAlreadyExistsContext is CheckIfImageAlreadyExists bound to ctx, so the query can be cancelled or given a deadline
+	CheckIfImageAlreadyExistsContext(ctx context.Context, dockerImage string, tag string) (bool, error)
+
 	// PullImageFromRegistry pulls an image from the docker registry given a docker Image name, image tag and image architecture
 	PullImageFromRegistry(dockerImage string, tag string, arch string) error
 
+	// PullImageFromRegistryContext is PullImageFromRegistry bound to ctx, so the query can be cancelled or given a deadline
+	PullImageFromRegistryContext(ctx context.Context, dockerImage string, tag string, arch string) error
+
+	/* PullImageWithProgress pulls an image from the docker registry given a docker Image name, image tag and image architecture.
+	progress is called for every progress message sent by the daemon (it can be nil) */
+	PullImageWithProgress(ctx context.Context, dockerImage string, tag string, arch string, progress ProgressFunc) error
+
+	/* PullImageWithAuth pulls an image from a registry given a docker Image name, image tag, image architecture and the
+	credentials of the registry (nil to pull anonymously). progress is called for every progress message sent by the daemon (it can be nil) */
+	PullImageWithAuth(ctx context.Context, dockerImage string, tag string, arch string, auth *models.AuthConfig, progress ProgressFunc) error
+
+	/* PushImage pushes an image to its registry given its repository, tag (every tag of the repository if empty) and the
+	credentials of the registry (nil to push anonymously). progress is called for every progress message sent by the daemon (it can be nil) */
+	PushImage(ctx context.Context, repository string, tag string, auth *models.AuthConfig, progress ProgressFunc) error
+
+	/* BuildImage builds an image given the directory holding the build context, honouring its .dockerignore file, and
+	the build options. progress is called for every message of the build output (it can be nil). It returns the image ID */
+	BuildImage(ctx context.Context, contextDir string, options BuildOptions, progress ProgressFunc) (string, error)
+
+	/* SaveImages writes to output a tar archive holding the images given by reference or ID, the way docker save does.
+	The archive is streamed, so it is never held in memory */
+	SaveImages(ctx context.Context, images []string, output io.Writer) error
+
+	/* LoadImage loads the images of a tar archive made by SaveImages, read from input as it is sent. progress is called for
+	every progress message sent by the daemon (it can be nil). It returns the references (or IDs, for untagged images) loaded */
+	LoadImage(ctx context.Context, input io.Reader, progress ProgressFunc) ([]string, error)
+
+	/* ImportImage creates an image from a filesystem tar archive read from input as it is sent, given the import options.
+	progress is called for every progress message sent by the daemon (it can be nil). It returns the ID of the new image */
+	ImportImage(ctx context.Context, input io.Reader, options ImportImageOptions, progress ProgressFunc) (string, error)
+
+	/* ListImages lists the local images given the list options.
+	It returns a summary of every image, most recently created first */
+	ListImages(ctx context.Context, options ListImagesOptions) ([]models.ImageSummary, error)
+
+	/* InspectImage returns low-level information about an image given its reference (name:tag) or ID:
+	digests, size, architecture and the default configuration of its containers */
+	InspectImage(ctx context.Context, image string) (*models.InspectImageResponseBody, error)
+
+	/* TagImage adds a reference to an image given its reference or ID, and the repository and tag of the new reference
+	(latest if empty). The new reference is moved out of the image holding it, if any */
+	TagImage(ctx context.Context, image string, repository string, tag string) error
+
+	/* RemoveImage removes an image given its reference or ID, and the remove options. Removing one of the references of
+	an image that has several ones only untags it. It returns the references untagged and the images deleted */
+	RemoveImage(ctx context.Context, image string, options RemoveImageOptions) ([]models.ImageDeleteResponseItem, error)
+
+	// ImageHistory returns the layers of an image given its reference or ID, most recent first
+	ImageHistory(ctx context.Context, image string) ([]models.ImageHistoryItem, error)
+
+	/* PruneImages removes the dangling images no container uses, given the filters (they can be nil).
+	It returns the references untagged and the images deleted alongside the disk space reclaimed */
+	PruneImages(ctx context.Context, filters Filters) (*models.PruneImagesResponseBody, error)
+
+	// CreateNetwork creates a network given its name and the network options. It returns the ID of the new network
+	CreateNetwork(ctx context.Context, name string, options CreateNetworkOptions) (string, error)
+
+	// ListNetworks lists the networks given the filters (they can be nil)
+	ListNetworks(ctx context.Context, filters Filters) ([]models.NetworkResource, error)
+
+	// InspectNetwork returns low-level information about a network given its name or ID, the containers attached to it included
+	InspectNetwork(ctx context.Context, network string) (*models.NetworkResource, error)
+
+	// RemoveNetwork removes a network given its name or ID. Networks with containers attached cannot be removed
+	RemoveNetwork(ctx context.Context, network string) error
+
+	/* PruneNetworks removes the user-defined networks no container is attached to, given the filters (they can be nil).
+	It returns the names of the networks removed */
+	PruneNetworks(ctx context.Context, filters Filters) ([]string, error)
+
+	/* ConnectNetwork attaches a container to a network given the network name or ID, the container ID and the settings of
+	the container in the network (aliases and static addresses) */
+	ConnectNetwork(ctx context.Context, network string, containerID string, options EndpointOptions) error
+
+	/* DisconnectNetwork detaches a container from a network given the network name or ID and the container ID.
+	force disconnects the container even if the network is no longer available */
+	DisconnectNetwork(ctx context.Context, network string, containerID string, force bool) error
+
 	/* CreateContainer creates a a container given a container name, image name, image tag and list of commands for cmd.
 	It returns the ID of the new created container */
 	CreateContainer(containerName string, image string, tag string, cmd []string) (string, error)
 
+	// CreateContainerContext is CreateContainer bound to ctx, so the query can be cancelled or given a deadline
+	CreateContainerContext(ctx context.Context, containerName string, image string, tag string, cmd []string) (string, error)
+
+	/* CreateContainerWithOptions creates a container given a container name (it can be empty to get a random one) and the container options.
+	It returns the ID of the new created container */
+	CreateContainerWithOptions(ctx context.Context, containerName string, options ContainerOptions) (string, error)
+
 	// RunContainer starts a new container given a container ID.
 	RunContainer(containerID string) error
 
+	// RunContainerContext is RunContainer bound to ctx, so the query can be cancelled or given a deadline
+	RunContainerContext(ctx context.Context, containerID string) error
+
+	/* WaitContainer blocks until a container meets the wait condition given (WaitConditionNotRunning if empty), or ctx is done.
+	It returns the exit code of the container */
+	WaitContainer(ctx context.Context, containerID string, condition WaitCondition) (int, error)
+
 	/* CheckIfContainerIsReady checks if a container is in running state
 	It returns true if it is running, false if in any other  */
 	CheckIfContainerIsReady(containerID string) (bool, error)
 
+	// CheckIfContainerIsReadyContext is CheckIfContainerIsReady bound to ctx, so the query can be cancelled or given a deadline
+	CheckIfContainerIsReadyContext(ctx context.Context, containerID string) (bool, error)
+
+	// InspectContainer returns low-level information about a container given a container ID: configuration, state, network settings and mounts
+	InspectContainer(ctx context.Context, containerID string) (*models.InspectContainerResponseBody, error)
+
+	/* ListContainers lists the containers given the list options. By default only running containers are listed.
+	It returns a summary of every container, most recently created first */
+	ListContainers(ctx context.Context, options ListContainersOptions) ([]models.ContainerSummary, error)
+
 	/* GenerateExecInstance generates a new exec instance on a container given a container ID and a command to run
 	It returns the exec ID */
 	GenerateExecInstance(containerID string, commands []string) (string, error)
 
+	// GenerateExecInstanceContext is GenerateExecInstance bound to ctx, so the query can be cancelled or given a deadline
+	GenerateExecInstanceContext(ctx context.Context, containerID string, commands []string) (string, error)
+
+	/* GenerateExecInstanceWithOptions generates a new exec instance on a container given a container ID and the exec options.
+	It returns the exec ID */
+	GenerateExecInstanceWithOptions(ctx context.Context, containerID string, options ExecOptions) (string, error)
+
 	/* StartExecInstance start an exec instance on a docker daemon given an exec ID.
 	It returns the stdout and stderr from inside the container */
 	StartExecInstance(execInstanceID string) (string, error)
 
+	// StartExecInstanceContext is StartExecInstance bound to ctx, so the query can be cancelled or given a deadline
+	StartExecInstanceContext(ctx context.Context, execInstanceID string) (string, error)
+
+	/* StartExecInstanceWithWriters start an exec instance on a docker daemon given an exec ID, streaming its output as it comes.
+	tty must match the Tty setting the exec instance was generated with: if set, the whole output is written to stdout,
+	otherwise stdout and stderr are demultiplexed into their own writers */
+	StartExecInstanceWithWriters(ctx context.Context, execInstanceID string, tty bool, stdout io.Writer, stderr io.Writer) error
+
+	/* StartInteractiveExecInstance starts an exec instance on a docker daemon given an exec ID, taking over the connection
+	so stdin can be written to while the output is read. It returns the connection, which must be closed */
+	StartInteractiveExecInstance(ctx context.Context, execInstanceID string, tty bool) (HijackedConn, error)
+
+	// ResizeExecInstance resizes the TTY of an exec instance given an exec ID and the new height and width in characters
+	ResizeExecInstance(ctx context.Context, execInstanceID string, height uint, width uint) error
+
+	// InspectExecInstance returns low-level information about an exec instance given an exec ID, including its exit code
+	InspectExecInstance(ctx context.Context, execInstanceID string) (*models.InspectExecResponseBody, error)
+
+	/* RunCommand runs a command inside a running container given a container ID and the exec options, and waits for it to finish.
+	It returns the output of the command alongside its exit code */
+	RunCommand(ctx context.Context, containerID string, options ExecOptions) (*ExecResult, error)
+
+	/* ContainerLogs writes the logs of a container given a container ID and the logs options. If the container has a TTY
+	the whole output is written to stdout, otherwise stdout and stderr are demultiplexed into their own writers (they can be nil).
+	When following the logs, it blocks until the container stops or ctx is done */
+	ContainerLogs(ctx context.Context, containerID string, options LogsOptions, stdout io.Writer, stderr io.Writer) error
+
+	/* ExportContainer writes to output the filesystem of a container given its ID as a tar archive, the way docker export does.
+	The archive is streamed, so it is never held in memory */
+	ExportContainer(ctx context.Context, containerID string, output io.Writer) error
+
+	/* StatContainerPath returns information about a file or directory inside a container given a container ID and the path.
+	Symbolic links are not followed */
+	StatContainerPath(ctx context.Context, containerID string, containerPath string) (*models.ContainerPathStat, error)
+
+	/* CopyFromContainer writes to output a tar archive of a file or directory inside a container given a container ID and the path.
+	The archive is streamed, so it is never held in memory. It returns information about the path copied */
+	CopyFromContainer(ctx context.Context, containerID string, srcPath string, output io.Writer) (*models.ContainerPathStat, error)
+
+	/* CopyToContainer extracts a tar archive read from archive as it is sent, into a directory of a container
+	given a container ID, the directory path and the copy options */
+	CopyToContainer(ctx context.Context, containerID string, dstPath string, archive io.Reader, options CopyToContainerOptions) error
+
+	// Stats returns a single sample of the resource usage (CPU, memory, network and block I/O) of a container given a container ID
+	Stats(ctx context.Context, containerID string) (*ContainerStats, error)
+
+	/* StreamStats streams the resource usage of a container given a container ID, calling onStats for every sample
+	the daemon sends. It blocks until ctx is done or the daemon ends the stream */
+	StreamStats(ctx context.Context, containerID string, onStats StatsFunc) error
+
 	/* StopContainer stops a container given a container ID.
 	Returns true if the container is stopped and false is the container was already stopped */
 	StopContainer(containerID string) (bool, error)
 
+	// StopContainerContext is StopContainer bound to ctx, so the query can be cancelled or given a deadline
+	StopContainerContext(ctx context.Context, containerID string) (bool, error)
+
+	/* StopContainerWithOptions stops a container given a container ID and the stop options (grace period and signal).
+	Returns true if the container is stopped and false is the container was already stopped */
+	StopContainerWithOptions(ctx context.Context, containerID string, options StopOptions) (bool, error)
+
+	// RestartContainer stops a container, if it is running, and starts it again given a container ID and the stop options
+	RestartContainer(ctx context.Context, containerID string, options StopOptions) error
+
+	// KillContainer sends a signal to a running container given a container ID and the signal (SIGKILL if empty)
+	KillContainer(ctx context.Context, containerID string, signal string) error
+
+	// PauseContainer suspends every process of a running container given a container ID
+	PauseContainer(ctx context.Context, containerID string) error
+
+	// UnpauseContainer resumes every process of a paused container given a container ID
+	UnpauseContainer(ctx context.Context, containerID string) error
+
 	// RemoveContainer removes a container given a container ID
 	RemoveContainer(containerID string) error
+
+	// RemoveContainerContext is RemoveContainer bound to ctx, so the query can be cancelled or given a deadline
+	RemoveContainerContext(ctx context.Context, containerID string) error
+
+	/* RemoveContainerWithOptions removes a container given a container ID and the remove options.
+	It fails with ErrContainerIsRunning if the container is running, unless Force is set */
+	RemoveContainerWithOptions(ctx context.Context, containerID string, options RemoveContainerOptions) error
+
+	/* PruneContainers removes every stopped container matching the filters given (they can be nil).
+	It returns the IDs of the containers removed alongside the disk space reclaimed */
+	PruneContainers(ctx context.Context, filters Filters) (*models.PruneContainersResponseBody, error)
 }
@@ -0,0 +1,279 @@
+package dockertest
+
+import (
+	"archive/tar"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// fakeFile is a file, directory or symbolic link of the fake filesystem of a container
+type fakeFile struct {
+	Mode       os.FileMode // Mode holds the type and permissions of the file
+	Content    []byte
+	LinkTarget string
+	ModTime    time.Time
+	Uid        int
+	Gid        int
+}
+
+// newContainerFiles returns the fake filesystem every container starts with
+func newContainerFiles(hostname string) map[string]*fakeFile {
+	now := time.Now()
+	dir := func(mode os.FileMode) *fakeFile { return &fakeFile{Mode: os.ModeDir | mode, ModTime: now} }
+	file := func(content string) *fakeFile { return &fakeFile{Mode: 0644, Content: []byte(content), ModTime: now} }
+	return map[string]*fakeFile{
+		"/":               dir(0755),
+		"/.dockerenv":     file(""),
+		"/bin":            dir(0755),
+		"/etc":            dir(0755),
+		"/etc/hostname":   file(hostname + "\n"),
+		"/etc/os-release": file(fakeOSRelease),
+		"/root":           dir(0700),
+		"/tmp":            dir(os.ModeSticky | 0777),
+	}
+}
+
+/* WriteContainerFile writes a regular file to the fake filesystem of a container given its ID or name, creating its
+parent directories. It returns false if the container does not exist */
+func (s *Server) WriteContainerFile(idOrName string, filePath string, content []byte, mode os.FileMode) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(idOrName)
+	if !ok {
+		return false
+	}
+	c.writeFile(path.Clean("/"+filePath), &fakeFile{Mode: mode.Perm(), Content: content, ModTime: time.Now()})
+	return true
+}
+
+/* ContainerFile returns a file of the fake filesystem of a container given its ID or name, and the file path:
+its content and mode. It returns false if the container or the file do not exist */
+func (s *Server) ContainerFile(idOrName string, filePath string) ([]byte, os.FileMode, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(idOrName)
+	if !ok {
+		return nil, 0, false
+	}
+	file, ok := c.files[path.Clean("/"+filePath)]
+	if !ok {
+		return nil, 0, false
+	}
+	return file.Content, file.Mode, true
+}
+
+// writeFile adds a file to the fake filesystem of the container, creating its missing parent directories. s.mu must be held
+func (c *container) writeFile(filePath string, file *fakeFile) {
+	for dir := path.Dir(filePath); c.files[dir] == nil; dir = path.Dir(dir) {
+		c.files[dir] = &fakeFile{Mode: os.ModeDir | 0755, ModTime: file.ModTime}
+	}
+	c.files[filePath] = file
+}
+
+// resolvePath returns the absolute path a path of an archive query refers to, relative paths starting at the working directory
+func (c *container) resolvePath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = path.Join("/", c.Config.WorkingDir, filePath)
+	}
+	return path.Clean(filePath)
+}
+
+// pathStat describes a file of the fake filesystem the way the X-Docker-Container-Path-Stat header does
+func pathStat(filePath string, file *fakeFile) string {
+	size := int64(len(file.Content))
+	if file.Mode.IsDir() {
+		size = 4096
+	}
+	stat, _ := json.Marshal(models.ContainerPathStat{
+		Name:       path.Base(filePath),
+		Size:       size,
+		Mode:       file.Mode,
+		Mtime:      file.ModTime,
+		LinkTarget: file.LinkTarget,
+	})
+	return base64.StdEncoding.EncodeToString(stat)
+}
+
+// findContainerPath looks for the container and the file of an archive query, writing the error if any is missing
+func (s *Server) findContainerPath(w http.ResponseWriter, r *http.Request, id string) (*container, string, bool) {
+	filePath := r.URL.Query().Get("path")
+	if filePath == "" {
+		writeError(w, http.StatusBadRequest, "path cannot be empty")
+		return nil, "", false
+	}
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return nil, "", false
+	}
+	filePath = c.resolvePath(filePath)
+	if _, ok := c.files[filePath]; !ok {
+		writeError(w, http.StatusNotFound, "Could not find the file %s in container %s", filePath, id)
+		return nil, "", false
+	}
+	return c, filePath, true
+}
+
+// statContainerPath handles HEAD /containers/{id}/archive, describing a path of the container in the response headers
+func (s *Server) statContainerPath(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, filePath, ok := s.findContainerPath(w, r, id)
+	if !ok {
+		return
+	}
+	w.Header().Set("X-Docker-Container-Path-Stat", pathStat(filePath, c.files[filePath]))
+	w.WriteHeader(http.StatusOK)
+}
+
+// getArchive handles GET /containers/{id}/archive, writing a path of the container and everything beneath as a tar archive
+func (s *Server) getArchive(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, filePath, ok := s.findContainerPath(w, r, id)
+	if !ok {
+		return
+	}
+
+	// Entries are rooted at the base name of the path, as docker does
+	rootName := path.Base(filePath)
+	if filePath == "/" {
+		rootName = "."
+	}
+	var paths []string
+	for p := range c.files {
+		if p == filePath || filePath == "/" || strings.HasPrefix(p, filePath+"/") {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("X-Docker-Container-Path-Stat", pathStat(filePath, c.files[filePath]))
+	w.WriteHeader(http.StatusOK)
+	archive := tar.NewWriter(w)
+	for _, p := range paths {
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, filePath), "/")
+		writeFakeFile(archive, path.Join(rootName, rel), c.files[p])
+	}
+	archive.Close()
+}
+
+// putArchive handles PUT /containers/{id}/archive, extracting a tar archive into a directory of the container
+func (s *Server) putArchive(w http.ResponseWriter, r *http.Request, id string) {
+	noOverwriteDirNonDir := queryBool(r.URL.Query(), "noOverwriteDirNonDir")
+	copyUIDGID := queryBool(r.URL.Query(), "copyUIDGID")
+
+	// The archive is read before changing anything, so a broken archive leaves the container untouched
+	type entry struct {
+		name string
+		file *fakeFile
+	}
+	var entries []entry
+	reader := tar.NewReader(r.Body)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "cannot read archive: %s", err)
+			return
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "cannot read archive: %s", err)
+			return
+		}
+
+		file := &fakeFile{Mode: os.FileMode(header.Mode).Perm(), ModTime: header.ModTime}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			file.Mode |= os.ModeDir
+		case tar.TypeReg:
+			file.Content = content
+		case tar.TypeSymlink:
+			file.Mode |= os.ModeSymlink
+			file.LinkTarget = header.Linkname
+		default:
+			continue // The fake filesystem only holds files, directories and symbolic links
+		}
+		if copyUIDGID {
+			file.Uid, file.Gid = header.Uid, header.Gid
+		}
+		entries = append(entries, entry{name: header.Name, file: file})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, dstPath, ok := s.findContainerPath(w, r, id)
+	if !ok {
+		return
+	}
+	if !c.files[dstPath].Mode.IsDir() {
+		writeError(w, http.StatusBadRequest, "extraction point is not a directory")
+		return
+	}
+
+	for _, e := range entries {
+		target := path.Join(dstPath, path.Clean("/"+e.name))
+		if existing, ok := c.files[target]; ok && noOverwriteDirNonDir && existing.Mode.IsDir() != e.file.Mode.IsDir() {
+			writeError(w, http.StatusBadRequest, "cannot overwrite %s with %s", target, e.name)
+			return
+		}
+	}
+	for _, e := range entries {
+		target := path.Join(dstPath, path.Clean("/"+e.name))
+		if existing, ok := c.files[target]; ok && existing.Mode.IsDir() && !e.file.Mode.IsDir() {
+			for p := range c.files {
+				if strings.HasPrefix(p, target+"/") {
+					delete(c.files, p)
+				}
+			}
+		}
+		if target != "/" {
+			c.writeFile(target, e.file)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeFakeFile writes a file of the fake filesystem to a tar archive
+func writeFakeFile(archive *tar.Writer, name string, file *fakeFile) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(file.Mode.Perm()),
+		Size:     int64(len(file.Content)),
+		ModTime:  file.ModTime,
+		Uid:      file.Uid,
+		Gid:      file.Gid,
+	}
+	switch {
+	case file.Mode.IsDir():
+		header.Typeflag, header.Name, header.Size = tar.TypeDir, name+"/", 0
+	case file.Mode&os.ModeSymlink != 0:
+		header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, file.LinkTarget, 0
+	}
+	if file.Mode&os.ModeSticky != 0 {
+		header.Mode |= 01000
+	}
+	archive.WriteHeader(header)
+	if header.Typeflag == tar.TypeReg {
+		archive.Write(file.Content)
+	}
+}
@@ -0,0 +1,559 @@
+package dockertest
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// instruction is a single instruction of a Dockerfile, continuation lines already joined
+type instruction struct {
+	Command string // Command is the instruction name, uppercased (e.g. RUN)
+	Args    string // Args is everything after the instruction name
+}
+
+// buildStage is a stage of a Dockerfile, starting with FROM
+type buildStage struct {
+	From         string // From are the arguments of the FROM instruction (image [AS name])
+	Name         string
+	Instructions []instruction
+}
+
+// buildState holds the image being built by a stage, and the variables its instructions can use
+type buildState struct {
+	Config models.ContainerConfig
+	Args   map[string]string // Args are the values of the ARG instructions of the stage
+}
+
+/* buildImage handles POST /build. The body is the build context as a tar archive. The Dockerfile instructions are
+emulated the way the classic builder reports them: FROM resolves local images, previous stages and images available
+in the fake registry, RUN runs through ExecHandler, and COPY and ADD check their sources are in the build context.
+No build cache is kept, so nocache is accepted and ignored */
+func (s *Server) buildImage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var tags []string
+	for _, tag := range query["t"] {
+		ref := normalizeReference(tag)
+		i := strings.LastIndex(ref, ":")
+		repository := ref[:i]
+		if j := strings.Index(repository, "/"); j >= 0 && strings.ContainsAny(repository[:j], ".:") {
+			repository = repository[j+1:]
+		}
+		if !repositoryName.MatchString(repository) || !tagName.MatchString(ref[i+1:]) {
+			writeError(w, http.StatusBadRequest, "invalid reference format: %s", tag)
+			return
+		}
+		tags = append(tags, ref)
+	}
+
+	buildArgs := make(map[string]*string)
+	if raw := query.Get("buildargs"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &buildArgs); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid buildargs: %s", err)
+			return
+		}
+	}
+	labels := make(map[string]string)
+	if raw := query.Get("labels"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &labels); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid labels: %s", err)
+			return
+		}
+	}
+
+	files, err := readTar(r.Body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read build context: %s", err)
+		return
+	}
+	dockerfileName := query.Get("dockerfile")
+	if dockerfileName == "" {
+		dockerfileName = "Dockerfile"
+	}
+	dockerfile, ok := files[path.Clean(dockerfileName)]
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "Cannot locate specified Dockerfile: %s", dockerfileName)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	config, err := s.runDockerfile(w, parseDockerfile(string(dockerfile)), query.Get("target"), buildArgs, files)
+	if err != nil {
+		writeJSONLine(w, map[string]interface{}{"error": err.Error(), "errorDetail": map[string]string{"message": err.Error()}})
+		return
+	}
+
+	var unused []string
+	for name := range buildArgs {
+		unused = append(unused, name)
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		writeJSONLine(w, map[string]string{"stream": fmt.Sprintf("[Warning] One or more build-args %v were not consumed\n", unused)})
+	}
+
+	if len(labels) > 0 && config.Labels == nil {
+		config.Labels = make(map[string]string)
+	}
+	for key, value := range labels {
+		config.Labels[key] = value
+	}
+
+	s.mu.Lock()
+	img := &image{
+		ID:      "sha256:" + generateID(),
+		Created: time.Now(),
+		Layer:   "sha256:" + generateID(),
+		Config:  config,
+	}
+	for _, ref := range tags {
+		s.untag(ref)
+		if !contains(img.RepoTags, ref) {
+			img.RepoTags = append(img.RepoTags, ref)
+		}
+	}
+	s.images[img.ID] = img
+	s.mu.Unlock()
+
+	writeJSONLine(w, map[string]interface{}{"aux": map[string]string{"ID": img.ID}})
+	writeJSONLine(w, map[string]string{"stream": "Successfully built " + shortImageID(img) + "\n"})
+	for _, ref := range tags {
+		writeJSONLine(w, map[string]string{"stream": "Successfully tagged " + ref + "\n"})
+	}
+}
+
+/* runDockerfile runs the instructions of a Dockerfile up to the end of the target stage (the last one if empty),
+streaming the build output to w. Build args consumed are removed from buildArgs. It returns the configuration of the image built */
+func (s *Server) runDockerfile(w http.ResponseWriter, instructions []instruction, target string,
+	buildArgs map[string]*string, files map[string][]byte) (models.ContainerConfig, error) {
+	// Instructions before the first FROM can only be ARG, whose values are available to the FROM instructions
+	globalArgs := make(map[string]string)
+	stages := []buildStage{}
+	for _, inst := range instructions {
+		switch {
+		case inst.Command == "FROM":
+			fields := strings.Fields(inst.Args)
+			stage := buildStage{From: inst.Args}
+			if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+				stage.Name = strings.ToLower(fields[2])
+			}
+			stages = append(stages, stage)
+		case len(stages) > 0:
+			stages[len(stages)-1].Instructions = append(stages[len(stages)-1].Instructions, inst)
+		case inst.Command == "ARG":
+			name, value := parseArg(inst.Args, buildArgs, nil)
+			globalArgs[name] = value
+		default:
+			return models.ContainerConfig{}, fmt.Errorf("no build stage in current context")
+		}
+	}
+	if len(stages) == 0 {
+		return models.ContainerConfig{}, fmt.Errorf("the Dockerfile (Dockerfile) cannot be empty")
+	}
+
+	last := len(stages) - 1
+	if target != "" {
+		last = -1
+		for i, stage := range stages {
+			if stage.Name == strings.ToLower(target) {
+				last = i
+			}
+		}
+		if last < 0 {
+			return models.ContainerConfig{}, fmt.Errorf("failed to reach build target %s in Dockerfile", target)
+		}
+	}
+
+	total := 0
+	for _, stage := range stages[:last+1] {
+		total += 1 + len(stage.Instructions)
+	}
+	step := 0
+	stepHeader := func(inst instruction) {
+		step++
+		writeJSONLine(w, map[string]string{"stream": fmt.Sprintf("Step %d/%d : %s %s\n", step, total, inst.Command, inst.Args)})
+	}
+	layerDone := func() {
+		writeJSONLine(w, map[string]string{"stream": " ---> " + generateID()[:12] + "\n"})
+	}
+
+	built := make(map[string]models.ContainerConfig)
+	var state buildState
+	for _, stage := range stages[:last+1] {
+		stepHeader(instruction{Command: "FROM", Args: stage.From})
+		fromArgs := strings.Fields(os.Expand(stage.From, func(name string) string { return globalArgs[name] }))
+		if len(fromArgs) == 0 {
+			return models.ContainerConfig{}, fmt.Errorf("FROM requires either one or three arguments")
+		}
+		base, err := s.resolveBaseImage(fromArgs[0], built)
+		if err != nil {
+			return models.ContainerConfig{}, err
+		}
+		state = buildState{Config: base, Args: make(map[string]string)}
+		layerDone()
+
+		for _, inst := range stage.Instructions {
+			stepHeader(inst)
+			if err := s.runInstruction(w, &state, inst, globalArgs, buildArgs, files); err != nil {
+				return models.ContainerConfig{}, err
+			}
+			layerDone()
+		}
+		if stage.Name != "" {
+			built[stage.Name] = state.Config
+		}
+	}
+	return state.Config, nil
+}
+
+/* resolveBaseImage returns the configuration of the base image of a stage: a previous stage, scratch, a local image
+or an image available in the fake registry, which gets pulled */
+func (s *Server) resolveBaseImage(ref string, built map[string]models.ContainerConfig) (models.ContainerConfig, error) {
+	if config, ok := built[strings.ToLower(ref)]; ok {
+		return copyConfig(config), nil
+	}
+	if ref == "scratch" {
+		return models.ContainerConfig{}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if img, ok := s.findImage(ref); ok {
+		return copyConfig(img.Config), nil
+	}
+	normalized := normalizeReference(ref)
+	if pullError, available := s.registry[normalized]; available && pullError == "" {
+		img := s.addImage(normalized)
+		if len(img.RepoDigests) == 0 {
+			img.RepoDigests = []string{normalized[:strings.LastIndex(normalized, ":")] + "@sha256:" + generateID()}
+		}
+		return copyConfig(img.Config), nil
+	}
+	repository := normalized[:strings.LastIndex(normalized, ":")]
+	return models.ContainerConfig{}, fmt.Errorf("pull access denied for %s, repository does not exist or may require "+
+		"'docker login': denied: requested access to the resource is denied", repository)
+}
+
+// runInstruction runs an instruction of a stage other than FROM, updating the state of the stage
+func (s *Server) runInstruction(w http.ResponseWriter, state *buildState, inst instruction, globalArgs map[string]string,
+	buildArgs map[string]*string, files map[string][]byte) error {
+	expand := func(value string) string {
+		return os.Expand(value, func(name string) string {
+			for _, env := range state.Config.Env {
+				if strings.HasPrefix(env, name+"=") {
+					return strings.TrimPrefix(env, name+"=")
+				}
+			}
+			return state.Args[name]
+		})
+	}
+
+	config := &state.Config
+	switch inst.Command {
+	case "ARG":
+		name, value := parseArg(inst.Args, buildArgs, globalArgs)
+		state.Args[name] = value
+	case "ENV":
+		for key, value := range parseKeyValues(expand(inst.Args)) {
+			config.Env = append(removeEnv(config.Env, key), key+"="+value)
+		}
+	case "LABEL":
+		if config.Labels == nil {
+			config.Labels = make(map[string]string)
+		}
+		for key, value := range parseKeyValues(expand(inst.Args)) {
+			config.Labels[key] = value
+		}
+	case "CMD":
+		config.Cmd = parseCommand(inst.Args)
+	case "ENTRYPOINT":
+		config.Entrypoint = parseCommand(inst.Args)
+	case "WORKDIR":
+		dir := expand(inst.Args)
+		if !path.IsAbs(dir) {
+			dir = path.Join("/", config.WorkingDir, dir)
+		}
+		config.WorkingDir = dir
+	case "USER":
+		config.User = expand(inst.Args)
+	case "STOPSIGNAL":
+		config.StopSignal = expand(inst.Args)
+	case "EXPOSE":
+		if config.ExposedPorts == nil {
+			config.ExposedPorts = make(map[string]struct{})
+		}
+		for _, port := range strings.Fields(expand(inst.Args)) {
+			if !strings.Contains(port, "/") {
+				port += "/tcp"
+			}
+			config.ExposedPorts[port] = struct{}{}
+		}
+	case "VOLUME":
+		if config.Volumes == nil {
+			config.Volumes = make(map[string]struct{})
+		}
+		for _, volume := range parseList(expand(inst.Args)) {
+			config.Volumes[volume] = struct{}{}
+		}
+	case "COPY", "ADD":
+		return checkBuildSources(inst, expand, files)
+	case "RUN":
+		cmd := parseCommand(inst.Args)
+		containerID := generateID()
+		writeJSONLine(w, map[string]string{"stream": " ---> Running in " + containerID[:12] + "\n"})
+		s.mu.Lock()
+		handler := s.ExecHandler
+		s.mu.Unlock()
+		run := cmd
+		if !strings.HasPrefix(inst.Args, "[") { // The shell expands the build args and environment of shell form commands
+			run = []string{cmd[0], cmd[1], expand(cmd[2])}
+		}
+		result := handler(containerID, run, "")
+		if output := result.Stdout + result.Stderr; output != "" {
+			writeJSONLine(w, map[string]string{"stream": output})
+		}
+		if result.ExitCode != 0 {
+			return fmt.Errorf("The command '%s' returned a non-zero code: %d", strings.Join(cmd, " "), result.ExitCode)
+		}
+		writeJSONLine(w, map[string]string{"stream": "Removing intermediate container " + containerID[:12] + "\n"})
+	case "HEALTHCHECK", "SHELL", "ONBUILD", "MAINTAINER":
+		// Accepted without effect on the fake images
+	default:
+		return fmt.Errorf("dockerfile parse error: unknown instruction: %s", inst.Command)
+	}
+	return nil
+}
+
+/* checkBuildSources checks the sources of a COPY or ADD instruction are in the build context, the way the daemon
+reports paths missing or excluded by .dockerignore. Sources copied from another stage or downloaded are not checked */
+func checkBuildSources(inst instruction, expand func(string) string, files map[string][]byte) error {
+	var sources []string
+	for _, field := range parseList(inst.Args) {
+		if strings.HasPrefix(field, "--from=") {
+			return nil
+		}
+		if !strings.HasPrefix(field, "--") {
+			sources = append(sources, expand(field))
+		}
+	}
+	if len(sources) < 2 {
+		return fmt.Errorf("%s requires at least two arguments", inst.Command)
+	}
+
+	for _, source := range sources[:len(sources)-1] {
+		if inst.Command == "ADD" && (strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")) {
+			continue
+		}
+		if !contextHasPath(files, source) {
+			return fmt.Errorf("%s failed: file not found in build context or excluded by .dockerignore: stat %s: file does not exist",
+				inst.Command, source)
+		}
+	}
+	return nil
+}
+
+// contextHasPath tells whether a path of the build context (wildcards allowed) matches any file or directory in it
+func contextHasPath(files map[string][]byte, source string) bool {
+	source = strings.TrimPrefix(path.Clean("/"+source), "/")
+	if source == "" {
+		return true // The root of the context is always there
+	}
+	for name := range files {
+		for p := name; p != "."; p = path.Dir(p) {
+			if matched, _ := path.Match(source, p); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readTar reads every entry of a tar archive, keeping the content of the files by their cleaned path
+func readTar(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(header.Name)] = content
+	}
+}
+
+// parseDockerfile splits a Dockerfile into instructions, joining continuation lines and leaving comments out
+func parseDockerfile(dockerfile string) []instruction {
+	var instructions []instruction
+	var current strings.Builder
+	for _, line := range strings.Split(dockerfile, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") || (line == "" && current.Len() == 0) {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\") + " ")
+			continue
+		}
+		current.WriteString(line)
+
+		fields := strings.SplitN(strings.TrimSpace(current.String()), " ", 2)
+		current.Reset()
+		inst := instruction{Command: strings.ToUpper(fields[0])}
+		if len(fields) == 2 {
+			inst.Args = strings.TrimSpace(fields[1])
+		}
+		instructions = append(instructions, inst)
+	}
+	return instructions
+}
+
+/* parseArg parses the arguments of an ARG instruction (name or name=default). The value comes from the build args
+if given there, which marks it as consumed, or from the global args for ARG instructions redeclaring a global one */
+func parseArg(args string, buildArgs map[string]*string, globalArgs map[string]string) (string, string) {
+	parts := strings.SplitN(args, "=", 2)
+	name, value := parts[0], ""
+	if len(parts) == 2 {
+		value = strings.Trim(parts[1], `"'`)
+	} else if global, ok := globalArgs[name]; ok {
+		value = global
+	}
+	if buildArg, ok := buildArgs[name]; ok {
+		if buildArg != nil {
+			value = *buildArg
+		}
+		delete(buildArgs, name)
+	}
+	return name, value
+}
+
+// parseCommand parses the arguments of RUN, CMD and ENTRYPOINT, either in exec (JSON array) or shell form
+func parseCommand(args string) []string {
+	var cmd []string
+	if strings.HasPrefix(args, "[") && json.Unmarshal([]byte(args), &cmd) == nil {
+		return cmd
+	}
+	return []string{"/bin/sh", "-c", args}
+}
+
+// parseList parses the arguments of COPY, ADD and VOLUME, either as a JSON array or separated by whitespace
+func parseList(args string) []string {
+	var list []string
+	if strings.HasPrefix(args, "[") && json.Unmarshal([]byte(args), &list) == nil {
+		return list
+	}
+	return strings.Fields(args)
+}
+
+// parseKeyValues parses the arguments of ENV and LABEL, either as key=value pairs (values may be quoted) or as a single key value pair
+func parseKeyValues(args string) map[string]string {
+	words := splitWords(args)
+	pairs := make(map[string]string)
+	if len(words) > 0 && !strings.Contains(words[0], "=") {
+		pairs[words[0]] = strings.Join(words[1:], " ")
+		return pairs
+	}
+	for _, word := range words {
+		parts := strings.SplitN(word, "=", 2)
+		if len(parts) == 2 {
+			pairs[parts[0]] = parts[1]
+		}
+	}
+	return pairs
+}
+
+// splitWords splits a string on whitespace, keeping quoted parts together and removing the quotes
+func splitWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range s {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// removeEnv returns the environment given without the variable given
+func removeEnv(env []string, key string) []string {
+	var kept []string
+	for _, variable := range env {
+		if !strings.HasPrefix(variable, key+"=") {
+			kept = append(kept, variable)
+		}
+	}
+	return kept
+}
+
+// copyConfig returns a copy of an image configuration that can be modified without changing the original
+func copyConfig(config models.ContainerConfig) models.ContainerConfig {
+	copied := config
+	copied.Env = append([]string(nil), config.Env...)
+	copied.Cmd = append([]string(nil), config.Cmd...)
+	copied.Entrypoint = append([]string(nil), config.Entrypoint...)
+	if config.Labels != nil {
+		copied.Labels = make(map[string]string, len(config.Labels))
+		for key, value := range config.Labels {
+			copied.Labels[key] = value
+		}
+	}
+	if config.ExposedPorts != nil {
+		copied.ExposedPorts = make(map[string]struct{}, len(config.ExposedPorts))
+		for port := range config.ExposedPorts {
+			copied.ExposedPorts[port] = struct{}{}
+		}
+	}
+	if config.Volumes != nil {
+		copied.Volumes = make(map[string]struct{}, len(config.Volumes))
+		for volume := range config.Volumes {
+			copied.Volumes[volume] = struct{}{}
+		}
+	}
+	return copied
+}
@@ -0,0 +1,773 @@
+package dockertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/units"
+)
+
+// container is the in-memory representation of a container
+type container struct {
+	ID         string
+	Name       string
+	ImageID    string
+	Config     models.ContainerConfig // Config.Image is the reference used when creating the container
+	HostConfig models.HostConfig
+	Created    time.Time
+	State      containerState
+	exits      int   // exits is the amount of times the container exited
+	sizeRw     int64 // sizeRw is the size of the files written by the container, see SetContainerSize
+	logs       []logEntry
+	changed    chan struct{} // changed is closed every time the container changes, see notify
+
+	files    map[string]*fakeFile // files is the fake filesystem of the container, by absolute path
+	networks map[string]*endpoint // networks are the attachments of the container, by network ID
+}
+
+// containerState mirrors the State object returned when inspecting a container
+type containerState struct {
+	Status     string
+	Running    bool
+	Pid        int
+	ExitCode   int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Health     *models.Health // Health is only set for containers with a healthcheck
+}
+
+// createContainerBody holds the fields of POST /containers/create the fake daemon cares about
+type createContainerBody struct {
+	models.ContainerConfig
+	HostConfig       models.HostConfig
+	NetworkingConfig models.NetworkingConfig
+}
+
+// ContainerStatus returns the status (created, running, exited...) of a container given its ID or name
+func (s *Server) ContainerStatus(idOrName string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(idOrName)
+	if !ok {
+		return "", false
+	}
+	return c.State.Status, true
+}
+
+/* SetContainerSize sets the size of the files written by a container given its ID or name, which is reported when
+listing containers and reclaimed when pruning them. It returns false if the container does not exist */
+func (s *Server) SetContainerSize(idOrName string, size int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(idOrName)
+	if !ok {
+		return false
+	}
+	c.sizeRw = size
+	return true
+}
+
+// findContainer looks for a container by ID, ID prefix or name. s.mu must be held
+func (s *Server) findContainer(idOrName string) (*container, bool) {
+	if c, ok := s.containers[idOrName]; ok {
+		return c, true
+	}
+	name := strings.TrimPrefix(idOrName, "/")
+	for _, c := range s.containers {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	if len(idOrName) >= 4 { // Avoid matching every container with very short prefixes
+		for id, c := range s.containers {
+			if strings.HasPrefix(id, idOrName) {
+				return c, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// routeContainers dispatches queries under /containers/
+func (s *Server) routeContainers(w http.ResponseWriter, r *http.Request, path string) {
+	if path == "create" && r.Method == http.MethodPost {
+		s.createContainer(w, r)
+		return
+	}
+	if path == "json" && r.Method == http.MethodGet {
+		s.listContainers(w, r)
+		return
+	}
+	if path == "prune" && r.Method == http.MethodPost {
+		s.pruneContainers(w, r)
+		return
+	}
+
+	id, action := splitPath(path)
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		s.removeContainer(w, r, id)
+	case action == "json" && r.Method == http.MethodGet:
+		s.inspectContainer(w, r, id)
+	case action == "start" && r.Method == http.MethodPost:
+		s.startContainer(w, r, id)
+	case action == "stop" && r.Method == http.MethodPost:
+		s.stopContainer(w, r, id)
+	case action == "restart" && r.Method == http.MethodPost:
+		s.restartContainer(w, r, id)
+	case action == "kill" && r.Method == http.MethodPost:
+		s.killContainer(w, r, id)
+	case action == "pause" && r.Method == http.MethodPost:
+		s.pauseContainer(w, r, id)
+	case action == "unpause" && r.Method == http.MethodPost:
+		s.unpauseContainer(w, r, id)
+	case action == "archive" && r.Method == http.MethodHead:
+		s.statContainerPath(w, r, id)
+	case action == "archive" && r.Method == http.MethodGet:
+		s.getArchive(w, r, id)
+	case action == "archive" && r.Method == http.MethodPut:
+		s.putArchive(w, r, id)
+	case action == "export" && r.Method == http.MethodGet:
+		s.exportContainer(w, r, id)
+	case action == "logs" && r.Method == http.MethodGet:
+		s.containerLogs(w, r, id)
+	case action == "wait" && r.Method == http.MethodPost:
+		s.waitContainer(w, r, id)
+	case action == "stats" && r.Method == http.MethodGet:
+		s.containerStats(w, r, id)
+	case action == "exec" && r.Method == http.MethodPost:
+		s.createExec(w, r, id)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// createContainer handles POST /containers/create
+func (s *Server) createContainer(w http.ResponseWriter, r *http.Request) {
+	var body createContainerBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %s", err)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name != "" && !containerName.MatchString(name) {
+		writeError(w, http.StatusBadRequest, "Invalid container name (%s), only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", name)
+		return
+	}
+	if err := validateHostConfig(body.HostConfig); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := validateHealthcheck(body.Healthcheck); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.findImage(body.Image)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such image: %s", body.Image)
+		return
+	}
+
+	networks, statusCode, err := s.containerNetworks(body)
+	if err != nil {
+		writeError(w, statusCode, "%s", err)
+		return
+	}
+
+	if name != "" {
+		if existing, ok := s.findContainer(name); ok && existing.Name == name {
+			writeError(w, http.StatusConflict, "Conflict. The container name \"/%s\" is already in use by container \"%s\". "+
+				"You have to remove (or rename) that container to be able to reuse that name.", name, existing.ID)
+			return
+		}
+	}
+
+	c := &container{
+		ID:         generateID(),
+		Name:       name,
+		ImageID:    img.ID,
+		Config:     body.ContainerConfig,
+		HostConfig: body.HostConfig,
+		Created:    time.Now(),
+		State:      containerState{Status: "created"},
+		changed:    make(chan struct{}),
+		networks:   networks,
+	}
+	if c.Name == "" {
+		c.Name = c.ID[:12]
+	}
+	hostname := c.Config.Hostname
+	if hostname == "" {
+		hostname = c.ID[:12]
+	}
+	c.files = newContainerFiles(hostname)
+	s.containers[c.ID] = c
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"Id": c.ID, "Warnings": []string{}})
+}
+
+// containerName matches the container names docker accepts
+var containerName = regexp.MustCompile(`^/?[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// validateHostConfig checks the host settings of a new container the way the daemon does
+func validateHostConfig(hostConfig models.HostConfig) error {
+	policy := hostConfig.RestartPolicy
+	switch policy.Name {
+	case "", "no", "always", "unless-stopped":
+		if policy.MaximumRetryCount != 0 {
+			return fmt.Errorf("maximum retry count cannot be used with restart policy '%s'", policy.Name)
+		}
+	case "on-failure":
+		if policy.MaximumRetryCount < 0 {
+			return fmt.Errorf("maximum retry count cannot be negative")
+		}
+	default:
+		return fmt.Errorf("invalid restart policy '%s'", policy.Name)
+	}
+	if hostConfig.AutoRemove && policy.Name != "" && policy.Name != "no" {
+		return fmt.Errorf("conflicting options: AutoRemove and restart policy")
+	}
+	if hostConfig.Memory > 0 && hostConfig.Memory < 6*1024*1024 {
+		return fmt.Errorf("Minimum memory limit allowed is 6MB")
+	}
+	if hostConfig.NanoCpus > 0 && (hostConfig.CPUPeriod > 0 || hostConfig.CPUQuota > 0) {
+		return fmt.Errorf("Conflicting options: Nano CPUs and CPU Period cannot both be set")
+	}
+	return nil
+}
+
+// containerStatuses are the values accepted by the status filter when listing containers
+var containerStatuses = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// listContainers handles GET /containers/json
+func (s *Server) listContainers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filters, err := parseFilters(query.Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := checkFilters(filters, "label", "status", "name", "ancestor"); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	for _, status := range filters["status"] {
+		if !contains(containerStatuses, status) {
+			writeError(w, http.StatusBadRequest, "invalid filter 'status=%s'", status)
+			return
+		}
+	}
+	var names []*regexp.Regexp
+	for _, name := range filters["name"] {
+		re, err := regexp.Compile(name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid filter 'name=%s': %s", name, err)
+			return
+		}
+		names = append(names, re)
+	}
+	all := queryBool(query, "all")
+	size := queryBool(query, "size")
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	containers := make([]*container, 0, len(s.containers))
+	for _, c := range s.containers {
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Created.After(containers[j].Created) })
+
+	summaries := []map[string]interface{}{}
+	for _, c := range containers {
+		if limit > 0 && len(summaries) == limit {
+			break
+		}
+		// The status filter or a limit list stopped containers too, as docker does
+		if !all && limit <= 0 && len(filters["status"]) == 0 && !c.State.Running {
+			continue
+		}
+		if !s.containerMatches(c, filters, names) {
+			continue
+		}
+
+		summary := map[string]interface{}{
+			"Id":      c.ID,
+			"Names":   []string{"/" + c.Name},
+			"Image":   c.Config.Image,
+			"ImageID": c.ImageID,
+			"Command": strings.Join(c.Config.Cmd, " "),
+			"Created": c.Created.Unix(),
+			"Ports":   []interface{}{},
+			"Labels":  c.labels(),
+			"State":   c.State.Status,
+			"Status":  c.statusText(),
+		}
+		if size {
+			summary["SizeRw"] = c.sizeRw
+			summary["SizeRootFs"] = c.sizeRw
+		}
+		summaries = append(summaries, summary)
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// containerMatches tells if a container matches every filter given. s.mu must be held
+func (s *Server) containerMatches(c *container, filters map[string][]string, names []*regexp.Regexp) bool {
+	if values := filters["status"]; len(values) > 0 && !contains(values, c.State.Status) {
+		return false
+	}
+	if len(names) > 0 {
+		matched := false
+		for _, re := range names {
+			matched = matched || re.MatchString("/"+c.Name)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if values := filters["ancestor"]; len(values) > 0 {
+		matched := false
+		for _, ancestor := range values {
+			img, ok := s.findImage(ancestor)
+			matched = matched || (ok && img.ID == c.ImageID)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return matchLabels(c.Config.Labels, filters["label"])
+}
+
+// labels returns the container labels, never nil so they are encoded as an empty object
+func (c *container) labels() map[string]string {
+	if c.Config.Labels == nil {
+		return map[string]string{}
+	}
+	return c.Config.Labels
+}
+
+// statusText returns the human readable status docker shows when listing containers
+func (c *container) statusText() string {
+	switch c.State.Status {
+	case "running":
+		status := "Up " + units.HumanDuration(time.Since(c.State.StartedAt))
+		if c.State.Health != nil {
+			status += fmt.Sprintf(" (%s)", c.State.Health.Status)
+		}
+		return status
+	case "paused":
+		return "Up " + units.HumanDuration(time.Since(c.State.StartedAt)) + " (Paused)"
+	case "exited":
+		return fmt.Sprintf("Exited (%d) %s ago", c.State.ExitCode, units.HumanDuration(time.Since(c.State.FinishedAt)))
+	default:
+		return strings.ToUpper(c.State.Status[:1]) + c.State.Status[1:]
+	}
+}
+
+// inspectContainer handles GET /containers/{id}/json
+func (s *Server) inspectContainer(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+
+	config := c.Config
+	if config.Hostname == "" {
+		config.Hostname = c.ID[:12]
+	}
+	hostConfig := c.HostConfig
+	hostConfig.NetworkMode = c.networkMode()
+	if hostConfig.RestartPolicy.Name == "" {
+		hostConfig.RestartPolicy.Name = "no"
+	}
+
+	var path string
+	args := []string{}
+	if len(config.Cmd) > 0 {
+		path, args = config.Cmd[0], config.Cmd[1:]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"Id":      c.ID,
+		"Created": c.Created.Format(time.RFC3339Nano),
+		"Path":    path,
+		"Args":    args,
+		"State": map[string]interface{}{
+			"Status":     c.State.Status,
+			"Running":    c.State.Running,
+			"Paused":     c.State.Status == "paused",
+			"Restarting": false,
+			"OOMKilled":  false,
+			"Dead":       false,
+			"Pid":        c.State.Pid,
+			"ExitCode":   c.State.ExitCode,
+			"Error":      "",
+			"StartedAt":  formatTime(c.State.StartedAt),
+			"FinishedAt": formatTime(c.State.FinishedAt),
+			"Health":     c.State.Health,
+		},
+		"Image":           c.ImageID,
+		"Name":            "/" + c.Name,
+		"RestartCount":    0,
+		"Driver":          "overlay2",
+		"Platform":        "linux",
+		"Config":          config,
+		"HostConfig":      hostConfig,
+		"NetworkSettings": s.networkSettings(c),
+		"Mounts":          c.mountPoints(),
+	})
+}
+
+// networkSettings returns the NetworkSettings object of a container, as found when inspecting it. s.mu must be held
+func (s *Server) networkSettings(c *container) map[string]interface{} {
+	settings := map[string]interface{}{
+		"SandboxID":  c.ID,
+		"Ports":      c.ports(),
+		"IPAddress":  "",
+		"Gateway":    "",
+		"MacAddress": "",
+	}
+	networks := map[string]interface{}{}
+	for networkID, e := range c.networks {
+		n := s.networks[networkID]
+		var gateway string
+		var prefixLen int
+		if e.IPAddress != "" {
+			gateway = n.gateway.String()
+			prefixLen, _ = n.subnet.Mask.Size()
+		}
+		var ipamConfig *models.EndpointIPAMConfig
+		if e.StaticIP != "" {
+			ipamConfig = &models.EndpointIPAMConfig{IPv4Address: e.StaticIP}
+		}
+		networks[n.Name] = map[string]interface{}{
+			"IPAMConfig":  ipamConfig,
+			"NetworkID":   n.ID,
+			"EndpointID":  e.ID,
+			"Gateway":     gateway,
+			"IPAddress":   e.IPAddress,
+			"IPPrefixLen": prefixLen,
+			"MacAddress":  macAddress(e.IPAddress),
+			"Aliases":     e.Aliases,
+		}
+
+		// The top level settings are the ones of the default bridge network
+		if n.builtin && n.Name == "bridge" {
+			settings["IPAddress"], settings["Gateway"], settings["MacAddress"] = e.IPAddress, gateway, macAddress(e.IPAddress)
+		}
+	}
+	settings["Networks"] = networks
+	return settings
+}
+
+// ports returns the exposed ports of a container, alongside the host ports they are published on while running
+func (c *container) ports() map[string][]models.PortBinding {
+	ports := make(map[string][]models.PortBinding)
+	for port := range c.Config.ExposedPorts {
+		ports[port] = nil
+	}
+	for port, bindings := range c.HostConfig.PortBindings {
+		ports[port] = nil
+		if !c.State.Running {
+			continue
+		}
+		for i, binding := range bindings {
+			if binding.HostIP == "" {
+				binding.HostIP = "0.0.0.0"
+			}
+			if binding.HostPort == "" {
+				binding.HostPort = strconv.Itoa(49153 + i) // Random ports are picked from the ephemeral range
+			}
+			ports[port] = append(ports[port], binding)
+		}
+	}
+	return ports
+}
+
+// mountPoints returns the mounts of a container from its binds and mounts settings
+func (c *container) mountPoints() []models.MountPoint {
+	mountPoints := []models.MountPoint{}
+	for _, bind := range c.HostConfig.Binds {
+		parts := strings.SplitN(bind, ":", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		mountPoint := models.MountPoint{Type: "bind", Source: parts[0], Destination: parts[1], RW: true, Propagation: "rprivate"}
+		if len(parts) == 3 {
+			mountPoint.Mode = parts[2]
+			mountPoint.RW = !contains(strings.Split(parts[2], ","), "ro")
+		}
+		if !strings.HasPrefix(parts[0], "/") { // Named volume
+			mountPoint.Type, mountPoint.Name, mountPoint.Driver, mountPoint.Propagation = "volume", parts[0], "local", ""
+			mountPoint.Source = "/var/lib/docker/volumes/" + parts[0] + "/_data"
+		}
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	for _, mount := range c.HostConfig.Mounts {
+		mountPoint := models.MountPoint{Type: mount.Type, Source: mount.Source, Destination: mount.Target, RW: !mount.ReadOnly}
+		if mount.Type == "volume" {
+			mountPoint.Name, mountPoint.Driver = mount.Source, "local"
+			mountPoint.Source = "/var/lib/docker/volumes/" + mount.Source + "/_data"
+		}
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	return mountPoints
+}
+
+/* startContainer handles POST /containers/{id}/start. The container command is run through the exec handler and
+its output is kept as the container logs, unless it is sleep (or empty), which keeps running until stopped */
+func (s *Server) startContainer(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	c, ok := s.findContainer(id)
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if c.State.Status == "paused" {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "cannot start a paused container, try unpause instead")
+		return
+	}
+	if c.State.Running {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.start(c)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/* start moves a container to the running state and runs its command, waiting for it unless it is sleep (or empty).
+s.mu must be held, and it is released on return */
+func (s *Server) start(c *container) {
+	c.State = containerState{Status: "running", Running: true, Pid: 1000 + len(s.containers), StartedAt: time.Now()}
+	s.assignIPs(c)
+	if healthcheckCmd := c.healthcheckCommand(); healthcheckCmd != nil {
+		c.State.Health = &models.Health{Status: "starting", Log: []models.HealthcheckResult{}}
+		go s.runHealthcheck(c, healthcheckCmd, c.State.StartedAt)
+	}
+	startedAt := c.State.StartedAt
+	cmd := append(append([]string{}, c.Config.Entrypoint...), c.Config.Cmd...)
+	handler := s.ExecHandler
+	s.mu.Unlock()
+
+	if len(cmd) == 0 || cmd[0] == "sleep" {
+		return
+	}
+	result := handler(c.ID, cmd, "")
+
+	s.mu.Lock()
+	c.appendLogs(streamStdout, result.Stdout)
+	c.appendLogs(streamStderr, result.Stderr)
+	// The container may have been stopped, or even restarted, while the command was running
+	if c.State.Running && c.State.StartedAt.Equal(startedAt) {
+		c.exit(result.ExitCode)
+	}
+	s.mu.Unlock()
+}
+
+/* stopContainer handles POST /containers/{id}/stop. The fake containers stop straight away, so the grace period is only validated.
+Without signal they exit with code 0, otherwise with the code of a process killed by the signal */
+func (s *Server) stopContainer(w http.ResponseWriter, r *http.Request, id string) {
+	exitCode, err := stopQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if !c.State.Running {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	c.exit(exitCode)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// waitContainer handles POST /containers/{id}/wait
+func (s *Server) waitContainer(w http.ResponseWriter, r *http.Request, id string) {
+	condition := r.URL.Query().Get("condition")
+	switch condition {
+	case "", "not-running", "next-exit", "removed":
+	default:
+		writeError(w, http.StatusBadRequest, "invalid condition: %q", condition)
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.findContainer(id)
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	exits := c.exits
+	s.mu.Unlock()
+
+	// The daemon answers straight away, so clients are not timed out, and sends the body once the condition is met
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	s.mu.Lock()
+	for {
+		var met bool
+		switch condition {
+		case "", "not-running":
+			met = !c.State.Running
+		case "next-exit":
+			met = c.exits > exits
+		case "removed":
+			met = s.containers[c.ID] != c
+		}
+		if met {
+			exitCode := c.State.ExitCode
+			s.mu.Unlock()
+			json.NewEncoder(w).Encode(map[string]interface{}{"StatusCode": exitCode, "Error": nil})
+			return
+		}
+
+		changed := c.changed
+		s.mu.Unlock()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+		s.mu.Lock()
+	}
+}
+
+/* removeContainer handles DELETE /containers/{id}. Running containers are killed first if force is set.
+The fake daemon keeps neither volumes nor legacy links, so v is accepted and ignored, while link always fails */
+func (s *Server) removeContainer(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if queryBool(query, "link") {
+		writeError(w, http.StatusBadRequest, "Conflict, cannot remove the default link name of the container")
+		return
+	}
+	if c.State.Running {
+		if !queryBool(query, "force") {
+			writeError(w, http.StatusConflict, "You cannot remove a running container %s. "+
+				"Stop the container before attempting removal or force remove", c.ID)
+			return
+		}
+		c.exit(137)
+	}
+
+	delete(s.containers, c.ID)
+	c.notify()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/* pruneContainers handles POST /containers/prune, removing every stopped container that matches the filters.
+Filters supported are until (timestamp or duration) and label, or label! to keep the containers with the label */
+func (s *Server) pruneContainers(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := checkFilters(filters, "until", "label", "label!"); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	var until time.Time
+	if values := filters["until"]; len(values) > 0 {
+		if len(values) > 1 {
+			writeError(w, http.StatusBadRequest, "more than one until filter specified")
+			return
+		}
+		if until, err = parseFilterTime(values[0]); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := []string{}
+	var reclaimed int64
+	for id, c := range s.containers {
+		if c.State.Running || (!until.IsZero() && !c.Created.Before(until)) || !matchLabels(c.Config.Labels, filters["label"]) {
+			continue
+		}
+		excluded := false
+		for _, label := range filters["label!"] {
+			excluded = excluded || matchLabels(c.Config.Labels, []string{label})
+		}
+		if excluded {
+			continue
+		}
+
+		delete(s.containers, id)
+		c.notify()
+		deleted = append(deleted, id)
+		reclaimed += c.sizeRw
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ContainersDeleted": deleted, "SpaceReclaimed": reclaimed})
+}
+
+// exit moves the container to the exited state with the exit code given
+func (c *container) exit(exitCode int) {
+	c.State.Status = "exited"
+	c.State.Running = false
+	c.State.Pid = 0
+	c.State.ExitCode = exitCode
+	c.State.FinishedAt = time.Now()
+	c.releaseIPs()
+	c.exits++
+	c.notify()
+}
+
+// formatTime formats t the way docker does, using the zero date for unset times
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "0001-01-01T00:00:00Z"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,320 @@
+package dockertest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Stream identifiers used by docker to multiplex stdout and stderr on a single connection
+const (
+	streamStdout byte = 1
+	streamStderr byte = 2
+)
+
+// ExecResult is the outcome of a command run inside a fake container
+type ExecResult struct {
+	// Stdout is what the command writes to the standard output
+	Stdout string
+	// Stderr is what the command writes to the standard error
+	Stderr string
+	// ExitCode is the exit code of the command
+	ExitCode int
+}
+
+/* ExecHandler computes the result of running cmd inside the container given.
+stdin holds everything written to the process stdin, and is only set for exec instances attaching stdin */
+type ExecHandler func(containerID string, cmd []string, stdin string) ExecResult
+
+/* DefaultExecHandler emulates a handful of commands: uname, hostname, echo, cat, true, false, exit and sh -c wrapping any of them.
+Any other command fails with exit code 127, as a shell would do */
+func DefaultExecHandler(containerID string, cmd []string, stdin string) ExecResult {
+	if len(cmd) == 0 {
+		return ExecResult{Stderr: "no command specified\n", ExitCode: 126}
+	}
+
+	switch strings.TrimPrefix(cmd[0], "/bin/") {
+	case "sh", "bash":
+		if len(cmd) == 3 && cmd[1] == "-c" {
+			return DefaultExecHandler(containerID, strings.Fields(cmd[2]), stdin)
+		}
+	case "uname":
+		return ExecResult{Stdout: "Linux\n"}
+	case "hostname":
+		return ExecResult{Stdout: containerID[:12] + "\n"}
+	case "cat":
+		return ExecResult{Stdout: stdin}
+	case "echo":
+		return ExecResult{Stdout: strings.Join(cmd[1:], " ") + "\n"}
+	case "true":
+		return ExecResult{}
+	case "false":
+		return ExecResult{ExitCode: 1}
+	case "exit":
+		if len(cmd) == 1 {
+			return ExecResult{}
+		}
+		if exitCode, err := strconv.Atoi(cmd[1]); err == nil {
+			return ExecResult{ExitCode: exitCode}
+		}
+		return ExecResult{Stderr: "exit: Illegal number: " + cmd[1] + "\n", ExitCode: 2}
+	}
+
+	return ExecResult{Stderr: cmd[0] + ": not found\n", ExitCode: 127}
+}
+
+// exec is the in-memory representation of an exec instance
+type exec struct {
+	ID           string
+	ContainerID  string
+	Cmd          []string
+	Tty          bool
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+	User         string
+	Height       int
+	Width        int
+	Running      bool
+	ExitCode     int
+	Pid          int
+}
+
+// createExecBody holds the fields of POST /containers/{id}/exec the fake daemon cares about
+type createExecBody struct {
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+	Tty          bool
+	Cmd          []string
+	User         string
+}
+
+// startExecBody holds the fields of POST /exec/{id}/start the fake daemon cares about
+type startExecBody struct {
+	Detach bool
+	Tty    bool
+}
+
+// routeExec dispatches queries under /exec/
+func (s *Server) routeExec(w http.ResponseWriter, r *http.Request, path string) {
+	id, action := splitPath(path)
+	switch {
+	case action == "start" && r.Method == http.MethodPost:
+		s.startExec(w, r, id)
+	case action == "json" && r.Method == http.MethodGet:
+		s.inspectExec(w, r, id)
+	case action == "resize" && r.Method == http.MethodPost:
+		s.resizeExec(w, r, id)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// createExec handles POST /containers/{id}/exec
+func (s *Server) createExec(w http.ResponseWriter, r *http.Request, containerID string) {
+	var body createExecBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(containerID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", containerID)
+		return
+	}
+	if !c.State.Running {
+		writeError(w, http.StatusConflict, "Container %s is not running", c.ID)
+		return
+	}
+	if c.State.Status == "paused" {
+		writeError(w, http.StatusConflict, "Container %s is paused, unpause the container before exec", c.ID)
+		return
+	}
+
+	e := &exec{
+		ID:           generateID(),
+		ContainerID:  c.ID,
+		Cmd:          body.Cmd,
+		Tty:          body.Tty,
+		AttachStdin:  body.AttachStdin,
+		AttachStdout: body.AttachStdout,
+		AttachStderr: body.AttachStderr,
+		User:         body.User,
+	}
+	s.execs[e.ID] = e
+
+	writeJSON(w, http.StatusCreated, map[string]string{"Id": e.ID})
+}
+
+// startExec handles POST /exec/{id}/start
+func (s *Server) startExec(w http.ResponseWriter, r *http.Request, id string) {
+	var body startExecBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	e, ok := s.execs[id]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "No such exec instance: %s", id)
+		return
+	}
+	c, ok := s.containers[e.ContainerID]
+	if !ok || !c.State.Running {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "Container %s is not running", e.ContainerID)
+		return
+	}
+	handler := s.ExecHandler
+	e.Running = true
+	e.Pid = c.State.Pid + len(s.execs)
+	s.mu.Unlock()
+
+	if r.Header.Get("Upgrade") == "tcp" {
+		s.runHijackedExec(w, e, handler)
+		return
+	}
+
+	result := s.runExec(e, handler, "")
+
+	if body.Detach {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", outputContentType(e.Tty))
+	w.WriteHeader(http.StatusOK)
+	writeOutput(w, e.Tty, result.Stdout, result.Stderr)
+}
+
+/* runHijackedExec takes over the connection of an exec start query the way the daemon does when the client asks
+for an upgrade: stdin is read from the connection until the client half closes it, and the output is written back */
+func (s *Server) runHijackedExec(w http.ResponseWriter, e *exec, handler ExecHandler) {
+	conn, buffer, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	buffer.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: " + outputContentType(e.Tty) +
+		"\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	buffer.Flush()
+
+	var stdin []byte
+	if e.AttachStdin {
+		stdin, _ = io.ReadAll(buffer)
+	}
+
+	result := s.runExec(e, handler, string(stdin))
+	writeOutput(conn, e.Tty, result.Stdout, result.Stderr)
+}
+
+// runExec runs the command of an exec instance and records its exit code. Streams not attached are dropped
+func (s *Server) runExec(e *exec, handler ExecHandler, stdin string) ExecResult {
+	result := handler(e.ContainerID, e.Cmd, stdin)
+
+	s.mu.Lock()
+	e.Running = false
+	e.ExitCode = result.ExitCode
+	s.mu.Unlock()
+
+	if !e.AttachStdout {
+		result.Stdout = ""
+	}
+	if !e.AttachStderr {
+		result.Stderr = ""
+	}
+	return result
+}
+
+// resizeExec handles POST /exec/{id}/resize
+func (s *Server) resizeExec(w http.ResponseWriter, r *http.Request, id string) {
+	height, errHeight := strconv.Atoi(r.URL.Query().Get("h"))
+	width, errWidth := strconv.Atoi(r.URL.Query().Get("w"))
+	if errHeight != nil || errWidth != nil {
+		writeError(w, http.StatusBadRequest, "invalid resize parameters h=%q w=%q", r.URL.Query().Get("h"), r.URL.Query().Get("w"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.execs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such exec instance: %s", id)
+		return
+	}
+
+	e.Height, e.Width = height, width
+	w.WriteHeader(http.StatusCreated)
+}
+
+// inspectExec handles GET /exec/{id}/json
+func (s *Server) inspectExec(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.execs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such exec instance: %s", id)
+		return
+	}
+
+	processConfig := map[string]interface{}{"tty": e.Tty, "user": e.User, "entrypoint": "", "arguments": []string{}}
+	if len(e.Cmd) > 0 {
+		processConfig["entrypoint"] = e.Cmd[0]
+		processConfig["arguments"] = e.Cmd[1:]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ID":            e.ID,
+		"ContainerID":   e.ContainerID,
+		"Running":       e.Running,
+		"ExitCode":      e.ExitCode,
+		"Pid":           e.Pid,
+		"ProcessConfig": processConfig,
+	})
+}
+
+// outputContentType returns the content type docker uses for process output streams
+func outputContentType(tty bool) string {
+	if tty {
+		return "application/vnd.docker.raw-stream"
+	}
+	return "application/vnd.docker.multiplexed-stream"
+}
+
+/* writeOutput sends the stdout and stderr of a process the way docker does: merged with CRLF line endings
+when a TTY is allocated, or multiplexed in frames otherwise */
+func writeOutput(w io.Writer, tty bool, stdout string, stderr string) {
+	if tty {
+		output := stdout + stderr
+		w.Write([]byte(strings.ReplaceAll(output, "\n", "\r\n")))
+		return
+	}
+
+	writeFrame(w, streamStdout, []byte(stdout))
+	writeFrame(w, streamStderr, []byte(stderr))
+}
+
+// writeFrame sends data as a multiplexed stream frame: an 8 bytes header (stream, 0, 0, 0, size) followed by the data
+func writeFrame(w io.Writer, stream byte, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	w.Write(header)
+	w.Write(data)
+}
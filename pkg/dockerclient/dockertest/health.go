@@ -0,0 +1,117 @@
+package dockertest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// Healthcheck defaults docker applies to the settings left empty
+const (
+	defaultHealthInterval = 30 * time.Second
+	defaultHealthRetries  = 3
+	maxHealthLogEntries   = 5
+	maxHealthOutputSize   = 4096
+)
+
+// validateHealthcheck checks the healthcheck of a new container the way the daemon does
+func validateHealthcheck(healthcheck *models.HealthConfig) error {
+	if healthcheck == nil {
+		return nil
+	}
+	for name, d := range map[string]time.Duration{"Interval": healthcheck.Interval, "Timeout": healthcheck.Timeout, "StartPeriod": healthcheck.StartPeriod} {
+		if d != 0 && d < time.Millisecond {
+			return fmt.Errorf("%s in Healthcheck cannot be less than 1ms", name)
+		}
+	}
+	if healthcheck.Retries < 0 {
+		return fmt.Errorf("Retries in Healthcheck cannot be negative")
+	}
+	return nil
+}
+
+// healthcheckCommand returns the command to run to check the health of a container, or nil if it has no healthcheck
+func (c *container) healthcheckCommand() []string {
+	healthcheck := c.Config.Healthcheck
+	if healthcheck == nil || len(healthcheck.Test) == 0 {
+		return nil
+	}
+	switch healthcheck.Test[0] {
+	case "CMD":
+		return healthcheck.Test[1:]
+	case "CMD-SHELL":
+		if len(healthcheck.Test) == 2 {
+			return []string{"/bin/sh", "-c", healthcheck.Test[1]}
+		}
+	}
+	return nil // NONE disables the healthcheck
+}
+
+/* runHealthcheck runs the healthcheck of a container every interval, the way docker does, until the container
+stops, is restarted or removed, or the server is closed. s.mu must not be held */
+func (s *Server) runHealthcheck(c *container, cmd []string, startedAt time.Time) {
+	healthcheck := c.Config.Healthcheck
+	interval := healthcheck.Interval
+	if interval == 0 {
+		interval = defaultHealthInterval
+	}
+	retries := healthcheck.Retries
+	if retries == 0 {
+		retries = defaultHealthRetries
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		running := s.containers[c.ID] == c && c.State.Running && c.State.StartedAt.Equal(startedAt)
+		paused := c.State.Status == "paused"
+		handler := s.ExecHandler
+		s.mu.Unlock()
+		if !running {
+			return
+		}
+		if paused {
+			continue // Paused containers cannot run their healthcheck
+		}
+
+		start := time.Now()
+		result := handler(c.ID, cmd, "")
+		output := result.Stdout + result.Stderr
+		if len(output) > maxHealthOutputSize {
+			output = output[:maxHealthOutputSize]
+		}
+
+		s.mu.Lock()
+		if !c.State.Running || !c.State.StartedAt.Equal(startedAt) {
+			s.mu.Unlock()
+			return
+		}
+		health := c.State.Health
+		health.Log = append(health.Log, models.HealthcheckResult{Start: start, End: time.Now(), ExitCode: result.ExitCode, Output: output})
+		if len(health.Log) > maxHealthLogEntries {
+			health.Log = health.Log[len(health.Log)-maxHealthLogEntries:]
+		}
+		switch {
+		case result.ExitCode == 0:
+			health.Status, health.FailingStreak = "healthy", 0
+		case time.Since(startedAt) < healthcheck.StartPeriod:
+			// Failures during the start period are not counted
+		default:
+			health.FailingStreak++
+			if health.FailingStreak >= retries {
+				health.Status = "unhealthy"
+			}
+		}
+		c.notify()
+		s.mu.Unlock()
+	}
+}
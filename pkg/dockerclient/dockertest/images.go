@@ -0,0 +1,579 @@
+package dockertest
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// fakeLayerSize is the size of the single layer every fake image is made of
+const fakeLayerSize = 1024
+
+var (
+	// repositoryName is the format of the repository part of a reference, leaving out the registry host
+	repositoryName = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*$`)
+	// tagName is the format of the tag part of a reference
+	tagName = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+)
+
+// image is the in-memory representation of a local image
+type image struct {
+	ID          string
+	RepoTags    []string // RepoTags is empty for dangling images
+	RepoDigests []string // RepoDigests is only set for images pulled from the registry
+	Created     time.Time
+	Layer       string // Layer is the digest of the single layer of the image
+	Comment     string // Comment is the commit message of imported images
+	Config      models.ContainerConfig
+}
+
+// AddRegistryImage makes the image given available to be pulled from the fake registry
+func (s *Server) AddRegistryImage(name string, tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.registry[reference(name, tag)] = ""
+}
+
+/* SetPullError makes the image given available in the fake registry, but pulling it fails in the middle
+of the progress stream with the error message given, the way a daemon does on e.g. registry timeouts */
+func (s *Server) SetPullError(name string, tag string, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.registry[reference(name, tag)] = message
+}
+
+// AddImage stores the image given in the fake daemon, as if it was already pulled. It returns the image ID
+func (s *Server) AddImage(name string, tag string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addImage(reference(name, tag)).ID
+}
+
+/* SetImageLabels sets the labels of an image given its reference or ID, which are used by the label filters.
+It returns false if the image does not exist */
+func (s *Server) SetImageLabels(nameOrID string, labels map[string]string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.findImage(nameOrID)
+	if !ok {
+		return false
+	}
+	img.Config.Labels = labels
+	return true
+}
+
+// addImage stores a new image given its reference, or returns the existing one. s.mu must be held
+func (s *Server) addImage(ref string) *image {
+	if img, ok := s.findImage(ref); ok {
+		return img
+	}
+
+	img := &image{
+		ID:       "sha256:" + generateID(),
+		RepoTags: []string{ref},
+		Created:  time.Now(),
+		Layer:    "sha256:" + generateID(),
+		Config: models.ContainerConfig{
+			Env: []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"},
+			Cmd: []string{"bash"},
+		},
+	}
+	s.images[img.ID] = img
+	return img
+}
+
+// findImage looks for an image either by reference, ID or ID prefix. s.mu must be held
+func (s *Server) findImage(nameOrID string) (*image, bool) {
+	ref := normalizeReference(nameOrID)
+	for _, img := range s.images {
+		if contains(img.RepoTags, ref) {
+			return img, true
+		}
+	}
+	if img, ok := s.images[nameOrID]; ok {
+		return img, true
+	}
+	id := strings.TrimPrefix(nameOrID, "sha256:")
+	if len(id) >= 4 { // Avoid matching every image with very short prefixes
+		for _, img := range s.images {
+			if strings.HasPrefix(strings.TrimPrefix(img.ID, "sha256:"), id) {
+				return img, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// untag removes a reference from the image holding it, if any. s.mu must be held
+func (s *Server) untag(ref string) {
+	for _, img := range s.images {
+		for i, tag := range img.RepoTags {
+			if tag == ref {
+				img.RepoTags = append(img.RepoTags[:i:i], img.RepoTags[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// imageUsers returns the containers created from an image. s.mu must be held
+func (s *Server) imageUsers(img *image) []*container {
+	var users []*container
+	for _, c := range s.containers {
+		if c.ImageID == img.ID {
+			users = append(users, c)
+		}
+	}
+	return users
+}
+
+// routeImages dispatches queries under /images/
+func (s *Server) routeImages(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case path == "create" && r.Method == http.MethodPost && r.URL.Query().Get("fromSrc") != "":
+		s.importImage(w, r)
+	case path == "create" && r.Method == http.MethodPost:
+		s.pullImage(w, r)
+	case path == "get" && r.Method == http.MethodGet:
+		s.saveImages(w, r)
+	case path == "load" && r.Method == http.MethodPost:
+		s.loadImage(w, r)
+	case path == "json" && r.Method == http.MethodGet:
+		s.listImages(w, r)
+	case path == "prune" && r.Method == http.MethodPost:
+		s.pruneImages(w, r)
+	case strings.HasSuffix(path, "/json") && r.Method == http.MethodGet:
+		s.inspectImage(w, r, strings.TrimSuffix(path, "/json"))
+	case strings.HasSuffix(path, "/history") && r.Method == http.MethodGet:
+		s.imageHistory(w, r, strings.TrimSuffix(path, "/history"))
+	case strings.HasSuffix(path, "/tag") && r.Method == http.MethodPost:
+		s.tagImage(w, r, strings.TrimSuffix(path, "/tag"))
+	case strings.HasSuffix(path, "/push") && r.Method == http.MethodPost:
+		s.pushImage(w, r, strings.TrimSuffix(path, "/push"))
+	case r.Method == http.MethodDelete:
+		s.removeImage(w, r, path)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+/* pullImage handles POST /images/create. Registries with credentials set reject pulls without the right
+X-Registry-Auth header with 401, the way the daemon reports the unauthorized errors of the registry */
+func (s *Server) pullImage(w http.ResponseWriter, r *http.Request) {
+	name, tag := r.URL.Query().Get("fromImage"), r.URL.Query().Get("tag")
+	ref := reference(name, tag)
+
+	s.mu.Lock()
+	if message := s.checkRegistryAuth(r, name); message != "" {
+		s.mu.Unlock()
+		writeError(w, http.StatusUnauthorized, "Head \"https://%s/v2/%s/manifests/%s\": %s", registryHost(name), name, tag, message)
+		return
+	}
+	pullError, available := s.registry[ref]
+	if available && pullError == "" {
+		img := s.addImage(ref)
+		if len(img.RepoDigests) == 0 {
+			img.RepoDigests = []string{name + "@sha256:" + generateID()}
+		}
+	}
+	s.mu.Unlock()
+
+	if !available {
+		writeError(w, http.StatusNotFound, "manifest for %s not found: manifest unknown: manifest unknown", ref)
+		return
+	}
+
+	// Every fake image is made of a single layer
+	layerID := generateID()[:12]
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	writeJSONLine(w, map[string]string{"status": "Pulling from " + name, "id": tag})
+	writeJSONLine(w, map[string]string{"status": "Pulling fs layer", "id": layerID})
+	writeJSONLine(w, map[string]interface{}{"status": "Downloading", "id": layerID,
+		"progressDetail": map[string]int{"current": fakeLayerSize / 2, "total": fakeLayerSize}})
+
+	if pullError != "" {
+		writeJSONLine(w, map[string]interface{}{"error": pullError, "errorDetail": map[string]string{"message": pullError}})
+		return
+	}
+
+	writeJSONLine(w, map[string]interface{}{"status": "Downloading", "id": layerID,
+		"progressDetail": map[string]int{"current": fakeLayerSize, "total": fakeLayerSize}})
+	writeJSONLine(w, map[string]string{"status": "Pull complete", "id": layerID})
+	writeJSONLine(w, map[string]string{"status": "Status: Downloaded newer image for " + ref})
+}
+
+/* listImages handles GET /images/json. Filters supported are dangling, reference (a name or name:tag pattern),
+label, before and since. Dangling images are listed as <none>:<none>, as docker does */
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := checkFilters(filters, "dangling", "reference", "label", "before", "since"); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	for _, value := range filters["dangling"] {
+		if value != "true" && value != "false" && value != "1" && value != "0" {
+			writeError(w, http.StatusBadRequest, "invalid filter 'dangling=%s'", value)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var before, since time.Time
+	for key, bound := range map[string]*time.Time{"before": &before, "since": &since} {
+		for _, value := range filters[key] {
+			img, ok := s.findImage(value)
+			if !ok {
+				writeError(w, http.StatusNotFound, "No such image: %s", value)
+				return
+			}
+			*bound = img.Created
+		}
+	}
+
+	images := s.sortedImages()
+	summaries := []map[string]interface{}{}
+	for _, img := range images {
+		if values := filters["dangling"]; len(values) > 0 && (len(img.RepoTags) == 0) != (values[0] == "true" || values[0] == "1") {
+			continue
+		}
+		if !before.IsZero() && !img.Created.Before(before) {
+			continue
+		}
+		if !since.IsZero() && !img.Created.After(since) {
+			continue
+		}
+		if !matchLabels(img.Config.Labels, filters["label"]) {
+			continue
+		}
+		repoTags := img.RepoTags
+		if values := filters["reference"]; len(values) > 0 {
+			if repoTags = matchReferences(img.RepoTags, values); len(repoTags) == 0 {
+				continue
+			}
+		}
+
+		repoDigests := img.RepoDigests
+		if len(repoTags) == 0 {
+			repoTags, repoDigests = []string{"<none>:<none>"}, []string{"<none>@<none>"}
+		}
+		if repoDigests == nil {
+			repoDigests = []string{}
+		}
+		summaries = append(summaries, map[string]interface{}{
+			"Id":          img.ID,
+			"ParentId":    "",
+			"RepoTags":    repoTags,
+			"RepoDigests": repoDigests,
+			"Created":     img.Created.Unix(),
+			"Size":        fakeLayerSize,
+			"SharedSize":  -1,
+			"VirtualSize": fakeLayerSize,
+			"Labels":      imageLabels(img),
+			"Containers":  -1,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// sortedImages returns every image, most recently created first. s.mu must be held
+func (s *Server) sortedImages() []*image {
+	images := make([]*image, 0, len(s.images))
+	for _, img := range s.images {
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Created.After(images[j].Created) })
+	return images
+}
+
+/* matchReferences returns the references matching any of the patterns given. Patterns with a tag are matched against
+the whole reference, and patterns without one against the repository only */
+func matchReferences(refs []string, patterns []string) []string {
+	var matched []string
+	for _, ref := range refs {
+		repository := ref[:strings.LastIndex(ref, ":")]
+		for _, pattern := range patterns {
+			target := repository
+			if strings.LastIndex(pattern, ":") > strings.LastIndex(pattern, "/") {
+				target = ref
+			}
+			if ok, _ := path.Match(pattern, target); ok {
+				matched = append(matched, ref)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// imageLabels returns the image labels, never nil so they are encoded as an empty object
+func imageLabels(img *image) map[string]string {
+	if img.Config.Labels == nil {
+		return map[string]string{}
+	}
+	return img.Config.Labels
+}
+
+// inspectImage handles GET /images/{name}/json
+func (s *Server) inspectImage(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.findImage(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such image: %s", name)
+		return
+	}
+
+	repoTags, repoDigests := img.RepoTags, img.RepoDigests
+	if repoTags == nil {
+		repoTags = []string{}
+	}
+	if repoDigests == nil {
+		repoDigests = []string{}
+	}
+	config := img.Config
+	config.Labels = imageLabels(img)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"Id":            img.ID,
+		"RepoTags":      repoTags,
+		"RepoDigests":   repoDigests,
+		"Parent":        "",
+		"Comment":       img.Comment,
+		"Created":       img.Created.Format(time.RFC3339Nano),
+		"DockerVersion": "20.10.7",
+		"Author":        "",
+		"Config":        config,
+		"Architecture":  "amd64",
+		"Os":            "linux",
+		"Size":          fakeLayerSize,
+		"VirtualSize":   fakeLayerSize,
+		"RootFS":        map[string]interface{}{"Type": "layers", "Layers": []string{img.Layer}},
+		"Metadata":      map[string]string{"LastTagTime": formatTime(time.Time{})},
+	})
+}
+
+// imageHistory handles GET /images/{name}/history. Every fake image was built out of a file and a default command
+func (s *Server) imageHistory(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.findImage(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such image: %s", name)
+		return
+	}
+
+	tags := img.RepoTags
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, http.StatusOK, []map[string]interface{}{
+		{
+			"Id":        img.ID,
+			"Created":   img.Created.Unix(),
+			"CreatedBy": `/bin/sh -c #(nop)  CMD ["bash"]`,
+			"Tags":      tags,
+			"Size":      0,
+			"Comment":   "",
+		},
+		{
+			"Id":        "<missing>",
+			"Created":   img.Created.Unix(),
+			"CreatedBy": "/bin/sh -c #(nop) ADD file:" + strings.TrimPrefix(img.Layer, "sha256:") + " in / ",
+			"Tags":      nil,
+			"Size":      fakeLayerSize,
+			"Comment":   "",
+		},
+	})
+}
+
+// tagImage handles POST /images/{name}/tag, moving the tag out of the image holding it, if any
+func (s *Server) tagImage(w http.ResponseWriter, r *http.Request, name string) {
+	repo, tag := r.URL.Query().Get("repo"), r.URL.Query().Get("tag")
+	if tag == "" {
+		tag = "latest"
+	}
+	repository := repo
+	if i := strings.Index(repo, "/"); i >= 0 && strings.ContainsAny(repo[:i], ".:") { // Leave the registry host out
+		repository = repo[i+1:]
+	}
+	if !repositoryName.MatchString(repository) {
+		writeError(w, http.StatusBadRequest, "invalid reference format: repository name must be lowercase")
+		return
+	}
+	if !tagName.MatchString(tag) {
+		writeError(w, http.StatusBadRequest, "invalid tag format")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.findImage(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such image: %s", name)
+		return
+	}
+
+	ref := repo + ":" + tag
+	if !contains(img.RepoTags, ref) {
+		s.untag(ref)
+		img.RepoTags = append(img.RepoTags, ref)
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+/* removeImage handles DELETE /images/{name}. Removing a reference of an image with several ones only untags it,
+otherwise the image is deleted unless a container uses it (or it has several references and it was given by ID),
+which force overrides for stopped containers. Fake images have no parents, so noprune is accepted and ignored */
+func (s *Server) removeImage(w http.ResponseWriter, r *http.Request, name string) {
+	force := queryBool(r.URL.Query(), "force")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.findImage(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such image: %s", name)
+		return
+	}
+
+	ref := normalizeReference(name)
+	byReference := contains(img.RepoTags, ref)
+	if byReference && len(img.RepoTags) > 1 {
+		s.untag(ref)
+		writeJSON(w, http.StatusOK, []map[string]string{{"Untagged": ref}})
+		return
+	}
+	if !byReference && len(img.RepoTags) > 1 && !force {
+		writeError(w, http.StatusConflict, "conflict: unable to delete %s (must be forced) - image is referenced in multiple repositories",
+			shortImageID(img))
+		return
+	}
+	for _, c := range s.imageUsers(img) {
+		if c.State.Running {
+			writeError(w, http.StatusConflict, "conflict: unable to delete %s (cannot be forced) - image is being used by running container %s",
+				shortImageID(img), c.ID[:12])
+			return
+		}
+		if !force {
+			writeError(w, http.StatusConflict, "conflict: unable to delete %s (must be forced) - image is being used by stopped container %s",
+				shortImageID(img), c.ID[:12])
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.deleteImage(img))
+}
+
+// deleteImage removes an image alongside its references, returning the items reported by the daemon. s.mu must be held
+func (s *Server) deleteImage(img *image) []map[string]string {
+	deleted := []map[string]string{}
+	for _, ref := range img.RepoTags {
+		deleted = append(deleted, map[string]string{"Untagged": ref})
+	}
+	for _, digest := range img.RepoDigests {
+		deleted = append(deleted, map[string]string{"Untagged": digest})
+	}
+	deleted = append(deleted, map[string]string{"Deleted": img.ID}, map[string]string{"Deleted": img.Layer})
+	delete(s.images, img.ID)
+	return deleted
+}
+
+/* pruneImages handles POST /images/prune, removing the dangling images that no container uses, or every unused image
+if the dangling filter is false. Other filters supported are until, label and label! */
+func (s *Server) pruneImages(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := checkFilters(filters, "dangling", "until", "label", "label!"); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	danglingOnly := true
+	if values := filters["dangling"]; len(values) > 0 {
+		switch values[0] {
+		case "true", "1":
+		case "false", "0":
+			danglingOnly = false
+		default:
+			writeError(w, http.StatusBadRequest, "invalid filter 'dangling=%s'", values[0])
+			return
+		}
+	}
+	var until time.Time
+	if values := filters["until"]; len(values) > 0 {
+		if until, err = parseFilterTime(values[0]); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := []map[string]string{}
+	var reclaimed int64
+	for _, img := range s.sortedImages() {
+		if (danglingOnly && len(img.RepoTags) > 0) || len(s.imageUsers(img)) > 0 {
+			continue
+		}
+		if (!until.IsZero() && !img.Created.Before(until)) || !matchLabels(img.Config.Labels, filters["label"]) {
+			continue
+		}
+		excluded := false
+		for _, label := range filters["label!"] {
+			excluded = excluded || matchLabels(img.Config.Labels, []string{label})
+		}
+		if excluded {
+			continue
+		}
+
+		deleted = append(deleted, s.deleteImage(img)...)
+		reclaimed += fakeLayerSize
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ImagesDeleted": deleted, "SpaceReclaimed": reclaimed})
+}
+
+// shortImageID returns the first 12 characters of an image ID, without the algorithm
+func shortImageID(img *image) string {
+	return strings.TrimPrefix(img.ID, "sha256:")[:12]
+}
+
+// reference builds a name:tag image reference, defaulting to the latest tag
+func reference(name string, tag string) string {
+	if tag == "" {
+		tag = "latest"
+	}
+	return name + ":" + tag
+}
+
+// normalizeReference adds the latest tag to references without one
+func normalizeReference(ref string) string {
+	if strings.LastIndex(ref, ":") > strings.LastIndex(ref, "/") {
+		return ref
+	}
+	return ref + ":latest"
+}
@@ -0,0 +1,154 @@
+package dockertest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// signals maps the signal names accepted by the daemon to their number
+var signals = map[string]int{
+	"HUP":  1,
+	"INT":  2,
+	"QUIT": 3,
+	"ABRT": 6,
+	"KILL": 9,
+	"USR1": 10,
+	"USR2": 12,
+	"PIPE": 13,
+	"ALRM": 14,
+	"TERM": 15,
+}
+
+/* parseSignal parses a signal given either as name, with or without the SIG prefix (e.g. SIGKILL or KILL), or as number.
+It returns the signal number */
+func parseSignal(signal string) (int, error) {
+	if number, err := strconv.Atoi(signal); err == nil {
+		if number <= 0 || number > 64 {
+			return 0, fmt.Errorf("Invalid signal: %s", signal)
+		}
+		return number, nil
+	}
+	number, ok := signals[strings.TrimPrefix(strings.ToUpper(signal), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("Invalid signal: %s", signal)
+	}
+	return number, nil
+}
+
+/* stopQuery validates the t and signal parameters of the stop and restart endpoints.
+It returns the exit code of the container once stopped */
+func stopQuery(query url.Values) (int, error) {
+	if t := query.Get("t"); t != "" {
+		if _, err := strconv.Atoi(t); err != nil {
+			return 0, fmt.Errorf("invalid value for t: %q", t)
+		}
+	}
+	if signal := query.Get("signal"); signal != "" {
+		number, err := parseSignal(signal)
+		if err != nil {
+			return 0, err
+		}
+		return 128 + number, nil
+	}
+	return 0, nil
+}
+
+// restartContainer handles POST /containers/{id}/restart, which stops the container if it is running and starts it again
+func (s *Server) restartContainer(w http.ResponseWriter, r *http.Request, id string) {
+	exitCode, err := stopQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.findContainer(id)
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if c.State.Running {
+		c.exit(exitCode)
+	}
+
+	s.start(c)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/* killContainer handles POST /containers/{id}/kill. The fake containers are terminated by any signal,
+exiting with the code of a process killed by it */
+func (s *Server) killContainer(w http.ResponseWriter, r *http.Request, id string) {
+	signal := r.URL.Query().Get("signal")
+	if signal == "" {
+		signal = "SIGKILL"
+	}
+	number, err := parseSignal(signal)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if !c.State.Running {
+		writeError(w, http.StatusConflict, "Container %s is not running", c.ID)
+		return
+	}
+
+	c.exit(128 + number)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pauseContainer handles POST /containers/{id}/pause
+func (s *Server) pauseContainer(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if !c.State.Running {
+		writeError(w, http.StatusConflict, "Container %s is not running", c.ID)
+		return
+	}
+	if c.State.Status == "paused" {
+		writeError(w, http.StatusConflict, "Container %s is already paused", c.ID)
+		return
+	}
+
+	c.State.Status = "paused"
+	c.notify()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unpauseContainer handles POST /containers/{id}/unpause
+func (s *Server) unpauseContainer(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	if c.State.Status != "paused" {
+		writeError(w, http.StatusConflict, "Container %s is not paused", c.ID)
+		return
+	}
+
+	c.State.Status = "running"
+	c.notify()
+	w.WriteHeader(http.StatusNoContent)
+}
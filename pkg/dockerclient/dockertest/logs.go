@@ -0,0 +1,183 @@
+package dockertest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logTimestampFormat is the format docker uses for timestamps in logs, with a fixed amount of digits
+const logTimestampFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// logEntry is a single line written by a container command
+type logEntry struct {
+	Time   time.Time
+	Stream byte
+	Line   string // Line includes the trailing newline
+}
+
+/* AddLogs appends output to the logs of a container given its ID or name, as if its command wrote it.
+Clients following the logs get the new lines straight away. It returns false if the container does not exist */
+func (s *Server) AddLogs(idOrName string, stdout string, stderr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(idOrName)
+	if !ok {
+		return false
+	}
+	c.appendLogs(streamStdout, stdout)
+	c.appendLogs(streamStderr, stderr)
+	return true
+}
+
+// appendLogs splits output in lines and stores them in the container logs. s.mu must be held
+func (c *container) appendLogs(stream byte, output string) {
+	if output == "" {
+		return
+	}
+	now := time.Now()
+	for _, line := range strings.SplitAfter(output, "\n") {
+		if line != "" {
+			c.logs = append(c.logs, logEntry{Time: now, Stream: stream, Line: line})
+		}
+	}
+	c.notify()
+}
+
+/* notify wakes up every client waiting for the container to change (new logs, exit or removal). s.mu must be held.
+c.changed is closed and replaced, so it can be used as a broadcast */
+func (c *container) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// logsQuery are the settings of a logs query
+type logsQuery struct {
+	stdout     bool
+	stderr     bool
+	since      time.Time
+	until      time.Time
+	timestamps bool
+}
+
+// selectLogs returns the entries matching the query. s.mu must be held
+func (q logsQuery) selectLogs(entries []logEntry) []logEntry {
+	var selected []logEntry
+	for _, entry := range entries {
+		if (entry.Stream == streamStdout && !q.stdout) || (entry.Stream == streamStderr && !q.stderr) {
+			continue
+		}
+		if (!q.since.IsZero() && entry.Time.Before(q.since)) || (!q.until.IsZero() && !entry.Time.Before(q.until)) {
+			continue
+		}
+		selected = append(selected, entry)
+	}
+	return selected
+}
+
+// containerLogs handles GET /containers/{id}/logs
+func (s *Server) containerLogs(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+	q := logsQuery{
+		stdout:     queryBool(query, "stdout"),
+		stderr:     queryBool(query, "stderr"),
+		timestamps: queryBool(query, "timestamps"),
+	}
+	if !q.stdout && !q.stderr {
+		writeError(w, http.StatusBadRequest, "Bad parameters: you must choose at least one stream")
+		return
+	}
+	var err error
+	if q.since, err = parseUnixTimestamp(query.Get("since")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid value for \"since\": %s", err)
+		return
+	}
+	if q.until, err = parseUnixTimestamp(query.Get("until")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid value for \"until\": %s", err)
+		return
+	}
+	tail, err := strconv.Atoi(query.Get("tail"))
+	if err != nil {
+		tail = -1 // "all" and any invalid value return every line, as docker does
+	}
+	follow := queryBool(query, "follow") && (q.until.IsZero() || q.until.After(time.Now()))
+
+	s.mu.Lock()
+	c, ok := s.findContainer(id)
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	tty := c.Config.Tty
+	entries := q.selectLogs(c.logs)
+	if tail >= 0 && len(entries) > tail {
+		entries = entries[len(entries)-tail:]
+	}
+	next, changed, running := len(c.logs), c.changed, c.State.Running
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", outputContentType(tty))
+	w.WriteHeader(http.StatusOK)
+	q.writeLogs(w, tty, entries)
+
+	// Following ends when the container stops or is removed, or when the client goes away
+	for follow && running {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+
+		s.mu.Lock()
+		if s.containers[c.ID] != c {
+			s.mu.Unlock()
+			return
+		}
+		entries = q.selectLogs(c.logs[next:])
+		next, changed, running = len(c.logs), c.changed, c.State.Running
+		s.mu.Unlock()
+
+		q.writeLogs(w, tty, entries)
+	}
+}
+
+// writeLogs sends log entries either raw or multiplexed in frames, and flushes them to the client
+func (q logsQuery) writeLogs(w http.ResponseWriter, tty bool, entries []logEntry) {
+	for _, entry := range entries {
+		line := entry.Line
+		if q.timestamps {
+			line = entry.Time.UTC().Format(logTimestampFormat) + " " + line
+		}
+		if tty {
+			w.Write([]byte(strings.ReplaceAll(line, "\n", "\r\n")))
+		} else {
+			writeFrame(w, entry.Stream, []byte(line))
+		}
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// parseUnixTimestamp parses a time given as seconds since epoch, optionally with a fractional part. Empty means the zero time
+func parseUnixTimestamp(value string) (time.Time, error) {
+	if value == "" || value == "0" {
+		return time.Time{}, nil
+	}
+	parts := strings.SplitN(value, ".", 2)
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nanoseconds int64
+	if len(parts) == 2 {
+		fraction := (parts[1] + "000000000")[:9]
+		if nanoseconds, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(seconds, nanoseconds), nil
+}
@@ -0,0 +1,629 @@
+package dockertest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// network is the in-memory representation of a network
+type network struct {
+	ID         string
+	Name       string
+	Driver     string
+	Created    time.Time
+	Internal   bool
+	Attachable bool
+	Labels     map[string]string
+	Options    map[string]string
+	builtin    bool       // builtin tells the predefined networks (bridge, host and none) apart
+	subnet     *net.IPNet // subnet is nil for networks without addresses (host and none)
+	ipRange    *net.IPNet // ipRange is the part of the subnet addresses are allocated from
+	gateway    net.IP
+	userSubnet bool // userSubnet tells whether the subnet was given when creating the network, which static addresses need
+}
+
+// endpoint is the attachment of a container to a network
+type endpoint struct {
+	ID        string
+	Aliases   []string
+	StaticIP  string // StaticIP is the address requested for the container, if any
+	IPAddress string // IPAddress is the address of the container in the network, only set while running
+}
+
+// newBuiltinNetworks returns the networks every daemon starts with, by ID
+func newBuiltinNetworks() map[string]*network {
+	_, bridgeSubnet, _ := net.ParseCIDR("172.17.0.0/16")
+	networks := []*network{
+		{Name: "bridge", Driver: "bridge", subnet: bridgeSubnet, ipRange: bridgeSubnet, gateway: net.ParseIP("172.17.0.1").To4()},
+		{Name: "host", Driver: "host"},
+		{Name: "none", Driver: "null"},
+	}
+	byID := make(map[string]*network)
+	for _, n := range networks {
+		n.ID, n.Created, n.builtin = generateID(), time.Now(), true
+		n.Labels, n.Options = map[string]string{}, map[string]string{}
+		byID[n.ID] = n
+	}
+	return byID
+}
+
+// findNetwork looks for a network by ID, name or ID prefix. s.mu must be held
+func (s *Server) findNetwork(nameOrID string) (*network, bool) {
+	if n, ok := s.networks[nameOrID]; ok {
+		return n, true
+	}
+	for _, n := range s.networks {
+		if n.Name == nameOrID {
+			return n, true
+		}
+	}
+	for id, n := range s.networks {
+		if strings.HasPrefix(id, nameOrID) {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// networkUsers returns the containers attached to a network. s.mu must be held
+func (s *Server) networkUsers(n *network) []*container {
+	var users []*container
+	for _, c := range s.containers {
+		if _, ok := c.networks[n.ID]; ok {
+			users = append(users, c)
+		}
+	}
+	return users
+}
+
+// routeNetworks dispatches queries under /networks
+func (s *Server) routeNetworks(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		s.listNetworks(w, r)
+		return
+	case path == "create" && r.Method == http.MethodPost:
+		s.createNetwork(w, r)
+		return
+	case path == "prune" && r.Method == http.MethodPost:
+		s.pruneNetworks(w, r)
+		return
+	}
+
+	id, action := splitPath(path)
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.inspectNetwork(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		s.removeNetwork(w, r, id)
+	case action == "connect" && r.Method == http.MethodPost:
+		s.connectNetwork(w, r, id)
+	case action == "disconnect" && r.Method == http.MethodPost:
+		s.disconnectNetwork(w, r, id)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// createNetwork handles POST /networks/create
+func (s *Server) createNetwork(w http.ResponseWriter, r *http.Request) {
+	var body models.CreateNetworkBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %s", err)
+		return
+	}
+	if body.Name == "" {
+		writeError(w, http.StatusBadRequest, "network name is required")
+		return
+	}
+	if body.Driver == "" {
+		body.Driver = "bridge"
+	}
+	if body.Driver != "bridge" {
+		writeError(w, http.StatusNotFound, "plugin %q not found", body.Driver)
+		return
+	}
+
+	n := &network{
+		ID:         generateID(),
+		Name:       body.Name,
+		Driver:     body.Driver,
+		Created:    time.Now(),
+		Internal:   body.Internal,
+		Attachable: body.Attachable,
+		Labels:     body.Labels,
+		Options:    body.Options,
+	}
+	if n.Labels == nil {
+		n.Labels = map[string]string{}
+	}
+	if n.Options == nil {
+		n.Options = map[string]string{}
+	}
+	if body.IPAM != nil && len(body.IPAM.Config) > 1 {
+		writeError(w, http.StatusBadRequest, "the fake daemon supports a single address pool per network")
+		return
+	}
+	if body.IPAM != nil && len(body.IPAM.Config) == 1 {
+		if err := n.configureIPAM(body.IPAM.Config[0]); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findNetwork(body.Name); ok {
+		writeError(w, http.StatusConflict, "network with name %s already exists", body.Name)
+		return
+	}
+	if n.subnet == nil {
+		n.subnet = s.freeSubnet()
+		if n.subnet == nil {
+			writeError(w, http.StatusForbidden, "could not find an available, non-overlapping IPv4 address pool among the defaults to assign to the network")
+			return
+		}
+		n.ipRange, n.gateway = n.subnet, firstHost(n.subnet)
+	}
+	for _, other := range s.networks {
+		if other.subnet != nil && overlaps(other.subnet, n.subnet) {
+			writeError(w, http.StatusForbidden, "Pool overlaps with other one on this address space")
+			return
+		}
+	}
+	s.networks[n.ID] = n
+
+	writeJSON(w, http.StatusCreated, map[string]string{"Id": n.ID, "Warning": ""})
+}
+
+// configureIPAM sets the subnet, range and gateway of a new network from the address pool given
+func (n *network) configureIPAM(config models.IPAMConfig) error {
+	if config.Subnet == "" {
+		return fmt.Errorf("an address pool needs a subnet")
+	}
+	_, subnet, err := net.ParseCIDR(config.Subnet)
+	if err != nil || subnet.IP.To4() == nil {
+		return fmt.Errorf("invalid subnet %s: the fake daemon supports IPv4 subnets in CIDR notation only", config.Subnet)
+	}
+	n.subnet, n.ipRange, n.gateway, n.userSubnet = subnet, subnet, firstHost(subnet), true
+
+	if config.IPRange != "" {
+		_, ipRange, err := net.ParseCIDR(config.IPRange)
+		if err != nil || !subnet.Contains(ipRange.IP) {
+			return fmt.Errorf("invalid ip range %s: it must be part of subnet %s", config.IPRange, config.Subnet)
+		}
+		n.ipRange = ipRange
+	}
+	if config.Gateway != "" {
+		gateway := net.ParseIP(config.Gateway).To4()
+		if gateway == nil || !subnet.Contains(gateway) {
+			return fmt.Errorf("no matching subnet for gateway %s", config.Gateway)
+		}
+		n.gateway = gateway
+	}
+	return nil
+}
+
+// freeSubnet returns the first 172.x.0.0/16 subnet not used by any network, or nil if there are none left. s.mu must be held
+func (s *Server) freeSubnet() *net.IPNet {
+	for x := 18; x < 32; x++ {
+		_, subnet, _ := net.ParseCIDR(fmt.Sprintf("172.%d.0.0/16", x))
+		used := false
+		for _, n := range s.networks {
+			used = used || (n.subnet != nil && overlaps(n.subnet, subnet))
+		}
+		if !used {
+			return subnet
+		}
+	}
+	return nil
+}
+
+// overlaps tells whether two subnets share any address
+func overlaps(a *net.IPNet, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// firstHost returns the first host address of a subnet, used as its gateway
+func firstHost(subnet *net.IPNet) net.IP {
+	return intToIP(ipToInt(subnet.IP) + 1)
+}
+
+// ipToInt converts an IPv4 address to an integer, to walk subnets
+func ipToInt(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+// intToIP converts an integer back to an IPv4 address
+func intToIP(i uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, i)
+	return ip
+}
+
+/* allocateIP returns a free address of the network for a container, skipping the gateway, the addresses of running
+containers and the static ones requested by any container. It returns an empty string if the network is full. s.mu must be held */
+func (s *Server) allocateIP(n *network) string {
+	used := map[string]bool{n.gateway.String(): true}
+	for _, c := range s.networkUsers(n) {
+		used[c.networks[n.ID].IPAddress] = true
+		used[c.networks[n.ID].StaticIP] = true
+	}
+
+	ones, bits := n.ipRange.Mask.Size()
+	first := ipToInt(n.ipRange.IP)
+	last := first + uint32(1)<<uint(bits-ones) - 1
+	for i := first + 1; i < last; i++ {
+		ip := intToIP(i)
+		if n.subnet.Contains(ip) && !used[ip.String()] {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+/* newEndpoint checks the settings of a container in a network the way the daemon does, and returns the endpoint
+of the container. It returns the status code and error to report if the settings are rejected. s.mu must be held */
+func (s *Server) newEndpoint(n *network, settings *models.EndpointSettings) (*endpoint, int, error) {
+	e := &endpoint{ID: generateID()}
+	if settings == nil {
+		return e, 0, nil
+	}
+	if len(settings.Aliases) > 0 && n.builtin {
+		return nil, http.StatusBadRequest, fmt.Errorf("network-scoped alias is supported only for containers in user defined networks")
+	}
+	e.Aliases = settings.Aliases
+
+	if settings.IPAMConfig == nil || settings.IPAMConfig.IPv4Address == "" {
+		return e, 0, nil
+	}
+	if !n.userSubnet {
+		return nil, http.StatusBadRequest, fmt.Errorf("user specified IP address is supported only when connecting to networks with user configured subnets")
+	}
+	ip := net.ParseIP(settings.IPAMConfig.IPv4Address).To4()
+	if ip == nil || !n.subnet.Contains(ip) {
+		return nil, http.StatusBadRequest, fmt.Errorf("no configured subnet contains IP address %s", settings.IPAMConfig.IPv4Address)
+	}
+	inUse := n.gateway.Equal(ip)
+	for _, c := range s.networkUsers(n) {
+		other := c.networks[n.ID]
+		inUse = inUse || other.StaticIP == ip.String() || other.IPAddress == ip.String()
+	}
+	if inUse {
+		return nil, http.StatusForbidden, fmt.Errorf("Address already in use")
+	}
+	e.StaticIP = ip.String()
+	return e, 0, nil
+}
+
+// assignIPs gives the running container an address in every network it is attached to. s.mu must be held
+func (s *Server) assignIPs(c *container) {
+	for networkID, e := range c.networks {
+		if n := s.networks[networkID]; n != nil && n.subnet != nil && e.IPAddress == "" {
+			e.IPAddress = e.StaticIP
+			if e.IPAddress == "" {
+				e.IPAddress = s.allocateIP(n)
+			}
+		}
+	}
+}
+
+// releaseIPs takes the addresses of a container back, once it is no longer running
+func (c *container) releaseIPs() {
+	for _, e := range c.networks {
+		e.IPAddress = ""
+	}
+}
+
+// macAddress returns the MAC address docker derives from an IPv4 address
+func macAddress(ip string) string {
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil {
+		return ""
+	}
+	return fmt.Sprintf("02:42:%02x:%02x:%02x:%02x", parsed[0], parsed[1], parsed[2], parsed[3])
+}
+
+// networkResource returns the network as listed or inspected. Attached containers are only given when inspecting. s.mu must be held
+func (s *Server) networkResource(n *network, withContainers bool) models.NetworkResource {
+	resource := models.NetworkResource{
+		Name:       n.Name,
+		ID:         n.ID,
+		Created:    n.Created,
+		Scope:      "local",
+		Driver:     n.Driver,
+		IPAM:       models.IPAM{Driver: "default", Config: []models.IPAMConfig{}},
+		Internal:   n.Internal,
+		Attachable: n.Attachable,
+		Containers: map[string]models.NetworkContainer{},
+		Options:    n.Options,
+		Labels:     n.Labels,
+	}
+	if n.subnet != nil {
+		config := models.IPAMConfig{Subnet: n.subnet.String(), Gateway: n.gateway.String()}
+		if n.ipRange.String() != n.subnet.String() {
+			config.IPRange = n.ipRange.String()
+		}
+		resource.IPAM.Config = append(resource.IPAM.Config, config)
+	}
+	if !withContainers {
+		return resource
+	}
+
+	for _, c := range s.networkUsers(n) {
+		e := c.networks[n.ID]
+		if !c.State.Running {
+			continue // Only running containers have an endpoint in the network
+		}
+		attached := models.NetworkContainer{Name: c.Name, EndpointID: e.ID}
+		if e.IPAddress != "" {
+			ones, _ := n.subnet.Mask.Size()
+			attached.IPv4Address = fmt.Sprintf("%s/%d", e.IPAddress, ones)
+			attached.MacAddress = macAddress(e.IPAddress)
+		}
+		resource.Containers[c.ID] = attached
+	}
+	return resource
+}
+
+// listNetworks handles GET /networks
+func (s *Server) listNetworks(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := checkFilters(filters, "name", "id", "driver", "label", "scope", "type"); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	for _, networkType := range filters["type"] {
+		if networkType != "custom" && networkType != "builtin" {
+			writeError(w, http.StatusBadRequest, "invalid filter: 'type'='%s'", networkType)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	networks := []models.NetworkResource{}
+	for _, n := range s.networks {
+		networkType := "custom"
+		if n.builtin {
+			networkType = "builtin"
+		}
+		if !matchAny(filters["name"], func(name string) bool { return strings.Contains(n.Name, name) }) ||
+			!matchAny(filters["id"], func(id string) bool { return strings.HasPrefix(n.ID, id) }) ||
+			!matchAny(filters["driver"], func(driver string) bool { return n.Driver == driver }) ||
+			!matchAny(filters["scope"], func(scope string) bool { return scope == "local" }) ||
+			!matchAny(filters["type"], func(t string) bool { return t == networkType }) ||
+			!matchLabels(n.Labels, filters["label"]) {
+			continue
+		}
+		networks = append(networks, s.networkResource(n, false))
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+
+	writeJSON(w, http.StatusOK, networks)
+}
+
+// matchAny tells whether any of the values of a filter matches, or true if the filter is not set
+func matchAny(values []string, match func(string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectNetwork handles GET /networks/{id}
+func (s *Server) inspectNetwork(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.findNetwork(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "network %s not found", id)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.networkResource(n, true))
+}
+
+// removeNetwork handles DELETE /networks/{id}
+func (s *Server) removeNetwork(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.findNetwork(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "network %s not found", id)
+		return
+	}
+	if n.builtin {
+		writeError(w, http.StatusForbidden, "%s is a pre-defined network and cannot be removed", n.Name)
+		return
+	}
+	if len(s.networkUsers(n)) > 0 {
+		writeError(w, http.StatusForbidden, "error while removing network: network %s id %s has active endpoints", n.Name, n.ID)
+		return
+	}
+	delete(s.networks, n.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pruneNetworks handles POST /networks/prune
+func (s *Server) pruneNetworks(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if err := checkFilters(filters, "until", "label", "label!"); err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	var until time.Time
+	if values := filters["until"]; len(values) > 0 {
+		if len(values) > 1 {
+			writeError(w, http.StatusBadRequest, "more than one until filter specified")
+			return
+		}
+		if until, err = parseFilterTime(values[0]); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := []string{}
+	for id, n := range s.networks {
+		if n.builtin || len(s.networkUsers(n)) > 0 || (!until.IsZero() && !n.Created.Before(until)) || !matchLabels(n.Labels, filters["label"]) {
+			continue
+		}
+		excluded := false
+		for _, label := range filters["label!"] {
+			excluded = excluded || matchLabels(n.Labels, []string{label})
+		}
+		if excluded {
+			continue
+		}
+
+		delete(s.networks, id)
+		deleted = append(deleted, n.Name)
+	}
+	sort.Strings(deleted)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"NetworksDeleted": deleted})
+}
+
+// connectNetwork handles POST /networks/{id}/connect
+func (s *Server) connectNetwork(w http.ResponseWriter, r *http.Request, id string) {
+	var body models.ConnectNetworkBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.findNetwork(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "network %s not found", id)
+		return
+	}
+	c, ok := s.findContainer(body.Container)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", body.Container)
+		return
+	}
+	if mode := c.networkMode(); n.Name == "host" || n.Name == "none" || mode == "host" || mode == "none" || strings.HasPrefix(mode, "container:") {
+		writeError(w, http.StatusBadRequest, "container sharing network namespace with another container or host cannot be connected to any other network")
+		return
+	}
+	if _, ok := c.networks[n.ID]; ok {
+		writeError(w, http.StatusForbidden, "endpoint with name %s already exists in network %s", c.Name, n.Name)
+		return
+	}
+
+	e, statusCode, err := s.newEndpoint(n, body.EndpointConfig)
+	if err != nil {
+		writeError(w, statusCode, "%s", err)
+		return
+	}
+	c.networks[n.ID] = e
+	if c.State.Running {
+		s.assignIPs(c)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// disconnectNetwork handles POST /networks/{id}/disconnect
+func (s *Server) disconnectNetwork(w http.ResponseWriter, r *http.Request, id string) {
+	var body models.DisconnectNetworkBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.findNetwork(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "network %s not found", id)
+		return
+	}
+	c, ok := s.findContainer(body.Container)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", body.Container)
+		return
+	}
+	if _, ok := c.networks[n.ID]; !ok {
+		writeError(w, http.StatusForbidden, "container %s is not connected to network %s", c.ID, n.Name)
+		return
+	}
+	delete(c.networks, n.ID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// networkMode returns the network mode of the container, as docker reports it when inspecting the container
+func (c *container) networkMode() string {
+	if c.HostConfig.NetworkMode == "" {
+		return "default"
+	}
+	return c.HostConfig.NetworkMode
+}
+
+/* containerNetworks returns the attachments of a new container: the network of its network mode, with the settings
+of its networking config. It returns the status code and error to report if they are rejected. s.mu must be held */
+func (s *Server) containerNetworks(body createContainerBody) (map[string]*endpoint, int, error) {
+	endpoints := body.NetworkingConfig.EndpointsConfig
+	if len(endpoints) > 1 {
+		return nil, http.StatusBadRequest, fmt.Errorf("Container cannot be connected to network endpoints: the fake daemon accepts a single network when creating a container")
+	}
+
+	mode := body.HostConfig.NetworkMode
+	switch {
+	case strings.HasPrefix(mode, "container:"):
+		if len(endpoints) > 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("conflicting options: container type network can't be used with links. This would result in undefined behavior")
+		}
+		return map[string]*endpoint{}, 0, nil
+	case mode == "" || mode == "default":
+		mode = "bridge"
+	}
+	n, ok := s.findNetwork(mode)
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("network %s not found", mode)
+	}
+
+	var settings *models.EndpointSettings
+	for name, endpointSettings := range endpoints {
+		if named, ok := s.findNetwork(name); !ok || named.ID != n.ID {
+			return nil, http.StatusBadRequest, fmt.Errorf("the network settings given are for network %s, not for %s", name, mode)
+		}
+		settings = endpointSettings
+	}
+	e, statusCode, err := s.newEndpoint(n, settings)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return map[string]*endpoint{n.ID: e}, 0, nil
+}
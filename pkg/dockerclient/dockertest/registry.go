@@ -0,0 +1,129 @@
+package dockertest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// defaultRegistry is the registry host of references without one (Docker Hub)
+const defaultRegistry = "docker.io"
+
+/* SetRegistryCredentials makes the registry given (a host such as registry.example.com:5000, or docker.io for
+references without one) require the username and password given to pull or push images */
+func (s *Server) SetRegistryCredentials(registry string, username string, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.registryAuth[registry] = models.AuthConfig{Username: username, Password: password}
+}
+
+// SetRegistryIdentityToken makes the registry given require the identity token given to pull or push images
+func (s *Server) SetRegistryIdentityToken(registry string, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.registryAuth[registry] = models.AuthConfig{IdentityToken: token}
+}
+
+/* checkRegistryAuth checks the X-Registry-Auth header of a query against the credentials of the registry of
+the repository given. It returns the error the registry reports, or an empty string if access is granted. s.mu must be held */
+func (s *Server) checkRegistryAuth(r *http.Request, repository string) string {
+	want, ok := s.registryAuth[registryHost(repository)]
+	if !ok {
+		return ""
+	}
+
+	var got models.AuthConfig
+	header := r.Header.Get("X-Registry-Auth")
+	decoded, err := base64.URLEncoding.DecodeString(header)
+	if header == "" || err != nil || json.Unmarshal(decoded, &got) != nil || (got == models.AuthConfig{}) {
+		return "unauthorized: authentication required"
+	}
+	if want.IdentityToken != "" && got.IdentityToken == want.IdentityToken {
+		return ""
+	}
+	if want.Username != "" && got.Username == want.Username && got.Password == want.Password {
+		return ""
+	}
+	return "unauthorized: incorrect username or password"
+}
+
+/* pushImage handles POST /images/{name}/push, pushing the tag given or every tag of the repository.
+Pushed references become available to be pulled from the fake registry. As with the daemon, errors
+reported by the registry (e.g. unauthorized) come in the middle of the progress stream */
+func (s *Server) pushImage(w http.ResponseWriter, r *http.Request, name string) {
+	tag := r.URL.Query().Get("tag")
+
+	s.mu.Lock()
+	var images []*image
+	var tags []string
+	for _, img := range s.sortedImages() {
+		for _, ref := range img.RepoTags {
+			i := strings.LastIndex(ref, ":")
+			if ref[:i] == name && (tag == "" || ref[i+1:] == tag) {
+				images, tags = append(images, img), append(tags, ref[i+1:])
+			}
+		}
+	}
+	if len(images) == 0 {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "An image does not exist locally with the tag: %s", name)
+		return
+	}
+
+	authError := s.checkRegistryAuth(r, name)
+	digests := make([]string, len(images))
+	if authError == "" {
+		for i, img := range images {
+			s.registry[reference(name, tags[i])] = ""
+			digest := name + "@sha256:" + generateID()
+			img.RepoDigests = append(removeRepository(img.RepoDigests, name), digest)
+			digests[i] = digest[len(name)+1:]
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	writeJSONLine(w, map[string]string{"status": "The push refers to repository [" + name + "]"})
+	for i, img := range images {
+		layerID := strings.TrimPrefix(img.Layer, "sha256:")[:12]
+		writeJSONLine(w, map[string]string{"status": "Preparing", "id": layerID})
+		if authError != "" {
+			writeJSONLine(w, map[string]interface{}{"error": authError, "errorDetail": map[string]string{"message": authError}})
+			return
+		}
+		writeJSONLine(w, map[string]interface{}{"status": "Pushing", "id": layerID,
+			"progressDetail": map[string]int{"current": fakeLayerSize, "total": fakeLayerSize}})
+		writeJSONLine(w, map[string]string{"status": "Pushed", "id": layerID})
+		writeJSONLine(w, map[string]string{"status": tags[i] + ": digest: " + digests[i] + " size: 528"})
+	}
+}
+
+// removeRepository returns the digests given (repository@digest) leaving out the ones of the repository given
+func removeRepository(repoDigests []string, repository string) []string {
+	var kept []string
+	for _, repoDigest := range repoDigests {
+		if !strings.HasPrefix(repoDigest, repository+"@") {
+			kept = append(kept, repoDigest)
+		}
+	}
+	return kept
+}
+
+// registryHost returns the registry host of a repository, or defaultRegistry if it has none
+func registryHost(repository string) string {
+	i := strings.Index(repository, "/")
+	if i < 0 {
+		return defaultRegistry
+	}
+	host := repository[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return defaultRegistry
+	}
+	return host
+}
@@ -0,0 +1,215 @@
+/* Package dockertest provides an in-process fake docker daemon, so code built on top of the docker
+Engine API can be tested without a real daemon or network access.
+
+The fake keeps its images, containers and exec instances in memory and emulates the status codes and
+error messages a real daemon (API v1.41) returns for the endpoints the dockerclient package uses */
+package dockertest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// apiVersionPrefix matches the optional version prefix of a query path (e.g. /v1.41)
+var apiVersionPrefix = regexp.MustCompile(`^/v[0-9]+\.[0-9]+`)
+
+// Server is a fake docker daemon listening on a local HTTP port
+type Server struct {
+	// URL is the base URL of the fake daemon, ready to be used as docker endpoint
+	URL string
+
+	// ExecHandler computes the result of every command executed inside a container, either
+	// through an exec instance or as the container command. Defaults to DefaultExecHandler
+	ExecHandler ExecHandler
+
+	// StatsInterval is the time between two samples when streaming the stats of a container. Defaults to one second, as docker does
+	StatsInterval time.Duration
+
+	httpServer *httptest.Server
+	done       chan struct{} // done is closed when the server is closed, stopping background work such as healthchecks
+
+	mu         sync.Mutex
+	registry   map[string]string // references (name:tag) available to be pulled, alongside the error to report while pulling
+	images     map[string]*image // images by ID
+	containers map[string]*container
+	execs      map[string]*exec
+	networks   map[string]*network // networks by ID
+
+	// registryAuth are the credentials required by registry host, registries not in the map accept anonymous pulls and pushes
+	registryAuth map[string]models.AuthConfig
+}
+
+// NewServer starts a new fake docker daemon. It must be closed using Close once done
+func NewServer() *Server {
+	s := &Server{
+		ExecHandler:   DefaultExecHandler,
+		StatsInterval: time.Second,
+		registry:      make(map[string]string),
+		registryAuth:  make(map[string]models.AuthConfig),
+		images:        make(map[string]*image),
+		containers:    make(map[string]*container),
+		execs:         make(map[string]*exec),
+		networks:      newBuiltinNetworks(),
+		done:          make(chan struct{}),
+	}
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.route))
+	s.URL = s.httpServer.URL
+
+	return s
+}
+
+// Close shuts down the fake daemon
+func (s *Server) Close() {
+	close(s.done)
+	s.httpServer.Close()
+}
+
+// route dispatches every query to its handler depending on the path
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := apiVersionPrefix.ReplaceAllString(r.URL.Path, "")
+
+	switch {
+	case path == "/_ping":
+		w.Write([]byte("OK"))
+	case path == "/build" && r.Method == http.MethodPost:
+		s.buildImage(w, r)
+	case strings.HasPrefix(path, "/images/"):
+		s.routeImages(w, r, strings.TrimPrefix(path, "/images/"))
+	case strings.HasPrefix(path, "/containers/"):
+		s.routeContainers(w, r, strings.TrimPrefix(path, "/containers/"))
+	case path == "/networks":
+		s.routeNetworks(w, r, "")
+	case strings.HasPrefix(path, "/networks/"):
+		s.routeNetworks(w, r, strings.TrimPrefix(path, "/networks/"))
+	case strings.HasPrefix(path, "/exec/"):
+		s.routeExec(w, r, strings.TrimPrefix(path, "/exec/"))
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// writeJSON sends body encoded as JSON with the status code given
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError sends an error the same way the docker daemon does
+func writeError(w http.ResponseWriter, statusCode int, format string, a ...interface{}) {
+	writeJSON(w, statusCode, map[string]string{"message": fmt.Sprintf(format, a...)})
+}
+
+// generateID returns a random 64 characters hexadecimal ID, like the ones docker uses
+func generateID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// splitPath splits a path as "<id>/<action>" and returns both parts. action is empty if not present
+func splitPath(path string) (string, string) {
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// writeJSONLine sends v as a single line of a newline-delimited JSON stream and flushes it to the client
+func writeJSONLine(w http.ResponseWriter, v interface{}) {
+	json.NewEncoder(w).Encode(v)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+/* parseFilters decodes the filters query parameter. Docker accepts both {"key": ["value"]} and
+the newer {"key": {"value": true}} forms */
+func parseFilters(raw string) (map[string][]string, error) {
+	filters := make(map[string][]string)
+	if raw == "" {
+		return filters, nil
+	}
+
+	var legacy map[string][]string
+	if err := json.Unmarshal([]byte(raw), &legacy); err == nil {
+		return legacy, nil
+	}
+	var sets map[string]map[string]bool
+	if err := json.Unmarshal([]byte(raw), &sets); err != nil {
+		return nil, fmt.Errorf("invalid filter: %s", err)
+	}
+	for key, values := range sets {
+		for value := range values {
+			filters[key] = append(filters[key], value)
+		}
+	}
+	return filters, nil
+}
+
+// checkFilters returns an error if a filter key is not in the list of accepted ones
+func checkFilters(filters map[string][]string, accepted ...string) error {
+	for key := range filters {
+		if !contains(accepted, key) {
+			return fmt.Errorf("invalid filter '%s'", key)
+		}
+	}
+	return nil
+}
+
+// matchLabels tells if labels satisfy every label filter given, each one being either "key" or "key=value"
+func matchLabels(labels map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, "=", 2)
+		value, ok := labels[parts[0]]
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+/* parseFilterTime parses the value of a time filter (e.g. until), given either as RFC3339 timestamp, seconds since epoch
+or a duration relative to now (e.g. 10m means 10 minutes ago) */
+func parseFilterTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	t, err := parseUnixTimestamp(value)
+	if err != nil || t.IsZero() {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
+
+// queryBool tells if a boolean query parameter is set, docker accepting both 1 and true
+func queryBool(query url.Values, key string) bool {
+	return query.Get(key) == "1" || query.Get(key) == "true"
+}
+
+// contains tells if value is in list
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
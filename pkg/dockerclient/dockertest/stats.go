@@ -0,0 +1,119 @@
+package dockertest
+
+import (
+	"net/http"
+	"time"
+)
+
+// Resource usage reported by every running fake container
+const (
+	// FakeCPUPercentage is the CPU usage of a running container, on a host with FakeOnlineCPUs
+	FakeCPUPercentage = 10.0
+	// FakeOnlineCPUs is the amount of CPUs available to containers
+	FakeOnlineCPUs = 2
+	// FakeMemoryUsage is the memory used by a running container, page cache excluded
+	FakeMemoryUsage = 80 * 1024 * 1024
+	// FakeMemoryCache is the page cache of a running container (reported as inactive_file, like cgroup v2 does)
+	FakeMemoryCache = 20 * 1024 * 1024
+	// FakeMemoryLimit is the memory limit of every container
+	FakeMemoryLimit = 1024 * 1024 * 1024
+	// FakeNetworkRx and FakeNetworkTx are the bytes received and sent on eth0
+	FakeNetworkRx = 1024
+	FakeNetworkTx = 2048
+	// FakeBlockRead and FakeBlockWrite are the bytes read from and written to disk
+	FakeBlockRead  = 4096
+	FakeBlockWrite = 8192
+
+	// systemCPUDelta is the host CPU time elapsed between two samples, in nanoseconds
+	systemCPUDelta = uint64(time.Second)
+)
+
+// statsSample builds the stats sample number n of a container. Stopped containers report zeroed stats, like docker does
+func statsSample(c *container, n uint64) map[string]interface{} {
+	if !c.State.Running {
+		return map[string]interface{}{
+			"read":         formatTime(time.Time{}),
+			"preread":      formatTime(time.Time{}),
+			"cpu_stats":    map[string]interface{}{"cpu_usage": map[string]uint64{"total_usage": 0}},
+			"precpu_stats": map[string]interface{}{"cpu_usage": map[string]uint64{"total_usage": 0}},
+			"memory_stats": map[string]interface{}{},
+			"pids_stats":   map[string]interface{}{},
+			"blkio_stats":  map[string]interface{}{},
+		}
+	}
+
+	// The CPU time consumed by the container on every sample gives FakeCPUPercentage
+	containerCPUDelta := uint64(FakeCPUPercentage / 100 / FakeOnlineCPUs * float64(systemCPUDelta))
+	cpuStats := func(n uint64) map[string]interface{} {
+		return map[string]interface{}{
+			"cpu_usage":        map[string]uint64{"total_usage": n * containerCPUDelta},
+			"system_cpu_usage": n * systemCPUDelta,
+			"online_cpus":      FakeOnlineCPUs,
+		}
+	}
+
+	now := time.Now()
+	return map[string]interface{}{
+		"read":         formatTime(now),
+		"preread":      formatTime(now.Add(-time.Second)),
+		"cpu_stats":    cpuStats(n),
+		"precpu_stats": cpuStats(n - 1),
+		"memory_stats": map[string]interface{}{
+			"usage": FakeMemoryUsage + FakeMemoryCache,
+			"limit": FakeMemoryLimit,
+			"stats": map[string]uint64{"inactive_file": FakeMemoryCache},
+		},
+		"networks": map[string]interface{}{
+			"eth0": map[string]uint64{"rx_bytes": FakeNetworkRx, "tx_bytes": FakeNetworkTx},
+		},
+		"blkio_stats": map[string]interface{}{
+			"io_service_bytes_recursive": []map[string]interface{}{
+				{"major": 8, "minor": 0, "op": "read", "value": FakeBlockRead},
+				{"major": 8, "minor": 0, "op": "write", "value": FakeBlockWrite},
+			},
+		},
+		"pids_stats": map[string]uint64{"current": 1},
+	}
+}
+
+// containerStats handles GET /containers/{id}/stats
+func (s *Server) containerStats(w http.ResponseWriter, r *http.Request, id string) {
+	stream := r.URL.Query().Get("stream") != "false" && r.URL.Query().Get("stream") != "0"
+
+	s.mu.Lock()
+	c, ok := s.findContainer(id)
+	interval := s.StatsInterval
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := uint64(1); ; n++ {
+		s.mu.Lock()
+		sample := statsSample(c, n)
+		_, exists := s.containers[c.ID]
+		s.mu.Unlock()
+
+		if !exists {
+			return // The stream ends when the container is removed
+		}
+		writeJSONLine(w, sample)
+		if !stream {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package dockertest
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// importChanges are the Dockerfile instructions that can be applied to an imported image, as the daemon accepts
+var importChanges = []string{"CMD", "ENTRYPOINT", "ENV", "EXPOSE", "LABEL", "ONBUILD", "STOPSIGNAL", "USER", "VOLUME", "WORKDIR"}
+
+// saveManifestItem is an entry of the manifest.json file of the archives made by docker save
+type saveManifestItem struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// saveImageConfig is the image configuration file of the archives made by docker save
+type saveImageConfig struct {
+	Architecture string                 `json:"architecture"`
+	OS           string                 `json:"os"`
+	Created      time.Time              `json:"created"`
+	Comment      string                 `json:"comment,omitempty"`
+	Config       models.ContainerConfig `json:"config"`
+	RootFS       struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+/* saveImages handles GET /images/get, writing the images given in the names parameter as a docker save archive:
+a manifest.json file, a repositories file, and a configuration file and a layer.tar archive for every image */
+func (s *Server) saveImages(w http.ResponseWriter, r *http.Request) {
+	names := r.URL.Query()["names"]
+
+	s.mu.Lock()
+	var manifest []saveManifestItem
+	repositories := make(map[string]map[string]string)
+	var configs [][]byte
+	var images []*image
+	for _, name := range names {
+		img, ok := s.findImage(name)
+		if !ok {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "No such image: %s", name)
+			return
+		}
+
+		// Images given by ID are saved without references, as docker does
+		item := saveManifestItem{
+			Config: strings.TrimPrefix(img.ID, "sha256:") + ".json",
+			Layers: []string{strings.TrimPrefix(img.Layer, "sha256:") + "/layer.tar"},
+		}
+		if ref := normalizeReference(name); contains(img.RepoTags, ref) {
+			item.RepoTags = []string{ref}
+			i := strings.LastIndex(ref, ":")
+			if repositories[ref[:i]] == nil {
+				repositories[ref[:i]] = make(map[string]string)
+			}
+			repositories[ref[:i]][ref[i+1:]] = strings.TrimPrefix(img.ID, "sha256:")
+		}
+
+		config := saveImageConfig{Architecture: "amd64", OS: "linux", Created: img.Created, Comment: img.Comment, Config: img.Config}
+		config.RootFS.Type, config.RootFS.DiffIDs = "layers", []string{img.Layer}
+		encoded, _ := json.Marshal(config)
+		manifest, configs, images = append(manifest, item), append(configs, encoded), append(images, img)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	archive := tar.NewWriter(w)
+	for i, img := range images {
+		layerDir := strings.TrimPrefix(img.Layer, "sha256:")
+		writeTarDir(archive, layerDir)
+		writeTarFile(archive, layerDir+"/layer.tar", fakeLayer())
+		writeTarFile(archive, manifest[i].Config, configs[i])
+	}
+	encodedManifest, _ := json.Marshal(manifest)
+	writeTarFile(archive, "manifest.json", encodedManifest)
+	encodedRepositories, _ := json.Marshal(repositories)
+	writeTarFile(archive, "repositories", encodedRepositories)
+	archive.Close()
+}
+
+/* loadImage handles POST /images/load, loading the images of a docker save archive. References loaded are moved
+out of the images holding them, if any. Archives without manifest.json are rejected before the stream starts */
+func (s *Server) loadImage(w http.ResponseWriter, r *http.Request) {
+	files, err := readTar(r.Body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error processing tar file(%s)", err)
+		return
+	}
+	var manifest []saveManifestItem
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		writeError(w, http.StatusInternalServerError, "open /var/lib/docker/tmp/docker-import/manifest.json: no such file or directory")
+		return
+	}
+
+	var loaded []string
+	s.mu.Lock()
+	for _, item := range manifest {
+		var config saveImageConfig
+		if err := json.Unmarshal(files[item.Config], &config); err != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusInternalServerError, "invalid image configuration %s: %s", item.Config, err)
+			return
+		}
+
+		id := "sha256:" + strings.TrimSuffix(path.Base(item.Config), ".json")
+		img, ok := s.images[id]
+		if !ok {
+			img = &image{ID: id, Created: config.Created, Comment: config.Comment, Config: config.Config, Layer: "sha256:" + generateID()}
+			if len(config.RootFS.DiffIDs) > 0 {
+				img.Layer = config.RootFS.DiffIDs[0]
+			}
+			s.images[id] = img
+		}
+		for _, ref := range item.RepoTags {
+			if !contains(img.RepoTags, ref) {
+				s.untag(ref)
+				img.RepoTags = append(img.RepoTags, ref)
+			}
+			loaded = append(loaded, "Loaded image: "+ref)
+		}
+		if len(item.RepoTags) == 0 {
+			loaded = append(loaded, "Loaded image ID: "+id)
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range loaded {
+		writeJSONLine(w, map[string]string{"stream": line + "\n"})
+	}
+}
+
+/* importImage handles POST /images/create?fromSrc=-, creating an image out of the filesystem archive in the body.
+The changes given are applied to the configuration of the new image */
+func (s *Server) importImage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("fromSrc") != "-" {
+		writeError(w, http.StatusBadRequest, "only imports from the request body (fromSrc=-) are supported")
+		return
+	}
+
+	var ref string
+	if repo := query.Get("repo"); repo != "" {
+		ref = reference(repo, query.Get("tag"))
+		repository := repo
+		if i := strings.Index(repo, "/"); i >= 0 && strings.ContainsAny(repo[:i], ".:") {
+			repository = repo[i+1:]
+		}
+		if !repositoryName.MatchString(repository) || !tagName.MatchString(ref[strings.LastIndex(ref, ":")+1:]) {
+			writeError(w, http.StatusBadRequest, "invalid reference format")
+			return
+		}
+	}
+
+	state := buildState{Args: make(map[string]string)}
+	for _, change := range query["changes"] {
+		inst := parseDockerfile(change)
+		if len(inst) != 1 {
+			writeError(w, http.StatusBadRequest, "invalid change %q: expected a single Dockerfile instruction", change)
+			return
+		}
+		if !contains(importChanges, inst[0].Command) {
+			writeError(w, http.StatusBadRequest, "%s is not a valid change command", inst[0].Command)
+			return
+		}
+		if err := s.runInstruction(nil, &state, inst[0], nil, nil, nil); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+	}
+
+	if _, err := readTar(r.Body); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error processing tar file(%s)", err)
+		return
+	}
+
+	s.mu.Lock()
+	img := &image{
+		ID:      "sha256:" + generateID(),
+		Created: time.Now(),
+		Layer:   "sha256:" + generateID(),
+		Comment: query.Get("message"),
+		Config:  state.Config,
+	}
+	if ref != "" {
+		s.untag(ref)
+		img.RepoTags = []string{ref}
+	}
+	s.images[img.ID] = img
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	writeJSONLine(w, map[string]string{"status": img.ID})
+}
+
+// exportContainer handles GET /containers/{id}/export, writing the fake filesystem of the container as a tar archive
+func (s *Server) exportContainer(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.findContainer(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: %s", id)
+		return
+	}
+	var paths []string
+	for p := range c.files {
+		if p != "/" {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	archive := tar.NewWriter(w)
+	for _, p := range paths {
+		writeFakeFile(archive, strings.TrimPrefix(p, "/"), c.files[p])
+	}
+	archive.Close()
+}
+
+// fakeOSRelease is the content of /etc/os-release in every fake image
+const fakeOSRelease = "NAME=\"Ubuntu\"\nVERSION_ID=\"20.04\"\n"
+
+// fakeLayer returns the archive of the single layer every fake image is made of
+func fakeLayer() []byte {
+	var layer bytes.Buffer
+	archive := tar.NewWriter(&layer)
+	writeTarDir(archive, "etc")
+	writeTarFile(archive, "etc/os-release", []byte(fakeOSRelease))
+	archive.Close()
+	return layer.Bytes()
+}
+
+// writeTarDir writes a directory entry to a tar archive
+func writeTarDir(archive *tar.Writer, name string) {
+	archive.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: 0755, ModTime: time.Now()})
+}
+
+// writeTarFile writes a regular file to a tar archive
+func writeTarFile(archive *tar.Writer, name string, content []byte) {
+	archive.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(content)), ModTime: time.Now()})
+	archive.Write(content)
+}
@@ -0,0 +1,79 @@
+package dockerclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/httpclient"
+)
+
+/* DockerAPIError is returned whenever the docker daemon answers with an unexpected status code.
+It wraps one of the package errors, so it can be checked using errors.Is (e.g. errors.Is(err, ErrContainerDoesNotExist)) */
+type DockerAPIError struct {
+	// StatusCode is the HTTP status code returned by the daemon
+	StatusCode int
+	// Method is the HTTP method of the failed query
+	Method string
+	// Endpoint is the URL of the failed query
+	Endpoint string
+	// Message is the error message sent by the daemon, if any
+	Message string
+	// Err is the package error this error maps to
+	Err error
+}
+
+// Error returns a human readable description of the error, including the message sent by the daemon
+func (d *DockerAPIError) Error() string {
+	if d.Message == "" {
+		return fmt.Sprintf("%s (%s %s returned %d)", d.Err, d.Method, d.Endpoint, d.StatusCode)
+	}
+	return fmt.Sprintf("%s (%s %s returned %d): %s", d.Err, d.Method, d.Endpoint, d.StatusCode, d.Message)
+}
+
+// Unwrap returns the package error this error maps to
+func (d *DockerAPIError) Unwrap() error {
+	return d.Err
+}
+
+/* newDockerAPIError builds a DockerAPIError from the daemon response given.
+The daemon sends errors as {"message": "..."}, but raw bodies are kept as message too */
+func newDockerAPIError(method string, endpoint string, httpResponse *httpclient.HttpResponse, err error) *DockerAPIError {
+	return &DockerAPIError{
+		StatusCode: httpResponse.StatusCode,
+		Method:     method,
+		Endpoint:   endpoint,
+		Message:    daemonErrorMessage(httpResponse.Body),
+		Err:        err,
+	}
+}
+
+// maxErrorBodySize caps how much of a streaming response body is read to build an error
+const maxErrorBodySize = 64 * 1024
+
+/* newDockerAPIStreamError builds a DockerAPIError from the streaming daemon response given.
+The response body is read to get the daemon message, and closed */
+func newDockerAPIStreamError(method string, endpoint string, httpResponse *httpclient.HttpStreamResponse, err error) *DockerAPIError {
+	defer httpResponse.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(httpResponse.Body, maxErrorBodySize)) // A partial body still gives a useful message
+
+	return &DockerAPIError{
+		StatusCode: httpResponse.StatusCode,
+		Method:     method,
+		Endpoint:   endpoint,
+		Message:    daemonErrorMessage(body),
+		Err:        err,
+	}
+}
+
+// daemonErrorMessage extracts the error message from a daemon response body
+func daemonErrorMessage(body []byte) string {
+	var errorBody struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err == nil && errorBody.Message != "" {
+		return errorBody.Message
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,53 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeletux/go-docker-manager/pkg/httpclient"
+)
+
+func TestDockerAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/running/json":
+			w.WriteHeader(500)
+			w.Write([]byte("plain text error\n"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(409)
+			w.Write([]byte(`{"message":"You cannot remove a running container running. Stop the container before attempting removal or force remove"}`))
+		}
+	}))
+	defer server.Close()
+
+	dockerClient := NewSimpeDocker(server.URL, httpclient.NewSimpleHttpClient())
+
+	err := dockerClient.RemoveContainerContext(context.Background(), "running")
+	if !errors.Is(err, ErrContainerIsRunning) {
+		t.Fatalf("SimpleDocker.RemoveContainerContext() error = %v, want errors.Is %v", err, ErrContainerIsRunning)
+	}
+
+	var apiError *DockerAPIError
+	if !errors.As(err, &apiError) {
+		t.Fatalf("SimpleDocker.RemoveContainerContext() error = %T, want *DockerAPIError", err)
+	}
+	if apiError.StatusCode != 409 || apiError.Method != "DELETE" || apiError.Endpoint != server.URL+"/containers/running" {
+		t.Errorf("DockerAPIError = %+v, want 409 DELETE %s/containers/running", apiError, server.URL)
+	}
+	if !strings.HasPrefix(apiError.Message, "You cannot remove a running container") {
+		t.Errorf("DockerAPIError.Message = %q, want the daemon message", apiError.Message)
+	}
+
+	_, err = dockerClient.CheckIfContainerIsReadyContext(context.Background(), "running")
+	if !errors.Is(err, ErrDockerInternalServerError) || !errors.As(err, &apiError) {
+		t.Fatalf("SimpleDocker.CheckIfContainerIsReadyContext() error = %v, want a DockerAPIError wrapping %v", err, ErrDockerInternalServerError)
+	}
+	if apiError.Message != "plain text error" {
+		t.Errorf("DockerAPIError.Message = %q, want %q", apiError.Message, "plain text error")
+	}
+}
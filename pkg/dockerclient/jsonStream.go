@@ -0,0 +1,40 @@
+package dockerclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// ProgressFunc is called for every message of a JSON progress stream (pull, push, build) as it is received
+type ProgressFunc func(message models.JSONMessage)
+
+/* readJSONMessages decodes a newline-delimited JSON stream of messages, calling progress for each of them (progress may be nil).
+It returns the error message reported by the daemon if any, or an error if the stream cannot be decoded */
+func readJSONMessages(stream io.Reader, progress ProgressFunc) (string, error) {
+	decoder := json.NewDecoder(stream)
+	for {
+		var message models.JSONMessage
+		err := decoder.Decode(&message)
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot decode JSON stream from docker daemon - %w", err)
+		}
+
+		if progress != nil {
+			progress(message)
+		}
+
+		if message.Error != "" {
+			return message.Error, nil
+		}
+		if message.ErrorDetail != nil && message.ErrorDetail.Message != "" {
+			return message.ErrorDetail.Message, nil
+		}
+	}
+}
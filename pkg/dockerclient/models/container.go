@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+// ContainerConfig is the configuration of a container that does not depend on the host it runs on
+type ContainerConfig struct {
+	// Hostname is the hostname of the container
+	Hostname string `json:",omitempty"`
+	// Domainname is the domain name of the container
+	Domainname string `json:",omitempty"`
+	// User is the user (and optionally group) that runs the container command
+	User string `json:",omitempty"`
+	// AttachStdin attaches to stdin
+	AttachStdin bool
+	// AttachStdout attaches to stdout
+	AttachStdout bool
+	// AttachStderr attaches to stderr
+	AttachStderr bool
+	// ExposedPorts are the ports exposed by the container, as "port/protocol" (e.g. "80/tcp")
+	ExposedPorts map[string]struct{} `json:",omitempty"`
+	// Tty allocates a pseudo-TTY
+	Tty bool
+	// OpenStdin keeps stdin open even if not attached
+	OpenStdin bool
+	// StdinOnce closes stdin after the first attached client disconnects
+	StdinOnce bool
+	// Env is a list of environment variables in the form KEY=value
+	Env []string `json:",omitempty"`
+	// Cmd is the command to run when starting the container
+	Cmd []string
+	// Healthcheck is the test run to check if the container is healthy
+	Healthcheck *HealthConfig `json:",omitempty"`
+	// Image is the image the container is created from
+	Image string
+	// Volumes are the paths inside the container used as anonymous volumes
+	Volumes map[string]struct{} `json:",omitempty"`
+	// WorkingDir is the directory the command runs in
+	WorkingDir string `json:",omitempty"`
+	// Entrypoint is the entrypoint of the container, overriding the image one
+	Entrypoint []string `json:",omitempty"`
+	// Labels are the labels set on the container
+	Labels map[string]string `json:",omitempty"`
+	// StopSignal is the signal sent to stop the container
+	StopSignal string `json:",omitempty"`
+	// StopTimeout is the time in seconds to wait for the container to stop before killing it
+	StopTimeout *int `json:",omitempty"`
+}
+
+// HealthConfig is the test run inside a container to check its health
+type HealthConfig struct {
+	/* Test is the test to run: [] inherits the image healthcheck, ["NONE"] disables it, ["CMD", args...] runs a
+	command directly and ["CMD-SHELL", command] runs it with the default shell */
+	Test []string `json:",omitempty"`
+	// Interval is the time to wait between two checks
+	Interval time.Duration `json:",omitempty"`
+	// Timeout is the time to wait before considering a check as hung
+	Timeout time.Duration `json:",omitempty"`
+	// StartPeriod is the time given to the container to start before failing checks count
+	StartPeriod time.Duration `json:",omitempty"`
+	// Retries is the amount of consecutive failures needed to consider the container unhealthy
+	Retries int `json:",omitempty"`
+}
+
+// HostConfig is the configuration of a container that depends on the host it runs on
+type HostConfig struct {
+	// Binds are the volume bindings, as "source:destination[:options]"
+	Binds []string `json:",omitempty"`
+	// NetworkMode is the network the container is attached to (bridge, host, none, container:<id> or a network name)
+	NetworkMode string `json:",omitempty"`
+	// PortBindings maps container ports ("port/protocol") to the host ports they are published on
+	PortBindings map[string][]PortBinding `json:",omitempty"`
+	// RestartPolicy is the behaviour to apply when the container exits
+	RestartPolicy RestartPolicy
+	// AutoRemove removes the container once it exits
+	AutoRemove bool
+	// Privileged gives extended privileges to the container
+	Privileged bool
+	// Memory is the memory limit in bytes. 0 means no limit
+	Memory int64 `json:",omitempty"`
+	// NanoCpus is the CPU quota in units of 10^-9 CPUs. 0 means no limit
+	NanoCpus int64 `json:",omitempty"`
+	// CPUShares is the relative CPU weight of the container against other containers (1024 by default)
+	CPUShares int64 `json:"CpuShares,omitempty"`
+	// CPUPeriod is the length in microseconds of a CPU CFS period
+	CPUPeriod int64 `json:"CpuPeriod,omitempty"`
+	// CPUQuota is the CPU time in microseconds the container can use per CPUPeriod
+	CPUQuota int64 `json:"CpuQuota,omitempty"`
+	// PidsLimit is the maximum amount of processes in the container. 0 means no limit
+	PidsLimit int64 `json:",omitempty"`
+	// Mounts are the mounts added to the container
+	Mounts []Mount `json:",omitempty"`
+	// ExtraHosts are the entries added to /etc/hosts, as "hostname:IP"
+	ExtraHosts []string `json:",omitempty"`
+	// CapAdd are the kernel capabilities added to the container
+	CapAdd []string `json:",omitempty"`
+	// CapDrop are the kernel capabilities dropped from the container
+	CapDrop []string `json:",omitempty"`
+}
+
+// PortBinding is a host port a container port is published on
+type PortBinding struct {
+	// HostIP is the host IP to bind to. Empty means every interface
+	HostIP string `json:"HostIp"`
+	// HostPort is the host port to bind to. Empty means a random port
+	HostPort string
+}
+
+// RestartPolicy is the behaviour to apply when a container exits
+type RestartPolicy struct {
+	// Name is the policy: "" or "no", "always", "unless-stopped" or "on-failure"
+	Name string
+	// MaximumRetryCount is the amount of restarts to attempt when Name is "on-failure"
+	MaximumRetryCount int
+}
+
+// Mount is a mount added to a container
+type Mount struct {
+	// Type is the type of mount: bind, volume or tmpfs
+	Type string
+	// Source is the host path (bind) or the volume name (volume)
+	Source string `json:",omitempty"`
+	// Target is the path inside the container
+	Target string
+	// ReadOnly mounts the source read only
+	ReadOnly bool `json:",omitempty"`
+}
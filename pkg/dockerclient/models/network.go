@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// NetworkResource is a network as listed or inspected from the docker daemon
+type NetworkResource struct {
+	// Name of the network
+	Name string
+	// ID of the network
+	ID string `json:"Id"`
+	// Created is the time the network was created
+	Created time.Time
+	// Scope is the scope of the network: local, global or swarm
+	Scope string
+	// Driver is the network driver (e.g. bridge, host, null, overlay)
+	Driver string
+	// EnableIPv6 tells whether IPv6 is enabled on the network
+	EnableIPv6 bool
+	// IPAM is the IP address management configuration of the network
+	IPAM IPAM
+	// Internal networks have no access to the outside world
+	Internal bool
+	// Attachable networks accept standalone containers (only meaningful for swarm networks)
+	Attachable bool
+	// Containers are the containers attached to the network, by container ID. Only filled when inspecting the network
+	Containers map[string]NetworkContainer
+	// Options are the driver options of the network
+	Options map[string]string
+	// Labels are the labels set on the network
+	Labels map[string]string
+}
+
+// IPAM is the IP address management configuration of a network
+type IPAM struct {
+	// Driver is the IPAM driver. Defaults to default
+	Driver string `json:",omitempty"`
+	// Config are the address pools of the network
+	Config []IPAMConfig
+	// Options are the IPAM driver options
+	Options map[string]string `json:",omitempty"`
+}
+
+// IPAMConfig is an address pool of a network
+type IPAMConfig struct {
+	// Subnet is the subnet of the pool in CIDR notation (e.g. 172.28.0.0/16)
+	Subnet string `json:",omitempty"`
+	// IPRange is the part of the subnet containers are given addresses from, in CIDR notation. Defaults to the whole subnet
+	IPRange string `json:",omitempty"`
+	// Gateway is the gateway of the subnet. Defaults to its first address
+	Gateway string `json:",omitempty"`
+}
+
+// NetworkContainer is a container attached to a network, as found when inspecting the network
+type NetworkContainer struct {
+	// Name of the container
+	Name string
+	// EndpointID is the ID of the endpoint of the container in the network
+	EndpointID string
+	// MacAddress is the MAC address of the container in the network
+	MacAddress string
+	// IPv4Address is the IPv4 of the container in CIDR notation (e.g. 172.28.0.2/16)
+	IPv4Address string
+	// IPv6Address is the IPv6 of the container in CIDR notation, if IPv6 is enabled
+	IPv6Address string
+}
+
+/* NetworkingConfig are the networks a new container is attached to, alongside its settings in each of them.
+The daemon accepts a single network, the one in HostConfig.NetworkMode */
+type NetworkingConfig struct {
+	// EndpointsConfig are the settings of the container in every network, by network name
+	EndpointsConfig map[string]*EndpointSettings `json:",omitempty"`
+}
+
+// EndpointIPAMConfig holds the static addresses of a container in a network
+type EndpointIPAMConfig struct {
+	// IPv4Address is the static IPv4 of the container. It must be in a subnet configured when creating the network
+	IPv4Address string `json:",omitempty"`
+	// IPv6Address is the static IPv6 of the container
+	IPv6Address string `json:",omitempty"`
+}
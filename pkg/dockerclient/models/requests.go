@@ -1,11 +1,13 @@
 package models
 
-// CreateContainerBody is the struct that models request body when creating a container
+/* CreateContainerBody is the struct that models request body when creating a container.
+The container configuration (Cmd, Image, Env...) is sent at the top level of the body */
 type CreateContainerBody struct {
-	// Cmd is the list of commands to execute when creating the container
-	Cmd []string
-	// Image the the image plus tag to use
-	Image string
+	ContainerConfig
+	// HostConfig is the configuration of the container that depends on the host
+	HostConfig HostConfig
+	// NetworkingConfig holds the settings of the container in the network it is attached to
+	NetworkingConfig NetworkingConfig
 }
 
 type GenerateExecInstanceBody struct {
@@ -19,6 +21,12 @@ type GenerateExecInstanceBody struct {
 	Tty bool
 	// Cmd are the commands to run inside the container
 	Cmd []string
+	// Env is a list of environment variables in the form KEY=value
+	Env []string `json:",omitempty"`
+	// WorkingDir is the directory to run the command in
+	WorkingDir string `json:",omitempty"`
+	// User is the user that runs the command
+	User string `json:",omitempty"`
 }
 
 type StartExecInstance struct {
@@ -27,3 +35,54 @@ type StartExecInstance struct {
 	// Tty Allocate a pseudo-TTY
 	Tty bool
 }
+
+/* AuthConfig holds the credentials of a registry, sent base64url-encoded in the X-Registry-Auth header when pulling
+or pushing images. Either Username and Password or IdentityToken are set */
+type AuthConfig struct {
+	// Username to log in with
+	Username string `json:"username,omitempty"`
+	// Password of the user
+	Password string `json:"password,omitempty"`
+	// ServerAddress is the address of the registry (e.g. registry.example.com or https://index.docker.io/v1/)
+	ServerAddress string `json:"serveraddress,omitempty"`
+	// IdentityToken is a refresh token the registry issued on a previous login, used instead of username and password
+	IdentityToken string `json:"identitytoken,omitempty"`
+	// RegistryToken is a bearer token sent straight to the registry
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// CreateNetworkBody is the struct that models request body when creating a network
+type CreateNetworkBody struct {
+	// Name of the network
+	Name string
+	// CheckDuplicate refuses to create a network with the name of an existing one
+	CheckDuplicate bool
+	// Driver is the network driver. Defaults to bridge
+	Driver string `json:",omitempty"`
+	// Internal networks have no access to the outside world
+	Internal bool
+	// Attachable networks accept standalone containers (only meaningful for swarm networks)
+	Attachable bool
+	// IPAM is the IP address management configuration of the network
+	IPAM *IPAM `json:",omitempty"`
+	// Options are the driver options of the network
+	Options map[string]string `json:",omitempty"`
+	// Labels are the labels to set on the network
+	Labels map[string]string `json:",omitempty"`
+}
+
+// ConnectNetworkBody is the struct that models request body when connecting a container to a network
+type ConnectNetworkBody struct {
+	// Container is the ID or name of the container to connect
+	Container string
+	// EndpointConfig holds the settings of the container in the network
+	EndpointConfig *EndpointSettings `json:",omitempty"`
+}
+
+// DisconnectNetworkBody is the struct that models request body when disconnecting a container from a network
+type DisconnectNetworkBody struct {
+	// Container is the ID or name of the container to disconnect
+	Container string
+	// Force disconnects the container even if the network is no longer available
+	Force bool
+}
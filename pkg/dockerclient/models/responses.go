@@ -1,5 +1,11 @@
 package models
 
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
 /* CreateContainerResponseBody wraps the response body coming from the docker daemon when
    creating a container */
 type CreateContainerResponseBody struct {
@@ -9,6 +15,9 @@ type CreateContainerResponseBody struct {
 	Warnings []string
 }
 
+/* CheckContainerStatusBody is the part of the container inspection CheckIfContainerIsReady used to rely on.
+
+Deprecated: use InspectContainerResponseBody instead */
 type CheckContainerStatusBody struct {
 	// State is an object that gives us different info about the container
 	State struct {
@@ -19,7 +28,456 @@ type CheckContainerStatusBody struct {
 	}
 }
 
+// ContainerSummary is a container as listed by the docker daemon
+type ContainerSummary struct {
+	// ID of the container
+	ID string
+	// Names of the container, starting with a slash
+	Names []string
+	// Image is the image reference the container was created from
+	Image string
+	// ImageID is the ID of the image the container was created from
+	ImageID string
+	// Command is the command the container runs
+	Command string
+	// Created is the time the container was created, in seconds since epoch
+	Created int64
+	// Ports are the ports exposed by the container
+	Ports []Port
+	// SizeRw is the size of the files created or changed by the container. Only set if the size was requested
+	SizeRw int64
+	// SizeRootFs is the total size of the container files, image included. Only set if the size was requested
+	SizeRootFs int64
+	// Labels are the labels set on the container
+	Labels map[string]string
+	// State is the state of the container (created, running, paused, restarting, removing, exited or dead)
+	State string
+	// Status is a human readable status (e.g. "Up 2 minutes", "Exited (0) 5 seconds ago")
+	Status string
+}
+
+// Port is a port exposed by a container
+type Port struct {
+	// IP is the host IP the port is published on
+	IP string `json:",omitempty"`
+	// PrivatePort is the port inside the container
+	PrivatePort uint16
+	// PublicPort is the port on the host. It is 0 if the port is not published
+	PublicPort uint16 `json:",omitempty"`
+	// Type is the protocol (tcp, udp or sctp)
+	Type string
+}
+
+// InspectContainerResponseBody wraps the response body coming from the docker daemon when inspecting a container
+type InspectContainerResponseBody struct {
+	// ID of the container
+	ID string
+	// Created is the time the container was created
+	Created time.Time
+	// Path is the executable the container runs
+	Path string
+	// Args are the arguments passed to Path
+	Args []string
+	// State is the current state of the container
+	State ContainerState
+	// Image is the ID of the image the container was created from
+	Image string
+	// Name of the container, starting with a slash
+	Name string
+	// RestartCount is the amount of times the container was restarted by its restart policy
+	RestartCount int
+	// Driver is the storage driver used by the container
+	Driver string
+	// Platform is the platform the container runs on (e.g. linux)
+	Platform string
+	// Config is the configuration of the container
+	Config ContainerConfig
+	// HostConfig is the configuration of the container that depends on the host
+	HostConfig HostConfig
+	// NetworkSettings are the network settings of the container
+	NetworkSettings NetworkSettings
+	// Mounts are the volumes and bind mounts of the container
+	Mounts []MountPoint
+}
+
+// ContainerState is the state of a container
+type ContainerState struct {
+	// Status gives us the current container status (created, running, paused, restarting, removing, exited or dead)
+	Status string
+	// Running tells if the container is running or not
+	Running bool
+	// Paused tells if the container is paused
+	Paused bool
+	// Restarting tells if the container is being restarted
+	Restarting bool
+	// OOMKilled tells if the container was killed because it ran out of memory
+	OOMKilled bool
+	// Dead tells if the container is dead
+	Dead bool
+	// Pid is the process ID of the container command on the docker host. 0 if not running
+	Pid int
+	// ExitCode is the exit code of the last run of the container
+	ExitCode int
+	// Error is the error that prevented the container from starting, if any
+	Error string
+	// StartedAt is the time the container was last started
+	StartedAt time.Time
+	// FinishedAt is the time the container last exited
+	FinishedAt time.Time
+	// Health is the health of the container. It is nil if the container has no healthcheck
+	Health *Health `json:",omitempty"`
+}
+
+// Health is the health of a container, as reported by its healthcheck
+type Health struct {
+	// Status is either starting, healthy or unhealthy
+	Status string
+	// FailingStreak is the amount of consecutive failed checks
+	FailingStreak int
+	// Log are the results of the last checks
+	Log []HealthcheckResult
+}
+
+// HealthcheckResult is the result of a single healthcheck run
+type HealthcheckResult struct {
+	// Start is the time the check started
+	Start time.Time
+	// End is the time the check finished
+	End time.Time
+	// ExitCode is the exit code of the check: 0 is healthy, 1 is unhealthy
+	ExitCode int
+	// Output is the output of the check
+	Output string
+}
+
+// NetworkSettings are the network settings of a container
+type NetworkSettings struct {
+	// SandboxID is the ID of the network namespace of the container
+	SandboxID string
+	// Ports maps container ports ("port/protocol") to the host ports they are published on
+	Ports map[string][]PortBinding
+	// IPAddress is the IP of the container on the default bridge network
+	IPAddress string
+	// Gateway is the gateway of the default bridge network
+	Gateway string
+	// MacAddress is the MAC address of the container on the default bridge network
+	MacAddress string
+	// Networks are the networks the container is attached to, by network name
+	Networks map[string]EndpointSettings
+}
+
+// EndpointSettings is the attachment of a container to a network
+type EndpointSettings struct {
+	// IPAMConfig holds the static addresses requested for the container, if any
+	IPAMConfig *EndpointIPAMConfig `json:",omitempty"`
+	// NetworkID is the ID of the network
+	NetworkID string
+	// EndpointID is the ID of the endpoint in the network
+	EndpointID string
+	// Gateway is the gateway of the network
+	Gateway string
+	// IPAddress is the IP of the container in the network
+	IPAddress string
+	// IPPrefixLen is the mask length of IPAddress
+	IPPrefixLen int
+	// MacAddress is the MAC address of the container in the network
+	MacAddress string
+	// Aliases are the names the container is reachable by in the network
+	Aliases []string
+}
+
+// MountPoint is a volume or bind mount of a container
+type MountPoint struct {
+	// Type is the type of mount: bind, volume or tmpfs
+	Type string
+	// Name is the name of the volume. Empty for bind mounts
+	Name string `json:",omitempty"`
+	// Source is the path of the mount on the host
+	Source string
+	// Destination is the path of the mount inside the container
+	Destination string
+	// Driver is the volume driver. Empty for bind mounts
+	Driver string `json:",omitempty"`
+	// Mode are the options given when mounting (e.g. "z", "ro")
+	Mode string
+	// RW tells if the mount is writable
+	RW bool
+	// Propagation is the mount propagation (e.g. rprivate)
+	Propagation string
+}
+
+// WaitContainerResponseBody wraps the response body coming from the docker daemon once a wait condition is met
+type WaitContainerResponseBody struct {
+	// StatusCode is the exit code of the container
+	StatusCode int
+	// Error is set when the daemon could not wait for the container
+	Error *struct {
+		// Message is the error message
+		Message string
+	} `json:",omitempty"`
+}
+
+// PruneContainersResponseBody wraps the response body coming from the docker daemon when pruning containers
+type PruneContainersResponseBody struct {
+	// ContainersDeleted are the IDs of the containers removed
+	ContainersDeleted []string
+	// SpaceReclaimed is the disk space freed, in bytes
+	SpaceReclaimed uint64
+}
+
 type CreateExecResponseBody struct {
 	// ID of the created exec instance
 	ID string
 }
+
+/* JSONMessage is a single line of the newline-delimited JSON streams the docker daemon sends
+when pulling, pushing or building images */
+type JSONMessage struct {
+	// Status is a human readable status (e.g. "Downloading", "Pull complete")
+	Status string `json:"status,omitempty"`
+	// Stream is a chunk of the build output (e.g. "Step 1/3 : FROM ubuntu:20.04\n")
+	Stream string `json:"stream,omitempty"`
+	// ID is the layer the message refers to, if any
+	ID string `json:"id,omitempty"`
+	// Progress is a progress bar rendered by the daemon
+	Progress string `json:"progress,omitempty"`
+	// ProgressDetail holds the raw numbers behind Progress
+	ProgressDetail struct {
+		// Current is the amount of bytes processed so far
+		Current int64 `json:"current,omitempty"`
+		// Total is the total amount of bytes to process
+		Total int64 `json:"total,omitempty"`
+	} `json:"progressDetail,omitempty"`
+	// Error is set when the daemon reports an error in the middle of the stream
+	Error string `json:"error,omitempty"`
+	// ErrorDetail gives more information about Error
+	ErrorDetail *struct {
+		// Code is an optional error code
+		Code int `json:"code,omitempty"`
+		// Message is the error message
+		Message string `json:"message,omitempty"`
+	} `json:"errorDetail,omitempty"`
+	// Aux holds auxiliary data, such as the ID of the image built ({"ID": "sha256:..."})
+	Aux json.RawMessage `json:"aux,omitempty"`
+}
+
+// InspectExecResponseBody wraps the response body coming from the docker daemon when inspecting an exec instance
+type InspectExecResponseBody struct {
+	// ID of the exec instance
+	ID string
+	// ContainerID is the ID of the container the exec instance runs in
+	ContainerID string
+	// Running tells if the command is still running
+	Running bool
+	// ExitCode is the exit code of the command. Only meaningful once the command is not running
+	ExitCode int
+	// Pid is the process ID of the command on the docker host
+	Pid int
+	// ProcessConfig describes the command executed
+	ProcessConfig struct {
+		// Entrypoint is the executable run
+		Entrypoint string `json:"entrypoint"`
+		// Arguments are the arguments passed to the entrypoint
+		Arguments []string `json:"arguments"`
+		// Tty tells if a pseudo-TTY was allocated
+		Tty bool `json:"tty"`
+		// User is the user that runs the command
+		User string `json:"user"`
+	}
+}
+
+// StatsResponseBody wraps every sample sent by the docker daemon when querying the stats of a container
+type StatsResponseBody struct {
+	// Read is the time the sample was taken
+	Read time.Time `json:"read"`
+	// PreRead is the time the previous sample was taken
+	PreRead time.Time `json:"preread"`
+	// CPUStats holds the CPU usage at the time of the sample
+	CPUStats CPUStats `json:"cpu_stats"`
+	// PreCPUStats holds the CPU usage at the time of the previous sample
+	PreCPUStats CPUStats `json:"precpu_stats"`
+	// MemoryStats holds the memory usage
+	MemoryStats struct {
+		// Usage is the current memory usage, including the page cache
+		Usage uint64 `json:"usage"`
+		// MaxUsage is the maximum memory usage recorded (cgroup v1 only)
+		MaxUsage uint64 `json:"max_usage"`
+		// Limit is the memory limit of the container
+		Limit uint64 `json:"limit"`
+		// Stats holds detailed cgroup memory counters (cache, inactive_file...)
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	// Networks holds the network usage of every interface of the container
+	Networks map[string]struct {
+		// RxBytes is the amount of bytes received
+		RxBytes uint64 `json:"rx_bytes"`
+		// TxBytes is the amount of bytes sent
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	// BlkioStats holds the block I/O usage
+	BlkioStats struct {
+		// IoServiceBytesRecursive lists the bytes transferred by device and operation
+		IoServiceBytesRecursive []struct {
+			// Major is the major number of the device
+			Major uint64 `json:"major"`
+			// Minor is the minor number of the device
+			Minor uint64 `json:"minor"`
+			// Op is the operation (Read, Write...). Its case depends on the cgroup version
+			Op string `json:"op"`
+			// Value is the amount of bytes
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	// PidsStats holds the amount of processes
+	PidsStats struct {
+		// Current is the amount of processes running in the container
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// CPUStats holds the CPU usage of a container at a given time
+type CPUStats struct {
+	// CPUUsage holds the CPU time consumed by the container
+	CPUUsage struct {
+		// TotalUsage is the total CPU time consumed, in nanoseconds
+		TotalUsage uint64 `json:"total_usage"`
+		// PercpuUsage is the CPU time consumed per core, in nanoseconds (cgroup v1 only)
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	// SystemUsage is the CPU time consumed by the whole host, in nanoseconds
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	// OnlineCPUs is the amount of CPUs available to the container
+	OnlineCPUs uint32 `json:"online_cpus"`
+}
+
+// ImageSummary is an image as listed by the docker daemon
+type ImageSummary struct {
+	// ID of the image
+	ID string
+	// ParentID is the ID of the parent image, if any
+	ParentID string
+	// RepoTags are the references (name:tag) of the image. Dangling images are listed as <none>:<none>
+	RepoTags []string
+	// RepoDigests are the references by content (name@digest) of the image
+	RepoDigests []string
+	// Created is the time the image was created, in seconds since epoch
+	Created int64
+	// Size is the total size of the image, layers shared with other images included
+	Size int64
+	// SharedSize is the size of the layers shared with other images. It is -1 unless computed
+	SharedSize int64
+	// VirtualSize is the total size of the image. Kept for backwards compatibility, it matches Size
+	VirtualSize int64
+	// Labels are the labels set on the image
+	Labels map[string]string
+	// Containers is the number of containers using the image. It is -1 unless computed
+	Containers int64
+}
+
+// InspectImageResponseBody wraps the response body coming from the docker daemon when inspecting an image
+type InspectImageResponseBody struct {
+	// ID of the image
+	ID string
+	// RepoTags are the references (name:tag) of the image
+	RepoTags []string
+	// RepoDigests are the references by content (name@digest) of the image
+	RepoDigests []string
+	// Parent is the ID of the parent image, if any
+	Parent string
+	// Comment is the commit message the image was created with
+	Comment string
+	// Created is the time the image was created
+	Created time.Time
+	// DockerVersion is the version of docker that built the image
+	DockerVersion string
+	// Author of the image
+	Author string
+	// Config is the default configuration of the containers created from the image
+	Config ContainerConfig
+	// Architecture is the CPU architecture the image runs on (e.g. amd64)
+	Architecture string
+	// Variant is the CPU architecture variant (e.g. v8 for arm64), if any
+	Variant string `json:",omitempty"`
+	// Os is the operating system the image runs on
+	Os string
+	// Size is the total size of the image, in bytes
+	Size int64
+	// VirtualSize is the total size of the image. Kept for backwards compatibility, it matches Size
+	VirtualSize int64
+	// RootFS are the layers the image is made of
+	RootFS RootFS
+	// Metadata holds local information about the image
+	Metadata struct {
+		// LastTagTime is the last time the image was tagged, zero if it was never tagged locally
+		LastTagTime time.Time
+	}
+}
+
+// RootFS are the layers an image is made of
+type RootFS struct {
+	// Type is the type of the root filesystem, usually layers
+	Type string
+	// Layers are the digests of the layers, from bottom to top
+	Layers []string
+}
+
+// ImageHistoryItem is a layer of an image history, most recent first
+type ImageHistoryItem struct {
+	// ID of the image the layer belongs to, or <missing> for layers built somewhere else
+	ID string
+	// Created is the time the layer was created, in seconds since epoch
+	Created int64
+	// CreatedBy is the command that created the layer
+	CreatedBy string
+	// Tags are the references of the image the layer belongs to
+	Tags []string
+	// Size is the size of the layer, in bytes
+	Size int64
+	// Comment is the commit message of the layer
+	Comment string
+}
+
+// ImageDeleteResponseItem is a reference untagged or an image deleted when removing or pruning images
+type ImageDeleteResponseItem struct {
+	// Untagged is the reference removed, if this item is about a reference
+	Untagged string `json:",omitempty"`
+	// Deleted is the ID of the image or layer deleted, if this item is about an image
+	Deleted string `json:",omitempty"`
+}
+
+// PruneImagesResponseBody wraps the response body coming from the docker daemon when pruning images
+type PruneImagesResponseBody struct {
+	// ImagesDeleted are the references untagged and the images deleted
+	ImagesDeleted []ImageDeleteResponseItem
+	// SpaceReclaimed is the disk space freed, in bytes
+	SpaceReclaimed uint64
+}
+
+// CreateNetworkResponseBody wraps the response body coming from the docker daemon when creating a network
+type CreateNetworkResponseBody struct {
+	// ID of the network created
+	ID string `json:"Id"`
+	// Warning is set if the network was created with a warning
+	Warning string
+}
+
+// PruneNetworksResponseBody wraps the response body coming from the docker daemon when pruning networks
+type PruneNetworksResponseBody struct {
+	// NetworksDeleted are the names of the networks removed
+	NetworksDeleted []string
+}
+
+/* ContainerPathStat describes a file or directory inside a container, as returned by the daemon
+in the X-Docker-Container-Path-Stat header of the archive endpoints */
+type ContainerPathStat struct {
+	// Name is the base name of the file
+	Name string `json:"name"`
+	// Size is the size of the file in bytes
+	Size int64 `json:"size"`
+	// Mode holds the type and permissions of the file
+	Mode os.FileMode `json:"mode"`
+	// Mtime is the last modification time of the file
+	Mtime time.Time `json:"mtime"`
+	// LinkTarget is the path a symbolic link points to, empty for other files
+	LinkTarget string `json:"linkTarget"`
+}
@@ -0,0 +1,561 @@
+package dockerclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// ContainerOptions gathers the settings of a new container
+type ContainerOptions struct {
+	// Image is the image reference to create the container from (e.g. ubuntu:20.04)
+	Image string
+	// Cmd is the command to run when starting the container. Defaults to the image command
+	Cmd []string
+	// Entrypoint overrides the image entrypoint
+	Entrypoint []string
+	// Env is a list of environment variables in the form KEY=value
+	Env []string
+	// Labels are the labels to set on the container
+	Labels map[string]string
+	// WorkingDir is the directory the command runs in. Defaults to the image working directory
+	WorkingDir string
+	// User is the user (and optionally group) that runs the command. Defaults to the image user
+	User string
+	// Hostname is the hostname of the container. Defaults to the short container ID
+	Hostname string
+	// Tty allocates a pseudo-TTY
+	Tty bool
+	// ExposedPorts are the ports the container listens on, as "port" or "port/protocol" (tcp by default)
+	ExposedPorts []string
+	// Ports are the container ports to publish on the host. They are exposed as well
+	Ports []PortMapping
+	// Binds are the bind mounts and named volumes, as "source:destination[:options]" (e.g. /data:/data:ro)
+	Binds []string
+	// Volumes are the paths inside the container to use as anonymous volumes
+	Volumes []string
+	// RestartPolicy is the behaviour to apply when the container exits. Defaults to never restarting it
+	RestartPolicy models.RestartPolicy
+	// AutoRemove removes the container once it exits
+	AutoRemove bool
+	// NetworkMode is the network to attach the container to (bridge, host, none, container:<id> or a network name)
+	NetworkMode string
+	// NetworkEndpoint sets the aliases and static addresses of the container in the NetworkMode network
+	NetworkEndpoint EndpointOptions
+	// Healthcheck is the test run to check if the container is healthy. Defaults to the image healthcheck
+	Healthcheck *models.HealthConfig
+	// Memory is the memory limit in bytes. 0 means no limit
+	Memory int64
+	// CPUShares is the relative CPU weight of the container against other containers. 0 means the default (1024)
+	CPUShares int64
+	// CPUPeriod is the length in microseconds of a CPU CFS period. 0 means the default (100ms)
+	CPUPeriod int64
+	// CPUQuota is the CPU time in microseconds the container can use per CPUPeriod. 0 means no limit
+	CPUQuota int64
+	// NanoCPUs is the CPU limit in units of 10^-9 CPUs (e.g. 1500000000 for 1.5 CPUs). 0 means no limit
+	NanoCPUs int64
+	// PidsLimit is the maximum amount of processes in the container. 0 means no limit
+	PidsLimit int64
+}
+
+// EndpointOptions gathers the settings of a container in a network
+type EndpointOptions struct {
+	// Aliases are extra names the container is reachable by in the network. Only user-defined networks support them
+	Aliases []string
+	// IPv4Address is a static IPv4 for the container. It must be in a subnet given when creating the network
+	IPv4Address string
+	// IPv6Address is a static IPv6 for the container
+	IPv6Address string
+}
+
+// settings returns the options as the endpoint settings the daemon expects, or nil if no option is set
+func (o EndpointOptions) settings() *models.EndpointSettings {
+	if len(o.Aliases) == 0 && o.IPv4Address == "" && o.IPv6Address == "" {
+		return nil
+	}
+	settings := &models.EndpointSettings{Aliases: o.Aliases}
+	if o.IPv4Address != "" || o.IPv6Address != "" {
+		settings.IPAMConfig = &models.EndpointIPAMConfig{IPv4Address: o.IPv4Address, IPv6Address: o.IPv6Address}
+	}
+	return settings
+}
+
+// PortMapping publishes a container port on the host
+type PortMapping struct {
+	// ContainerPort is the port inside the container, as "port" or "port/protocol" (tcp by default)
+	ContainerPort string
+	// HostIP is the host IP to bind to. Empty means every interface
+	HostIP string
+	// HostPort is the host port to bind to. Empty means a random port
+	HostPort string
+}
+
+// body returns the options as the body of a container creation query
+func (o ContainerOptions) body() (models.CreateContainerBody, error) {
+	body := models.CreateContainerBody{
+		ContainerConfig: models.ContainerConfig{
+			Hostname:    o.Hostname,
+			User:        o.User,
+			Tty:         o.Tty,
+			Env:         o.Env,
+			Cmd:         o.Cmd,
+			Healthcheck: o.Healthcheck,
+			Image:       o.Image,
+			WorkingDir:  o.WorkingDir,
+			Entrypoint:  o.Entrypoint,
+			Labels:      o.Labels,
+		},
+		HostConfig: models.HostConfig{
+			Binds:         o.Binds,
+			NetworkMode:   o.NetworkMode,
+			RestartPolicy: o.RestartPolicy,
+			AutoRemove:    o.AutoRemove,
+			Memory:        o.Memory,
+			NanoCpus:      o.NanoCPUs,
+			CPUShares:     o.CPUShares,
+			CPUPeriod:     o.CPUPeriod,
+			CPUQuota:      o.CPUQuota,
+			PidsLimit:     o.PidsLimit,
+		},
+	}
+
+	if endpoint := o.NetworkEndpoint.settings(); endpoint != nil {
+		network := o.NetworkMode
+		if network == "" || network == "default" {
+			network = "bridge"
+		}
+		body.NetworkingConfig.EndpointsConfig = map[string]*models.EndpointSettings{network: endpoint}
+	}
+	for _, port := range o.ExposedPorts {
+		port, err := normalizePort(port)
+		if err != nil {
+			return body, err
+		}
+		if body.ExposedPorts == nil {
+			body.ExposedPorts = make(map[string]struct{})
+		}
+		body.ExposedPorts[port] = struct{}{}
+	}
+	for _, mapping := range o.Ports {
+		port, err := normalizePort(mapping.ContainerPort)
+		if err != nil {
+			return body, err
+		}
+		if body.ExposedPorts == nil {
+			body.ExposedPorts = make(map[string]struct{})
+		}
+		if body.HostConfig.PortBindings == nil {
+			body.HostConfig.PortBindings = make(map[string][]models.PortBinding)
+		}
+		body.ExposedPorts[port] = struct{}{}
+		body.HostConfig.PortBindings[port] = append(body.HostConfig.PortBindings[port],
+			models.PortBinding{HostIP: mapping.HostIP, HostPort: mapping.HostPort})
+	}
+	for _, volume := range o.Volumes {
+		if body.Volumes == nil {
+			body.Volumes = make(map[string]struct{})
+		}
+		body.Volumes[volume] = struct{}{}
+	}
+
+	return body, nil
+}
+
+// normalizePort returns a port as "port/protocol", defaulting to tcp
+func normalizePort(port string) (string, error) {
+	number, protocol := port, "tcp"
+	if i := strings.Index(port, "/"); i >= 0 {
+		number, protocol = port[:i], port[i+1:]
+	}
+	if n, err := strconv.ParseUint(number, 10, 16); err != nil || n == 0 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+	switch protocol {
+	case "tcp", "udp", "sctp":
+		return number + "/" + protocol, nil
+	default:
+		return "", fmt.Errorf("invalid protocol %q in port %q", protocol, port)
+	}
+}
+
+// WaitCondition is the condition to wait for when waiting for a container
+type WaitCondition string
+
+// Conditions accepted by the wait endpoint
+const (
+	// WaitConditionNotRunning returns as soon as the container is not running, straight away if it is already stopped
+	WaitConditionNotRunning WaitCondition = "not-running"
+	// WaitConditionNextExit returns the next time the container exits, even if it is already stopped
+	WaitConditionNextExit WaitCondition = "next-exit"
+	// WaitConditionRemoved returns once the container is removed
+	WaitConditionRemoved WaitCondition = "removed"
+)
+
+/* StopOptions gathers the settings used when stopping or restarting a container. The container is sent Signal and,
+if it is still running after Timeout, it is killed */
+type StopOptions struct {
+	// Timeout is the number of seconds to wait before killing the container. The container default (usually 10) is used if nil
+	Timeout *int
+	// Signal is the signal sent to stop the container (e.g. SIGINT). The container StopSignal (usually SIGTERM) is used if empty
+	Signal string
+}
+
+// query returns the options as URL query parameters
+func (o StopOptions) query() url.Values {
+	query := url.Values{}
+	if o.Timeout != nil {
+		query.Set("t", strconv.Itoa(*o.Timeout))
+	}
+	if o.Signal != "" {
+		query.Set("signal", o.Signal)
+	}
+	return query
+}
+
+// RemoveContainerOptions gathers the settings used when removing a container
+type RemoveContainerOptions struct {
+	// Force kills the container first if it is running. Otherwise running containers cannot be removed
+	Force bool
+	// RemoveVolumes removes the anonymous volumes of the container as well. Named volumes are always kept
+	RemoveVolumes bool
+	// RemoveLinks removes the legacy link given as container name (e.g. /parent/alias) instead of the container
+	RemoveLinks bool
+}
+
+// query returns the options as URL query parameters
+func (o RemoveContainerOptions) query() url.Values {
+	query := url.Values{}
+	if o.Force {
+		query.Set("force", "true")
+	}
+	if o.RemoveVolumes {
+		query.Set("v", "true")
+	}
+	if o.RemoveLinks {
+		query.Set("link", "true")
+	}
+	return query
+}
+
+// ExecOptions gathers the settings of a new exec instance
+type ExecOptions struct {
+	// Cmd are the commands to run inside the container
+	Cmd []string
+	// Tty allocates a pseudo-TTY. stdout and stderr are merged when set, and kept apart otherwise
+	Tty bool
+	// AttachStdin keeps stdin open, so it can be written to through StartInteractiveExecInstance
+	AttachStdin bool
+	// Env is a list of environment variables in the form KEY=value
+	Env []string
+	// WorkingDir is the directory to run the command in. Defaults to the container working directory
+	WorkingDir string
+	// User is the user (and optionally group) that runs the command. Defaults to the container user
+	User string
+}
+
+// ExecResult is the outcome of a command run inside a container
+type ExecResult struct {
+	// Stdout is the standard output of the command. It holds the whole output if a TTY was allocated
+	Stdout string
+	// Stderr is the standard error of the command. Always empty if a TTY was allocated
+	Stderr string
+	// ExitCode is the exit code of the command
+	ExitCode int
+}
+
+/* Filters narrows down the results of list queries, mapping every filter key to the values accepted for it
+(e.g. Filters{"status": {"running", "paused"}}). Values of a key are ORed, while different keys are ANDed */
+type Filters map[string][]string
+
+// Add appends a value to the filter key given
+func (f Filters) Add(key string, value string) {
+	f[key] = append(f[key], value)
+}
+
+// encode returns the filters as the JSON document the daemon expects in the filters query parameter
+func (f Filters) encode() (string, error) {
+	filters, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return string(filters), nil
+}
+
+// ListContainersOptions gathers the settings used when listing containers
+type ListContainersOptions struct {
+	// All lists every container. By default only running containers are listed
+	All bool
+	// Limit lists only the last containers created, running or not. 0 means no limit
+	Limit int
+	// Size computes the size of the containers files (SizeRw and SizeRootFs)
+	Size bool
+	// Filters narrows down the containers listed. Keys supported are label (key or key=value), status, name and ancestor
+	Filters Filters
+}
+
+// query returns the options as URL query parameters
+func (o ListContainersOptions) query() (url.Values, error) {
+	query := url.Values{}
+	if o.All {
+		query.Set("all", "true")
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Size {
+		query.Set("size", "true")
+	}
+	if len(o.Filters) > 0 {
+		filters, err := o.Filters.encode()
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", filters)
+	}
+	return query, nil
+}
+
+// ListImagesOptions gathers the settings used when listing images
+type ListImagesOptions struct {
+	// All lists intermediate images as well. By default only top level images are listed
+	All bool
+	// Digests returns the digests of the images (RepoDigests)
+	Digests bool
+	/* Filters narrows down the images listed. Keys supported are dangling (true or false), reference (a name or name:tag,
+	with wildcards), label (key or key=value), and before and since (an image reference or ID) */
+	Filters Filters
+}
+
+// query returns the options as URL query parameters
+func (o ListImagesOptions) query() (url.Values, error) {
+	query := url.Values{}
+	if o.All {
+		query.Set("all", "true")
+	}
+	if o.Digests {
+		query.Set("digests", "true")
+	}
+	if len(o.Filters) > 0 {
+		filters, err := o.Filters.encode()
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", filters)
+	}
+	return query, nil
+}
+
+// RemoveImageOptions gathers the settings used when removing an image
+type RemoveImageOptions struct {
+	// Force removes the image even if stopped containers use it or it is referenced in multiple repositories
+	Force bool
+	// NoPrune keeps the untagged parents of the image
+	NoPrune bool
+}
+
+// query returns the options as URL query parameters
+func (o RemoveImageOptions) query() url.Values {
+	query := url.Values{}
+	if o.Force {
+		query.Set("force", "true")
+	}
+	if o.NoPrune {
+		query.Set("noprune", "true")
+	}
+	return query
+}
+
+// BuildOptions gathers the settings used when building an image
+type BuildOptions struct {
+	// Tags are the references (name:tag) to give to the image built
+	Tags []string
+	// Dockerfile is the path of the Dockerfile, relative to the build context. Defaults to Dockerfile
+	Dockerfile string
+	// BuildArgs are the values of the ARG instructions of the Dockerfile
+	BuildArgs map[string]string
+	// Labels are the labels to set on the image built
+	Labels map[string]string
+	// Target is the stage of a multi-stage Dockerfile to build. Defaults to the last one
+	Target string
+	// NoCache builds every step from scratch, without using the build cache
+	NoCache bool
+}
+
+// query returns the options as URL query parameters
+func (o BuildOptions) query() (url.Values, error) {
+	query := url.Values{}
+	for _, tag := range o.Tags {
+		query.Add("t", tag)
+	}
+	if o.Dockerfile != "" {
+		query.Set("dockerfile", o.Dockerfile)
+	}
+	if len(o.BuildArgs) > 0 {
+		buildArgs, err := json.Marshal(o.BuildArgs)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("buildargs", string(buildArgs))
+	}
+	if len(o.Labels) > 0 {
+		labels, err := json.Marshal(o.Labels)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("labels", string(labels))
+	}
+	if o.Target != "" {
+		query.Set("target", o.Target)
+	}
+	if o.NoCache {
+		query.Set("nocache", "true")
+	}
+	return query, nil
+}
+
+// ImportImageOptions gathers the settings used when importing an image from a filesystem archive
+type ImportImageOptions struct {
+	// Repository is the repository of the new image. The image is left untagged if empty
+	Repository string
+	// Tag is the tag of the new image. Defaults to latest
+	Tag string
+	// Changes are Dockerfile instructions (CMD, ENTRYPOINT, ENV, EXPOSE, LABEL, STOPSIGNAL, USER, VOLUME or WORKDIR) applied to the image
+	Changes []string
+	// Message is the commit message of the new image
+	Message string
+}
+
+// query returns the options as URL query parameters, the archive being read from the request body
+func (o ImportImageOptions) query() url.Values {
+	query := url.Values{}
+	query.Set("fromSrc", "-")
+	if o.Repository != "" {
+		query.Set("repo", o.Repository)
+	}
+	if o.Tag != "" {
+		query.Set("tag", o.Tag)
+	}
+	for _, change := range o.Changes {
+		query.Add("changes", change)
+	}
+	if o.Message != "" {
+		query.Set("message", o.Message)
+	}
+	return query
+}
+
+// CopyToContainerOptions gathers the settings used when copying an archive into a container
+type CopyToContainerOptions struct {
+	// NoOverwriteDirNonDir fails the copy if it would replace a directory with a file or the other way round
+	NoOverwriteDirNonDir bool
+	// CopyUIDGID keeps the owners of the archive entries. By default files are owned by the container root user
+	CopyUIDGID bool
+}
+
+// query returns the options as URL query parameters, alongside the directory to extract the archive into
+func (o CopyToContainerOptions) query(dstPath string) url.Values {
+	query := url.Values{}
+	query.Set("path", dstPath)
+	if o.NoOverwriteDirNonDir {
+		query.Set("noOverwriteDirNonDir", "true")
+	}
+	if o.CopyUIDGID {
+		query.Set("copyUIDGID", "true")
+	}
+	return query
+}
+
+// LogsOptions gathers the settings used when reading the logs of a container
+type LogsOptions struct {
+	// Stdout returns the standard output of the container. Both streams are returned if neither Stdout nor Stderr are set
+	Stdout bool
+	// Stderr returns the standard error of the container. Both streams are returned if neither Stdout nor Stderr are set
+	Stderr bool
+	// Since only returns the logs written after a given time. The zero time means since the beginning
+	Since time.Time
+	// Until only returns the logs written before a given time. The zero time means until now
+	Until time.Time
+	// Tail only returns the last lines of the logs (none if it points to 0). Every line is returned if nil
+	Tail *int
+	// Timestamps prefixes every line with the time it was written (RFC3339 with nanoseconds)
+	Timestamps bool
+	// Follow keeps streaming the logs as they are written, until the container stops
+	Follow bool
+}
+
+// query returns the options as URL query parameters
+func (o LogsOptions) query() url.Values {
+	query := url.Values{}
+	stdout, stderr := o.Stdout, o.Stderr
+	if !stdout && !stderr {
+		stdout, stderr = true, true
+	}
+	query.Set("stdout", strconv.FormatBool(stdout))
+	query.Set("stderr", strconv.FormatBool(stderr))
+	if !o.Since.IsZero() {
+		query.Set("since", unixTimestamp(o.Since))
+	}
+	if !o.Until.IsZero() {
+		query.Set("until", unixTimestamp(o.Until))
+	}
+	if o.Tail != nil {
+		query.Set("tail", strconv.Itoa(*o.Tail))
+	}
+	if o.Timestamps {
+		query.Set("timestamps", "true")
+	}
+	if o.Follow {
+		query.Set("follow", "true")
+	}
+	return query
+}
+
+// unixTimestamp formats t as seconds since epoch with nanoseconds, the way docker expects times in queries
+func unixTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// CreateNetworkOptions gathers the settings of a new network
+type CreateNetworkOptions struct {
+	// Driver is the network driver. Defaults to bridge
+	Driver string
+	// Subnet is the subnet of the network in CIDR notation (e.g. 172.28.0.0/16). Defaults to a free subnet picked by the daemon
+	Subnet string
+	// IPRange is the part of the subnet containers are given addresses from, in CIDR notation. Defaults to the whole subnet
+	IPRange string
+	// Gateway is the gateway of the subnet. Defaults to its first address
+	Gateway string
+	// Internal restricts the access of the containers in the network to the network itself
+	Internal bool
+	// Attachable lets standalone containers join the network (only meaningful for swarm networks)
+	Attachable bool
+	// Labels are the labels to set on the network
+	Labels map[string]string
+	// Options are the driver options (e.g. com.docker.network.bridge.name)
+	Options map[string]string
+}
+
+// body returns the options as the body of a network creation query
+func (o CreateNetworkOptions) body(name string) models.CreateNetworkBody {
+	body := models.CreateNetworkBody{
+		Name:           name,
+		CheckDuplicate: true,
+		Driver:         o.Driver,
+		Internal:       o.Internal,
+		Attachable:     o.Attachable,
+		Options:        o.Options,
+		Labels:         o.Labels,
+	}
+	if o.Subnet != "" || o.IPRange != "" || o.Gateway != "" {
+		body.IPAM = &models.IPAM{
+			Driver: "default",
+			Config: []models.IPAMConfig{{Subnet: o.Subnet, IPRange: o.IPRange, Gateway: o.Gateway}},
+		}
+	}
+	return body
+}
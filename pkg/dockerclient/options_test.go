@@ -0,0 +1,69 @@
+package dockerclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+func TestContainerOptions_body(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          ContainerOptions
+		wantExposedPorts map[string]struct{}
+		wantPortBindings map[string][]models.PortBinding
+		wantErr          bool
+	}{
+		{
+			name:    "No ports",
+			options: ContainerOptions{Image: "ubuntu:20.04"},
+		},
+		{
+			name:             "Ports default to tcp",
+			options:          ContainerOptions{ExposedPorts: []string{"80", "53/udp"}},
+			wantExposedPorts: map[string]struct{}{"80/tcp": {}, "53/udp": {}},
+		},
+		{
+			name: "Published ports are exposed",
+			options: ContainerOptions{Ports: []PortMapping{
+				{ContainerPort: "80", HostPort: "8080"},
+				{ContainerPort: "80/tcp", HostIP: "127.0.0.1", HostPort: "8081"},
+				{ContainerPort: "443"},
+			}},
+			wantExposedPorts: map[string]struct{}{"80/tcp": {}, "443/tcp": {}},
+			wantPortBindings: map[string][]models.PortBinding{
+				"80/tcp":  {{HostPort: "8080"}, {HostIP: "127.0.0.1", HostPort: "8081"}},
+				"443/tcp": {{}},
+			},
+		},
+		{
+			name:    "Port out of range",
+			options: ContainerOptions{ExposedPorts: []string{"70000"}},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown protocol",
+			options: ContainerOptions{Ports: []PortMapping{{ContainerPort: "80/http"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.options.body()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ContainerOptions.body() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.ExposedPorts, tt.wantExposedPorts) {
+				t.Errorf("ContainerOptions.body() exposed ports = %v, want %v", got.ExposedPorts, tt.wantExposedPorts)
+			}
+			if !reflect.DeepEqual(got.HostConfig.PortBindings, tt.wantPortBindings) {
+				t.Errorf("ContainerOptions.body() port bindings = %v, want %v", got.HostConfig.PortBindings, tt.wantPortBindings)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// ReadyState is a state a container can be waited for using WaitForState
+type ReadyState string
+
+// States supported by WaitForState
+const (
+	// StateRunning is reached once the container is running
+	StateRunning ReadyState = "running"
+	// StateHealthy is reached once the healthcheck of the container reports it as healthy
+	StateHealthy ReadyState = "healthy"
+	// StateExited is reached once the container is no longer running
+	StateExited ReadyState = "exited"
+)
+
+// ErrNoHealthcheck is returned when waiting for a container without healthcheck to be healthy
+var ErrNoHealthcheck = errors.New("the container has no healthcheck")
+
+// Backoff is the delay between two checks when waiting for a container, growing from Initial up to Max
+type Backoff struct {
+	// Initial is the delay before the second check (the first one is done straight away)
+	Initial time.Duration
+	// Max is the longest delay between two checks
+	Max time.Duration
+	// Factor is what the delay is multiplied by after every check
+	Factor float64
+}
+
+// DefaultBackoff checks quickly at first, so containers starting fast are not waited for longer than needed
+var DefaultBackoff = Backoff{Initial: 100 * time.Millisecond, Max: 2 * time.Second, Factor: 2}
+
+// next returns the delay that follows delay
+func (b Backoff) next(delay time.Duration) time.Duration {
+	delay = time.Duration(float64(delay) * b.Factor)
+	if delay > b.Max || delay <= 0 {
+		return b.Max
+	}
+	return delay
+}
+
+// ContainerExitedError is returned when a container exits while waiting for it to be running or healthy
+type ContainerExitedError struct {
+	// ContainerID is the container waited for
+	ContainerID string
+	// ExitCode is the exit code of the container
+	ExitCode int
+}
+
+func (e *ContainerExitedError) Error() string {
+	return fmt.Sprintf("the container %s exited with code %d", e.ContainerID, e.ExitCode)
+}
+
+// ContainerUnhealthyError is returned when a container turns unhealthy while waiting for it to be healthy
+type ContainerUnhealthyError struct {
+	// ContainerID is the container waited for
+	ContainerID string
+	// FailingStreak is the amount of consecutive failed checks
+	FailingStreak int
+	// Log are the results of the last checks, which usually tell why the container is unhealthy
+	Log []models.HealthcheckResult
+}
+
+func (e *ContainerUnhealthyError) Error() string {
+	message := fmt.Sprintf("the container %s is unhealthy after %d failed checks", e.ContainerID, e.FailingStreak)
+	if len(e.Log) > 0 {
+		last := e.Log[len(e.Log)-1]
+		message += fmt.Sprintf(", last check exited with code %d: %s", last.ExitCode, strings.TrimSpace(last.Output))
+	}
+	return message
+}
+
+/* WaitForState blocks until a container gets into the state given, checking it with backoff, or until ctx is done
+(use context.WithTimeout to give up after a while). It returns the container as inspected once in that state.
+A ContainerExitedError is returned if the container exits while waiting for it to be running or healthy, and a
+ContainerUnhealthyError (holding the healthcheck log) if it turns unhealthy while waiting for it to be healthy */
+func WaitForState(ctx context.Context, dockerClient Docker, containerID string, state ReadyState, backoff Backoff) (*models.InspectContainerResponseBody, error) {
+	if state == StateExited {
+		// The daemon tells when the container exits, so there is no need to poll
+		if _, err := dockerClient.WaitContainer(ctx, containerID, WaitConditionNotRunning); err != nil {
+			return nil, err
+		}
+		return dockerClient.InspectContainer(ctx, containerID)
+	}
+
+	delay := backoff.Initial
+	for {
+		container, err := dockerClient.InspectContainer(ctx, containerID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case state == StateRunning && container.State.Running:
+			return container, nil
+		case state == StateHealthy && container.State.Health != nil && container.State.Health.Status == "healthy":
+			return container, nil
+		case state != StateRunning && state != StateHealthy:
+			return nil, fmt.Errorf("unknown container state %q", state)
+		case container.State.Status == "exited" || container.State.Status == "dead":
+			return container, &ContainerExitedError{ContainerID: containerID, ExitCode: container.State.ExitCode}
+		case state == StateHealthy && container.State.Running && container.State.Health == nil:
+			return container, ErrNoHealthcheck
+		case state == StateHealthy && container.State.Health != nil && container.State.Health.Status == "unhealthy":
+			return container, &ContainerUnhealthyError{ContainerID: containerID,
+				FailingStreak: container.State.Health.FailingStreak, Log: container.State.Health.Log}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay = backoff.next(delay)
+	}
+}
+
+/* WaitForExecInstance polls an exec instance given its ID until the daemon no longer reports it running, or ctx is done.
+The output stream of an exec instance may end slightly before the daemon marks it as finished, so its exit code cannot
+be trusted until then. It returns the last inspection of the exec instance, holding its exit code */
+func WaitForExecInstance(ctx context.Context, dockerClient Docker, execInstanceID string) (*models.InspectExecResponseBody, error) {
+	const pollInterval = 50 * time.Millisecond
+	for {
+		execInstance, err := dockerClient.InspectExecInstance(ctx, execInstanceID)
+		if err != nil {
+			return nil, err
+		}
+		if !execInstance.Running {
+			return execInstance, nil
+		}
+
+		timer := time.NewTimer(pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+func TestWaitForState(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create containers from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create and run a container that keeps running, and another one that exits with code 3
+	runningID, err := dockerClient.CreateContainerContext(context.Background(), "ubunturunning", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exitedID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntuexited", "ubuntu", "20.04", []string{"sh", "-c", "exit 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{runningID, exitedID} {
+		err = dockerClient.RunContainerContext(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		containerID  string
+		state        ReadyState
+		wantExitCode int
+		wantErr      error
+	}{
+		{
+			name:        "Wait for a container to be running",
+			containerID: runningID,
+			state:       StateRunning,
+		},
+		{
+			name:         "Wait for a container to exit",
+			containerID:  exitedID,
+			state:        StateExited,
+			wantExitCode: 3,
+		},
+		{
+			name:         "Wait for an exited container to be running",
+			containerID:  exitedID,
+			state:        StateRunning,
+			wantExitCode: 3,
+			wantErr:      &ContainerExitedError{},
+		},
+		{
+			name:        "Wait for a container without healthcheck to be healthy",
+			containerID: runningID,
+			state:       StateHealthy,
+			wantErr:     ErrNoHealthcheck,
+		},
+		{
+			name:        "Wait for a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			state:       StateRunning,
+			wantErr:     ErrContainerDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			got, err := WaitForState(ctx, dockerClient, tt.containerID, tt.state, DefaultBackoff)
+			var exitedErr *ContainerExitedError
+			if errors.As(tt.wantErr, &exitedErr) {
+				if !errors.As(err, &exitedErr) || exitedErr.ExitCode != tt.wantExitCode {
+					t.Errorf("WaitForState() error = %v, want a ContainerExitedError with exit code %d", err, tt.wantExitCode)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WaitForState() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.State.ExitCode != tt.wantExitCode {
+				t.Errorf("WaitForState() exit code = %d, want %d", got.State.ExitCode, tt.wantExitCode)
+			}
+		})
+	}
+
+	// Waiting gives up when ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = WaitForState(ctx, dockerClient, runningID, StateExited, DefaultBackoff)
+	if err == nil {
+		t.Errorf("WaitForState() error = nil, want an error once ctx is done")
+	}
+
+	// Unknown states are rejected
+	_, err = WaitForState(context.Background(), dockerClient, runningID, "paused", DefaultBackoff)
+	if err == nil {
+		t.Errorf("WaitForState() error = nil, want an error for unknown states")
+	}
+
+	// Remove containers
+	_, err = dockerClient.StopContainerContext(context.Background(), runningID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{runningID, exitedID} {
+		err = dockerClient.RemoveContainerContext(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWaitForState_Healthcheck(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create containers from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		containerName string
+		healthcheck   *models.HealthConfig
+		wantErr       bool
+	}{
+		{
+			name:          "Wait for a healthy container",
+			containerName: "ubuntuhealthy",
+			healthcheck:   &models.HealthConfig{Test: []string{"CMD", "true"}, Interval: 50 * time.Millisecond},
+			wantErr:       false,
+		},
+		{
+			name:          "Wait for a container that turns unhealthy",
+			containerName: "ubuntuunhealthy",
+			healthcheck:   &models.HealthConfig{Test: []string{"CMD", "false"}, Interval: 50 * time.Millisecond, Retries: 2},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := dockerClient.CreateContainerWithOptions(context.Background(), tt.containerName,
+				ContainerOptions{Image: "ubuntu:20.04", Cmd: []string{"sleep", "infinity"}, Healthcheck: tt.healthcheck})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				dockerClient.StopContainerContext(context.Background(), id)
+				dockerClient.RemoveContainerContext(context.Background(), id)
+			}()
+			err = dockerClient.RunContainerContext(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			got, err := WaitForState(ctx, dockerClient, id, StateHealthy, DefaultBackoff)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WaitForState() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				if got.State.Health.Status != "healthy" || len(got.State.Health.Log) == 0 {
+					t.Errorf("WaitForState() health = %+v, want healthy with the log of the checks", got.State.Health)
+				}
+				return
+			}
+
+			// The health log tells why the container is unhealthy
+			var unhealthyErr *ContainerUnhealthyError
+			if !errors.As(err, &unhealthyErr) {
+				t.Fatalf("WaitForState() error = %v, want a ContainerUnhealthyError", err)
+			}
+			if unhealthyErr.FailingStreak < tt.healthcheck.Retries || len(unhealthyErr.Log) == 0 ||
+				unhealthyErr.Log[len(unhealthyErr.Log)-1].ExitCode != 1 {
+				t.Errorf("WaitForState() error = %+v, want %d failed checks in the log", unhealthyErr, tt.healthcheck.Retries)
+			}
+		})
+	}
+}
+
+func TestBackoff_next(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Factor: 2}
+	tests := []struct {
+		name  string
+		delay time.Duration
+		want  time.Duration
+	}{
+		{name: "Grow the delay", delay: 100 * time.Millisecond, want: 200 * time.Millisecond},
+		{name: "Cap the delay", delay: 800 * time.Millisecond, want: time.Second},
+		{name: "Keep the maximum delay", delay: time.Second, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoff.next(tt.delay); got != tt.want {
+				t.Errorf("Backoff.next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// lateExecDocker reports an exec instance as running for a number of inspections before it finishes with exit code 1
+type lateExecDocker struct {
+	Docker
+	runningInspections int
+}
+
+func (d *lateExecDocker) InspectExecInstance(ctx context.Context, execInstanceID string) (*models.InspectExecResponseBody, error) {
+	if d.runningInspections > 0 {
+		d.runningInspections--
+		return &models.InspectExecResponseBody{ID: execInstanceID, Running: true}, nil
+	}
+	return &models.InspectExecResponseBody{ID: execInstanceID, ExitCode: 1}, nil
+}
+
+func TestWaitForExecInstance(t *testing.T) {
+	tests := []struct {
+		name               string
+		runningInspections int
+		timeout            time.Duration
+		wantExitCode       int
+		wantErr            error
+	}{
+		{
+			name:               "Wait for an exec instance already finished",
+			runningInspections: 0,
+			timeout:            time.Second,
+			wantExitCode:       1,
+		},
+		{
+			name:               "Wait for an exec instance still reported running",
+			runningInspections: 3,
+			timeout:            time.Second,
+			wantExitCode:       1,
+		},
+		{
+			name:               "Time out while the exec instance is running",
+			runningInspections: 1000,
+			timeout:            200 * time.Millisecond,
+			wantErr:            context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			execInstance, err := WaitForExecInstance(ctx, &lateExecDocker{runningInspections: tt.runningInspections}, "execid")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WaitForExecInstance() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (execInstance.Running || execInstance.ExitCode != tt.wantExitCode) {
+				t.Errorf("WaitForExecInstance() = %+v, want exit code %d once finished", execInstance, tt.wantExitCode)
+			}
+		})
+	}
+}
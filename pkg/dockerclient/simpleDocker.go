@@ -1,9 +1,13 @@
 package dockerclient
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 
 	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
 	"github.com/mikeletux/go-docker-manager/pkg/httpclient"
@@ -18,6 +22,20 @@ var (
 	ErrContainerIsRunning        = errors.New("cannot perform this operation with the container running")
 	ErrContainerIsStopped        = errors.New("cannot perform this operation because the container is stopped")
 	ErrExecInstanceDoesNotExist  = errors.New("the exec instance selected does not exist")
+	ErrImagePullFailed           = errors.New("the docker daemon reported an error while pulling the image")
+	ErrBadParameter              = errors.New("the docker daemon rejected the parameters of the query")
+	ErrContainerWaitFailed       = errors.New("the docker daemon reported an error while waiting for the container")
+	ErrContainerIsNotPaused      = errors.New("cannot perform this operation because the container is not paused")
+	ErrContainerStateConflict    = errors.New("the container is not in a state that allows this operation")
+	ErrImageConflict             = errors.New("the image is being used or referenced in multiple repositories")
+	ErrRegistryUnauthorized      = errors.New("the registry rejected the credentials given")
+	ErrImagePushFailed           = errors.New("the docker daemon reported an error while pushing the image")
+	ErrImageBuildFailed          = errors.New("the docker daemon reported an error while building the image")
+	ErrImageLoadFailed           = errors.New("the docker daemon reported an error while loading or importing the image")
+	ErrContainerPathDoesNotExist = errors.New("the path selected does not exist in the container")
+	ErrNetworkDoesNotExist       = errors.New("the network selected does not exist")
+	ErrNetworkAlreadyExist       = errors.New("the network already exist")
+	ErrNetworkInUse              = errors.New("the network has containers attached or is a predefined network")
 )
 
 // SimpleDocker is a docker client that complies with the Docker interface
@@ -37,13 +55,19 @@ func NewSimpeDocker(dockerEndpoint string, httpClient httpclient.HttpClient) *Si
 	}
 }
 
-/* CheckIfImageAlreadyExists figures out if an image is already in the local repository.
-Returns true if it is available in the local registry, false otherwise.*/
+// CheckIfImageAlreadyExists is CheckIfImageAlreadyExistsContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) CheckIfImageAlreadyExists(dockerImage string, tag string) (bool, error) {
-	httpResponse, err := s.HttpClient.Get(fmt.Sprintf("%s/images/%s:%s/json", s.DockerEndpoint, dockerImage, tag), nil)
+	return s.CheckIfImageAlreadyExistsContext(context.Background(), dockerImage, tag)
+}
+
+/* CheckIfImageAlreadyExistsContext figures out if an image is already in the local repository.
+Returns true if it is available in the local registry, false otherwise.*/
+func (s *SimpleDocker) CheckIfImageAlreadyExistsContext(ctx context.Context, dockerImage string, tag string) (bool, error) {
+	urlEndpoint := fmt.Sprintf("%s/images/%s:%s/json", s.DockerEndpoint, dockerImage, tag)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
 	if err != nil {
 		return false, fmt.Errorf("there was an issue with HTTP client when performing "+
-			"GET on %s/images/%s:%s/json - %s", s.DockerEndpoint, dockerImage, tag, err)
+			"GET on %s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
@@ -52,47 +76,99 @@ func (s *SimpleDocker) CheckIfImageAlreadyExists(dockerImage string, tag string)
 	case 404:
 		return false, nil
 	default:
-		return false, ErrDockerInternalServerError
+		return false, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-// PullImageFromRegistry pulls an image from the docker registry given a docker Image name, image tag and image architecture
+// PullImageFromRegistry is PullImageFromRegistryContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) PullImageFromRegistry(dockerImage string, tag string, arch string) error {
-	httpResponse, err := s.HttpClient.Post(fmt.Sprintf("%s/images/create?fromImage=%s&tag=%s&platform=%s",
-		s.DockerEndpoint, dockerImage, tag, arch),
-		nil, // No headers needed
-		"")  // No body needed either
+	return s.PullImageFromRegistryContext(context.Background(), dockerImage, tag, arch)
+}
+
+// PullImageFromRegistryContext pulls an image from the docker registry given a docker Image name, image tag and image architecture
+func (s *SimpleDocker) PullImageFromRegistryContext(ctx context.Context, dockerImage string, tag string, arch string) error {
+	return s.PullImageWithProgress(ctx, dockerImage, tag, arch, nil)
+}
+
+/* PullImageWithProgress pulls an image from the docker registry given a docker Image name, image tag and image architecture.
+progress is called for every progress message sent by the daemon (it can be nil). Errors reported in the middle of
+the pull are returned as ErrImagePullFailed */
+func (s *SimpleDocker) PullImageWithProgress(ctx context.Context, dockerImage string, tag string, arch string, progress ProgressFunc) error {
+	return s.PullImageWithAuth(ctx, dockerImage, tag, arch, nil, progress)
+}
+
+/* PullImageWithAuth pulls an image from a registry given a docker Image name, image tag, image architecture and the
+credentials of the registry (nil to pull anonymously). progress is called for every progress message sent by the daemon
+(it can be nil). Credentials rejected by the registry are returned as ErrRegistryUnauthorized */
+func (s *SimpleDocker) PullImageWithAuth(ctx context.Context, dockerImage string, tag string, arch string, auth *models.AuthConfig, progress ProgressFunc) error {
+	headers, err := registryAuthHeaders(auth)
+	if err != nil {
+		return err
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/images/create?fromImage=%s&tag=%s&platform=%s", s.DockerEndpoint, dockerImage, tag, arch)
+	httpResponse, err := s.HttpClient.Stream(ctx, "POST", urlEndpoint,
+		headers,
+		nil) // No body needed
 	if err != nil {
 		return fmt.Errorf("there was an issue with HTTP client when performing POST "+
-			"on %s/images/create?fromImage=%s&tag=%s&platform=%s - %s", s.DockerEndpoint, dockerImage, tag, arch, err)
+			"on %s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
 	case 200:
+		defer httpResponse.Body.Close()
+
+		// The daemon answers 200 as soon as the pull starts, errors may come later on in the stream
+		daemonError, err := readJSONMessages(httpResponse.Body, progress)
+		if err != nil {
+			return err
+		}
+		if daemonError != "" {
+			return &DockerAPIError{StatusCode: httpResponse.StatusCode, Method: "POST", Endpoint: urlEndpoint,
+				Message: daemonError, Err: ErrImagePullFailed}
+		}
 		return nil
+	case 401:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrRegistryUnauthorized)
 	case 404:
-		return ErrImageDoesNotExist
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrImageDoesNotExist)
 	default:
-		return ErrDockerInternalServerError
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-/* CreateContainer creates a a container given a container name, image name, image tag and list of commands for cmd.
-It returns the ID of the new created container */
+// CreateContainer is CreateContainerContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) CreateContainer(containerName string, image string, tag string, cmd []string) (string, error) {
-	httpRequestBody := models.CreateContainerBody{Cmd: cmd, Image: fmt.Sprintf("%s:%s", image, tag)}
+	return s.CreateContainerContext(context.Background(), containerName, image, tag, cmd)
+}
+
+/* CreateContainerContext creates a a container given a container name, image name, image tag and list of commands for cmd.
+It returns the ID of the new created container */
+func (s *SimpleDocker) CreateContainerContext(ctx context.Context, containerName string, image string, tag string, cmd []string) (string, error) {
+	return s.CreateContainerWithOptions(ctx, containerName, ContainerOptions{Image: fmt.Sprintf("%s:%s", image, tag), Cmd: cmd})
+}
+
+/* CreateContainerWithOptions creates a container given a container name (it can be empty to get a random one) and the container options.
+It returns the ID of the new created container */
+func (s *SimpleDocker) CreateContainerWithOptions(ctx context.Context, containerName string, options ContainerOptions) (string, error) {
+	httpRequestBody, err := options.body()
+	if err != nil {
+		return "", fmt.Errorf("invalid container options - %s", err)
+	}
 	jsonBodyRequest, err := json.Marshal(httpRequestBody)
 	if err != nil {
 		return "", fmt.Errorf("json marshall issue when creating container - %s", err)
 	}
 
-	httpResponse, err := s.HttpClient.Post(fmt.Sprintf("%s/containers/create?name=%s", s.DockerEndpoint, containerName),
+	urlEndpoint := fmt.Sprintf("%s/containers/create?name=%s", s.DockerEndpoint, url.QueryEscape(containerName))
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
 		map[string]string{"Content-Type": "application/json"},
 		string(jsonBodyRequest))
 
 	if err != nil {
 		return "", fmt.Errorf("there was an issue with HTTP client performing POST on "+
-			"%s/containers/create?name=%s - %s", s.DockerEndpoint, containerName, err)
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
@@ -103,75 +179,188 @@ func (s *SimpleDocker) CreateContainer(containerName string, image string, tag s
 			return "", fmt.Errorf("json unmarshalling issue when creating container - %s", err)
 		}
 		return responseBody.ID, nil
+	case 400:
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
 	case 404:
-		return "", ErrImageDoesNotExist
+		// A missing network is reported the same way a missing image is
+		apiErr := newDockerAPIError("POST", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+		if isUserNetwork(options.NetworkMode) {
+			if _, err := s.InspectNetwork(ctx, options.NetworkMode); errors.Is(err, ErrNetworkDoesNotExist) {
+				apiErr.Err = ErrNetworkDoesNotExist
+			}
+		}
+		return "", apiErr
 	case 409:
-		return "", ErrContainerAlreadyExist
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerAlreadyExist)
 	default:
-		return "", ErrDockerInternalServerError
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-// RunContainer starts a new container given a container ID.
+// RunContainer is RunContainerContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) RunContainer(containerID string) error {
-	httpResponse, err := s.HttpClient.Post(fmt.Sprintf("%s/containers/%s/start", s.DockerEndpoint, containerID),
+	return s.RunContainerContext(context.Background(), containerID)
+}
+
+// RunContainerContext starts a new container given a container ID.
+func (s *SimpleDocker) RunContainerContext(ctx context.Context, containerID string) error {
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/start", s.DockerEndpoint, containerID)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
 		nil,
 		"")
 	if err != nil {
 		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
-			"%s/containers/%s/start - %s", s.DockerEndpoint, containerID, err)
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
 	case 204, 304:
 		return nil
 	case 404:
-		return ErrContainerDoesNotExist
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	case 409:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerStateConflict)
 	default:
-		return ErrDockerInternalServerError
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 
 }
 
-/* CheckIfContainerIsReady checks if a container is in running state
-It returns true if it is running, false if in any other  */
+/* WaitContainer blocks until a container meets the wait condition given (WaitConditionNotRunning if empty), or ctx is done.
+It returns the exit code of the container */
+func (s *SimpleDocker) WaitContainer(ctx context.Context, containerID string, condition WaitCondition) (int, error) {
+	if condition == "" {
+		condition = WaitConditionNotRunning
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/wait?condition=%s", s.DockerEndpoint, containerID, condition)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return 0, fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.WaitContainerResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return 0, fmt.Errorf("json unmarshalling issue when waiting for container - %s", err)
+		}
+		// The daemon sends the status code before waiting, so errors found afterwards come in the body
+		if responseBody.Error != nil && responseBody.Error.Message != "" {
+			return responseBody.StatusCode, &DockerAPIError{StatusCode: httpResponse.StatusCode, Method: "POST", Endpoint: urlEndpoint,
+				Message: responseBody.Error.Message, Err: ErrContainerWaitFailed}
+		}
+		return responseBody.StatusCode, nil
+	case 400:
+		return 0, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return 0, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	default:
+		return 0, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// CheckIfContainerIsReady is CheckIfContainerIsReadyContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) CheckIfContainerIsReady(containerID string) (bool, error) {
-	httpResponse, err := s.HttpClient.Get(fmt.Sprintf("%s/containers/%s/json", s.DockerEndpoint, containerID),
+	return s.CheckIfContainerIsReadyContext(context.Background(), containerID)
+}
+
+/* CheckIfContainerIsReadyContext checks if a container is in running state
+It returns true if it is running, false if in any other  */
+func (s *SimpleDocker) CheckIfContainerIsReadyContext(ctx context.Context, containerID string) (bool, error) {
+	container, err := s.InspectContainer(ctx, containerID)
+	if err != nil {
+		return false, err
+	}
+
+	if container.State.Status == "running" {
+		return true, nil
+	}
+	return false, nil // The container is not yet in a running state
+}
+
+// InspectContainer returns low-level information about a container given a container ID: configuration, state, network settings and mounts
+func (s *SimpleDocker) InspectContainer(ctx context.Context, containerID string) (*models.InspectContainerResponseBody, error) {
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/json", s.DockerEndpoint, containerID)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint,
 		nil)
 	if err != nil {
-		return false, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
-			"%s/containers/%s/json - %s", s.DockerEndpoint, containerID, err)
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
 	case 200:
-		var containerStatus models.CheckContainerStatusBody
-		err = json.Unmarshal(httpResponse.Body, &containerStatus)
+		var container models.InspectContainerResponseBody
+		err = json.Unmarshal(httpResponse.Body, &container)
 		if err != nil {
-			return false, fmt.Errorf("json unmarshalling issue when checking if container ready - %s", err)
+			return nil, fmt.Errorf("json unmarshalling issue when inspecting container - %s", err)
 		}
+		return &container, nil
+	case 404:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
 
-		if containerStatus.State.Status == "running" {
-			return true, nil
-		}
-		return false, nil // The container is not yet in a running state
+/* ListContainers lists the containers given the list options. By default only running containers are listed.
+It returns a summary of every container, most recently created first */
+func (s *SimpleDocker) ListContainers(ctx context.Context, options ListContainersOptions) ([]models.ContainerSummary, error) {
+	query, err := options.query()
+	if err != nil {
+		return nil, fmt.Errorf("json marshalling issue when listing containers - %s", err)
+	}
 
-	case 404:
-		return false, ErrContainerDoesNotExist
+	urlEndpoint := fmt.Sprintf("%s/containers/json?%s", s.DockerEndpoint, query.Encode())
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var containers []models.ContainerSummary
+		err = json.Unmarshal(httpResponse.Body, &containers)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when listing containers - %s", err)
+		}
+		return containers, nil
+	case 400:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrBadParameter)
 	default:
-		return false, ErrDockerInternalServerError
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-/* GenerateExecInstance generates a new exec instance on a container given a container ID and a command to run
-It returns the exec ID */
+// GenerateExecInstance is GenerateExecInstanceContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) GenerateExecInstance(containerID string, commands []string) (string, error) {
+	return s.GenerateExecInstanceContext(context.Background(), containerID, commands)
+}
+
+/* GenerateExecInstanceContext generates a new exec instance on a container given a container ID and a command to run
+It returns the exec ID */
+func (s *SimpleDocker) GenerateExecInstanceContext(ctx context.Context, containerID string, commands []string) (string, error) {
+	return s.GenerateExecInstanceWithOptions(ctx, containerID, ExecOptions{Cmd: commands, Tty: true})
+}
+
+/* GenerateExecInstanceWithOptions generates a new exec instance on a container given a container ID and the exec options.
+It returns the exec ID */
+func (s *SimpleDocker) GenerateExecInstanceWithOptions(ctx context.Context, containerID string, options ExecOptions) (string, error) {
 	httpRequestBody := models.GenerateExecInstanceBody{
-		AttachStdin:  false,
+		AttachStdin:  options.AttachStdin,
 		AttachStdout: true,
 		AttachStderr: true,
-		Tty:          true,
-		Cmd:          commands,
+		Tty:          options.Tty,
+		Cmd:          options.Cmd,
+		Env:          options.Env,
+		WorkingDir:   options.WorkingDir,
+		User:         options.User,
 	}
 
 	jsonBodyRequest, err := json.Marshal(httpRequestBody)
@@ -179,13 +368,14 @@ func (s *SimpleDocker) GenerateExecInstance(containerID string, commands []strin
 		return "", fmt.Errorf("json marshall issue when generating exec instance - %s", err)
 	}
 
-	httpResponse, err := s.HttpClient.Post(fmt.Sprintf("%s/containers/%s/exec", s.DockerEndpoint, containerID),
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/exec", s.DockerEndpoint, containerID)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
 		map[string]string{"Content-Type": "application/json"},
 		string(jsonBodyRequest))
 
 	if err != nil {
 		return "", fmt.Errorf("there was an issue with HTTP client when performing POST on "+
-			"%s/containers/%s/exec - %s", s.DockerEndpoint, containerID, err)
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
@@ -197,17 +387,22 @@ func (s *SimpleDocker) GenerateExecInstance(containerID string, commands []strin
 		}
 		return responseBody.ID, nil
 	case 404:
-		return "", ErrContainerDoesNotExist
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
 	case 409:
-		return "", ErrContainerIsStopped
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerIsStopped)
 	default:
-		return "", ErrDockerInternalServerError
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-/* StartExecInstance start an exec instance on a docker daemon given an exec ID.
-It returns the stdout and stderr from inside the container */
+// StartExecInstance is StartExecInstanceContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) StartExecInstance(execInstanceID string) (string, error) {
+	return s.StartExecInstanceContext(context.Background(), execInstanceID)
+}
+
+/* StartExecInstanceContext start an exec instance on a docker daemon given an exec ID.
+It returns the stdout and stderr from inside the container */
+func (s *SimpleDocker) StartExecInstanceContext(ctx context.Context, execInstanceID string) (string, error) {
 	httpRequestBody := models.StartExecInstance{
 		Detach: false,
 		Tty:    true,
@@ -218,36 +413,189 @@ func (s *SimpleDocker) StartExecInstance(execInstanceID string) (string, error)
 		return "", fmt.Errorf("json marshalling issue when starting exec instance - %s", err)
 	}
 
-	httpResponse, err := s.HttpClient.Post(fmt.Sprintf("%s/exec/%s/start", s.DockerEndpoint, execInstanceID),
+	urlEndpoint := fmt.Sprintf("%s/exec/%s/start", s.DockerEndpoint, execInstanceID)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
 		map[string]string{"Content-Type": "application/json"},
 		string(jsonBodyRequest))
 
 	if err != nil {
 		return "", fmt.Errorf("there was an issue with HTTP client when performing POST on "+
-			"%s/exec/%s/start - %s", s.DockerEndpoint, execInstanceID, err)
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
 	case 200:
 		return string(httpResponse.Body), nil
 	case 404:
-		return "", ErrExecInstanceDoesNotExist
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrExecInstanceDoesNotExist)
 	case 409:
-		return "", ErrContainerIsStopped
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerIsStopped)
 	default:
-		return "", ErrDockerInternalServerError
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-/* StopContainer stops a container given a container ID.
-Returns true if the container is stopped and false is the container was already stopped */
+/* StartExecInstanceWithWriters start an exec instance on a docker daemon given an exec ID, streaming its output as it comes.
+tty must match the Tty setting the exec instance was generated with: if set, the whole output is written to stdout,
+otherwise stdout and stderr are demultiplexed into their own writers (any of them can be nil to discard it) */
+func (s *SimpleDocker) StartExecInstanceWithWriters(ctx context.Context, execInstanceID string, tty bool, stdout io.Writer, stderr io.Writer) error {
+	httpRequestBody := models.StartExecInstance{
+		Detach: false,
+		Tty:    tty,
+	}
+
+	jsonBodyRequest, err := json.Marshal(httpRequestBody)
+	if err != nil {
+		return fmt.Errorf("json marshalling issue when starting exec instance - %s", err)
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/exec/%s/start", s.DockerEndpoint, execInstanceID)
+	httpResponse, err := s.HttpClient.Stream(ctx, "POST", urlEndpoint,
+		map[string]string{"Content-Type": "application/json"},
+		bytes.NewReader(jsonBodyRequest))
+
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+		return copyOutput(httpResponse.Body, tty, stdout, stderr)
+	case 404:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrExecInstanceDoesNotExist)
+	case 409:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrContainerIsStopped)
+	default:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// InspectExecInstance returns low-level information about an exec instance given an exec ID, including its exit code
+func (s *SimpleDocker) InspectExecInstance(ctx context.Context, execInstanceID string) (*models.InspectExecResponseBody, error) {
+	urlEndpoint := fmt.Sprintf("%s/exec/%s/json", s.DockerEndpoint, execInstanceID)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.InspectExecResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when inspecting exec instance - %s", err)
+		}
+		return &responseBody, nil
+	case 404:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrExecInstanceDoesNotExist)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* RunCommand runs a command inside a running container given a container ID and the exec options, and waits for it to finish.
+It returns the output of the command alongside its exit code. A non zero exit code is not considered an error */
+func (s *SimpleDocker) RunCommand(ctx context.Context, containerID string, options ExecOptions) (*ExecResult, error) {
+	execID, err := s.GenerateExecInstanceWithOptions(ctx, containerID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = s.StartExecInstanceWithWriters(ctx, execID, options.Tty, &stdout, &stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	execInstance, err := WaitForExecInstance(ctx, s, execID)
+	if err != nil {
+		return nil, err
+	}
+	return &ExecResult{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: execInstance.ExitCode}, nil
+}
+
+/* Stats returns a single sample of the resource usage (CPU, memory, network and block I/O) of a container given a container ID.
+The daemon takes around a second to answer, since the CPU usage is computed between two samples */
+func (s *SimpleDocker) Stats(ctx context.Context, containerID string) (*ContainerStats, error) {
+	var stats *ContainerStats
+	err := s.stats(ctx, containerID, false, func(sample ContainerStats) {
+		stats = &sample
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("the docker daemon sent no stats for container %s", containerID)
+	}
+	return stats, nil
+}
+
+/* StreamStats streams the resource usage of a container given a container ID, calling onStats for every sample
+the daemon sends (around once a second). It blocks until ctx is done or the daemon ends the stream (e.g. the container is removed) */
+func (s *SimpleDocker) StreamStats(ctx context.Context, containerID string, onStats StatsFunc) error {
+	err := s.stats(ctx, containerID, true, onStats)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err() // Cancelling the stream is the way to stop it, so the read error is not relevant
+	}
+	return err
+}
+
+// stats queries /containers/{id}/stats, calling onStats for every sample received
+func (s *SimpleDocker) stats(ctx context.Context, containerID string, stream bool, onStats StatsFunc) error {
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/stats?stream=%t", s.DockerEndpoint, containerID, stream)
+	httpResponse, err := s.HttpClient.Stream(ctx, "GET", urlEndpoint, nil, nil)
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+
+		decoder := json.NewDecoder(httpResponse.Body)
+		for {
+			var sample models.StatsResponseBody
+			err := decoder.Decode(&sample)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("json unmarshalling issue when reading container stats - %w", err)
+			}
+			onStats(calculateStats(&sample))
+		}
+	case 404:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	default:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// StopContainer is StopContainerContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) StopContainer(containerID string) (bool, error) {
-	httpResponse, err := s.HttpClient.Post(fmt.Sprintf("%s/containers/%s/stop", s.DockerEndpoint, containerID),
+	return s.StopContainerContext(context.Background(), containerID)
+}
+
+/* StopContainerContext stops a container given a container ID.
+Returns true if the container is stopped and false is the container was already stopped */
+func (s *SimpleDocker) StopContainerContext(ctx context.Context, containerID string) (bool, error) {
+	return s.StopContainerWithOptions(ctx, containerID, StopOptions{})
+}
+
+/* StopContainerWithOptions stops a container given a container ID and the stop options (grace period and signal).
+Returns true if the container is stopped and false is the container was already stopped */
+func (s *SimpleDocker) StopContainerWithOptions(ctx context.Context, containerID string, options StopOptions) (bool, error) {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s/stop", s.DockerEndpoint, containerID), options.query())
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
 		nil,
 		"")
 	if err != nil {
 		return false, fmt.Errorf("there was an issue with HTTP client when performing POST on "+
-			"%s/containers/%s/stop - %s", s.DockerEndpoint, containerID, err)
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
@@ -255,31 +603,113 @@ func (s *SimpleDocker) StopContainer(containerID string) (bool, error) {
 		return true, nil
 	case 304:
 		return false, nil
+	case 400:
+		return false, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
 	case 404:
-		return false, ErrContainerDoesNotExist
+		return false, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
 	default:
-		return false, ErrDockerInternalServerError
+		return false, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
 	}
 }
 
-// RemoveContainer removes a container given a container ID
+// RemoveContainer is RemoveContainerContext with a background context, which cannot be cancelled
 func (s *SimpleDocker) RemoveContainer(containerID string) error {
-	httpResponse, err := s.HttpClient.Delete(fmt.Sprintf("%s/containers/%s", s.DockerEndpoint, containerID),
+	return s.RemoveContainerContext(context.Background(), containerID)
+}
+
+// RemoveContainerContext removes a container given a container ID
+func (s *SimpleDocker) RemoveContainerContext(ctx context.Context, containerID string) error {
+	return s.RemoveContainerWithOptions(ctx, containerID, RemoveContainerOptions{})
+}
+
+/* RemoveContainerWithOptions removes a container given a container ID and the remove options.
+It fails with ErrContainerIsRunning if the container is running, unless Force is set */
+func (s *SimpleDocker) RemoveContainerWithOptions(ctx context.Context, containerID string, options RemoveContainerOptions) error {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s", s.DockerEndpoint, containerID), options.query())
+	httpResponse, err := s.HttpClient.DeleteContext(ctx, urlEndpoint,
 		nil)
 
 	if err != nil {
 		return fmt.Errorf("there was an issue with HTTP client when performing DELETE on "+
-			"%s/containers/%s - %s", s.DockerEndpoint, containerID, err)
+			"%s - %w", urlEndpoint, err)
 	}
 
 	switch httpResponse.StatusCode {
 	case 204:
 		return nil
+	case 400:
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrBadParameter)
 	case 404:
-		return ErrContainerDoesNotExist
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
 	case 409:
-		return ErrContainerIsRunning
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrContainerIsRunning)
 	default:
-		return ErrDockerInternalServerError
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* PruneContainers removes every stopped container matching the filters given (they can be nil).
+Keys supported are until (timestamp or duration, e.g. 24h), label (key or key=value) and label! to keep the containers with the label.
+It returns the IDs of the containers removed alongside the disk space reclaimed */
+func (s *SimpleDocker) PruneContainers(ctx context.Context, filters Filters) (*models.PruneContainersResponseBody, error) {
+	query := url.Values{}
+	if len(filters) > 0 {
+		encoded, err := filters.encode()
+		if err != nil {
+			return nil, fmt.Errorf("json marshalling issue when pruning containers - %s", err)
+		}
+		query.Set("filters", encoded)
 	}
+
+	urlEndpoint := withQuery(s.DockerEndpoint+"/containers/prune", query)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.PruneContainersResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when pruning containers - %s", err)
+		}
+		return &responseBody, nil
+	case 400:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// withQuery appends the query parameters given to urlEndpoint, if any
+func withQuery(urlEndpoint string, query url.Values) string {
+	if len(query) == 0 {
+		return urlEndpoint
+	}
+	return urlEndpoint + "?" + query.Encode()
+}
+
+/* copyOutput copies the output stream of a process (exec, logs) into stdout and stderr.
+Raw TTY streams go to stdout as they are, while multiplexed streams are split. Nil writers discard their stream */
+func copyOutput(stream io.Reader, tty bool, stdout io.Writer, stderr io.Writer) error {
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	if tty {
+		if _, err := io.Copy(stdout, stream); err != nil {
+			return fmt.Errorf("cannot copy output stream - %w", err)
+		}
+		return nil
+	}
+
+	_, err := StdCopy(stdout, stderr, stream)
+	return err
 }
@@ -0,0 +1,115 @@
+package dockerclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// pathStatHeader is the header the daemon describes the path of an archive query in
+const pathStatHeader = "X-Docker-Container-Path-Stat"
+
+/* StatContainerPath returns information about a file or directory inside a container given a container ID and the path
+(relative paths start at the working directory of the container). Symbolic links are not followed */
+func (s *SimpleDocker) StatContainerPath(ctx context.Context, containerID string, containerPath string) (*models.ContainerPathStat, error) {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s/archive", s.DockerEndpoint, containerID), url.Values{"path": {containerPath}})
+	httpResponse, err := s.HttpClient.Head(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing HEAD on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		return decodePathStat(httpResponse.Header.Get(pathStatHeader))
+	case 400:
+		return nil, newDockerAPIError("HEAD", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return nil, s.archiveNotFoundError(ctx, containerID, newDockerAPIError("HEAD", urlEndpoint, httpResponse, ErrContainerPathDoesNotExist))
+	default:
+		return nil, newDockerAPIError("HEAD", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* CopyFromContainer writes to output a tar archive of a file or directory inside a container given a container ID and the path.
+The archive is rooted at the base name of the path and is streamed, so it is never held in memory. ExtractArchive unpacks it.
+It returns information about the path copied */
+func (s *SimpleDocker) CopyFromContainer(ctx context.Context, containerID string, srcPath string, output io.Writer) (*models.ContainerPathStat, error) {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s/archive", s.DockerEndpoint, containerID), url.Values{"path": {srcPath}})
+	httpResponse, err := s.HttpClient.Stream(ctx, "GET", urlEndpoint, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+		stat, err := decodePathStat(httpResponse.Header.Get(pathStatHeader))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(output, httpResponse.Body); err != nil {
+			return nil, fmt.Errorf("cannot write the archive of %s - %w", srcPath, err)
+		}
+		return stat, nil
+	case 400:
+		return nil, newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return nil, s.archiveNotFoundError(ctx, containerID, newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrContainerPathDoesNotExist))
+	default:
+		return nil, newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* CopyToContainer extracts a tar archive (such as the ones made by ArchivePath) read from archive as it is sent, into
+a directory of a container given a container ID, the directory path and the copy options. The directory must exist */
+func (s *SimpleDocker) CopyToContainer(ctx context.Context, containerID string, dstPath string, archive io.Reader, options CopyToContainerOptions) error {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s/archive", s.DockerEndpoint, containerID), options.query(dstPath))
+	httpResponse, err := s.HttpClient.Put(ctx, urlEndpoint,
+		map[string]string{"Content-Type": "application/x-tar"},
+		archive)
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing PUT on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		return nil
+	case 400:
+		return newDockerAPIError("PUT", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return s.archiveNotFoundError(ctx, containerID, newDockerAPIError("PUT", urlEndpoint, httpResponse, ErrContainerPathDoesNotExist))
+	default:
+		return newDockerAPIError("PUT", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// decodePathStat decodes the base64-encoded JSON the daemon describes a path of a container with
+func decodePathStat(header string) (*models.ContainerPathStat, error) {
+	decoded, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode the %s header - %s", pathStatHeader, err)
+	}
+	var stat models.ContainerPathStat
+	if err := json.Unmarshal(decoded, &stat); err != nil {
+		return nil, fmt.Errorf("json unmarshalling issue when reading container path stat - %s", err)
+	}
+	return &stat, nil
+}
+
+/* archiveNotFoundError tells apart the two reasons the archive endpoints answer 404 with: a missing container or a missing
+path (HEAD responses carry no message to tell them apart). apiErr reports the path missing, and is changed if the container is */
+func (s *SimpleDocker) archiveNotFoundError(ctx context.Context, containerID string, apiErr *DockerAPIError) error {
+	if _, err := s.InspectContainer(ctx, containerID); errors.Is(err, ErrContainerDoesNotExist) {
+		apiErr.Err = ErrContainerDoesNotExist
+	}
+	return apiErr
+}
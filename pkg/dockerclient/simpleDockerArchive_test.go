@@ -0,0 +1,159 @@
+package dockerclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSimpleDocker_StatContainerPath(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Create a container to look into
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntustat", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveContainerContext(context.Background(), containerID)
+
+	tests := []struct {
+		name        string
+		containerID string
+		path        string
+		wantName    string
+		wantDir     bool
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Stat a file",
+			containerID: containerID,
+			path:        "/etc/os-release",
+			wantName:    "os-release",
+			wantDir:     false,
+			wantErr:     false,
+		},
+		{
+			name:        "Stat a directory",
+			containerID: containerID,
+			path:        "/tmp",
+			wantName:    "tmp",
+			wantDir:     true,
+			wantErr:     false,
+		},
+		{
+			name:        "Stat a non existing path",
+			containerID: containerID,
+			path:        "/fakepath",
+			wantErr:     true,
+			wantErrIs:   ErrContainerPathDoesNotExist,
+		},
+		{
+			name:        "Stat a path of a non existing container",
+			containerID: "fakecontainer",
+			path:        "/tmp",
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.StatContainerPath(context.Background(), tt.containerID, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.StatContainerPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("SimpleDocker.StatContainerPath() error = %v, wantErrIs %v", err, tt.wantErrIs)
+				return
+			}
+			if err == nil && (got.Name != tt.wantName || got.Mode.IsDir() != tt.wantDir) {
+				t.Errorf("SimpleDocker.StatContainerPath() = %+v, want %s (directory %v)", got, tt.wantName, tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_CopyToFromContainer(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Create a container to copy files into
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntucopy", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveContainerContext(context.Background(), containerID)
+
+	// Copy a local directory with an executable script into /tmp
+	srcDir := filepath.Join(t.TempDir(), "scripts")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "hello.sh"), []byte("echo hello\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := ArchivePath(srcDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if err := dockerClient.CopyToContainer(context.Background(), containerID, "/tmp", archive, CopyToContainerOptions{}); err != nil {
+		t.Fatalf("SimpleDocker.CopyToContainer() error = %v", err)
+	}
+
+	// Copy it back out and extract it somewhere else
+	var copied bytes.Buffer
+	stat, err := dockerClient.CopyFromContainer(context.Background(), containerID, "/tmp/scripts", &copied)
+	if err != nil {
+		t.Fatalf("SimpleDocker.CopyFromContainer() error = %v", err)
+	}
+	if stat.Name != "scripts" || !stat.Mode.IsDir() {
+		t.Errorf("SimpleDocker.CopyFromContainer() stat = %+v, want the scripts directory", stat)
+	}
+	dstDir := t.TempDir()
+	if err := ExtractArchive(&copied, dstDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dstDir, "scripts", "hello.sh"))
+	if err != nil || string(content) != "echo hello\n" {
+		t.Errorf("hello.sh copied back = %q, %v, want %q", content, err, "echo hello\n")
+	}
+	if info, err := os.Stat(filepath.Join(dstDir, "scripts", "hello.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("hello.sh copied back = %v, %v, want mode %v", info, err, os.FileMode(0755))
+	}
+
+	_, err = dockerClient.CopyFromContainer(context.Background(), containerID, "/fakepath", io.Discard)
+	if !errors.Is(err, ErrContainerPathDoesNotExist) {
+		t.Errorf("SimpleDocker.CopyFromContainer() error = %v, want %v", err, ErrContainerPathDoesNotExist)
+	}
+
+	// The destination must be an existing directory
+	archive, err = ArchivePath(srcDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	err = dockerClient.CopyToContainer(context.Background(), containerID, "/etc/os-release", archive, CopyToContainerOptions{})
+	if !errors.Is(err, ErrBadParameter) {
+		t.Errorf("SimpleDocker.CopyToContainer() error = %v, want %v", err, ErrBadParameter)
+	}
+
+	err = dockerClient.CopyToContainer(context.Background(), "fakecontainer", "/tmp", bytes.NewReader(nil), CopyToContainerOptions{})
+	if !errors.Is(err, ErrContainerDoesNotExist) {
+		t.Errorf("SimpleDocker.CopyToContainer() error = %v, want %v", err, ErrContainerDoesNotExist)
+	}
+}
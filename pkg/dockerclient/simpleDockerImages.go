@@ -0,0 +1,293 @@
+package dockerclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+/* ListImages lists the local images given the list options.
+It returns a summary of every image, most recently created first */
+func (s *SimpleDocker) ListImages(ctx context.Context, options ListImagesOptions) ([]models.ImageSummary, error) {
+	query, err := options.query()
+	if err != nil {
+		return nil, fmt.Errorf("json marshalling issue when listing images - %s", err)
+	}
+
+	urlEndpoint := withQuery(s.DockerEndpoint+"/images/json", query)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var images []models.ImageSummary
+		err = json.Unmarshal(httpResponse.Body, &images)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when listing images - %s", err)
+		}
+		return images, nil
+	case 400:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* InspectImage returns low-level information about an image given its reference (name:tag) or ID:
+digests, size, architecture and the default configuration of its containers */
+func (s *SimpleDocker) InspectImage(ctx context.Context, image string) (*models.InspectImageResponseBody, error) {
+	urlEndpoint := fmt.Sprintf("%s/images/%s/json", s.DockerEndpoint, image)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.InspectImageResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when inspecting image - %s", err)
+		}
+		return &responseBody, nil
+	case 404:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* TagImage adds a reference to an image given its reference or ID, and the repository and tag of the new reference
+(latest if empty). The new reference is moved out of the image holding it, if any */
+func (s *SimpleDocker) TagImage(ctx context.Context, image string, repository string, tag string) error {
+	query := url.Values{}
+	query.Set("repo", repository)
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+
+	urlEndpoint := withQuery(fmt.Sprintf("%s/images/%s/tag", s.DockerEndpoint, image), query)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 201:
+		return nil
+	case 400:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+	case 409:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrImageConflict)
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* RemoveImage removes an image given its reference or ID, and the remove options. Removing one of the references of
+an image that has several ones only untags it. It returns the references untagged and the images deleted */
+func (s *SimpleDocker) RemoveImage(ctx context.Context, image string, options RemoveImageOptions) ([]models.ImageDeleteResponseItem, error) {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/images/%s", s.DockerEndpoint, image), options.query())
+	httpResponse, err := s.HttpClient.DeleteContext(ctx, urlEndpoint,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing DELETE on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var items []models.ImageDeleteResponseItem
+		err = json.Unmarshal(httpResponse.Body, &items)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when removing image - %s", err)
+		}
+		return items, nil
+	case 404:
+		return nil, newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+	case 409:
+		return nil, newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrImageConflict)
+	default:
+		return nil, newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// ImageHistory returns the layers of an image given its reference or ID, most recent first
+func (s *SimpleDocker) ImageHistory(ctx context.Context, image string) ([]models.ImageHistoryItem, error) {
+	urlEndpoint := fmt.Sprintf("%s/images/%s/history", s.DockerEndpoint, image)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var history []models.ImageHistoryItem
+		err = json.Unmarshal(httpResponse.Body, &history)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when reading image history - %s", err)
+		}
+		return history, nil
+	case 404:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* PruneImages removes the dangling images no container uses, given the filters (they can be nil). Keys supported are
+dangling (false removes every unused image), until (timestamp or duration, e.g. 24h), label (key or key=value) and label!.
+It returns the references untagged and the images deleted alongside the disk space reclaimed */
+func (s *SimpleDocker) PruneImages(ctx context.Context, filters Filters) (*models.PruneImagesResponseBody, error) {
+	query := url.Values{}
+	if len(filters) > 0 {
+		encoded, err := filters.encode()
+		if err != nil {
+			return nil, fmt.Errorf("json marshalling issue when pruning images - %s", err)
+		}
+		query.Set("filters", encoded)
+	}
+
+	urlEndpoint := withQuery(s.DockerEndpoint+"/images/prune", query)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.PruneImagesResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when pruning images - %s", err)
+		}
+		return &responseBody, nil
+	case 400:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* PushImage pushes an image to its registry given its repository, tag (every tag of the repository if empty) and the
+credentials of the registry (nil to push anonymously). progress is called for every progress message sent by the daemon
+(it can be nil). Errors reported in the middle of the push, credentials rejected included, are returned as ErrImagePushFailed */
+func (s *SimpleDocker) PushImage(ctx context.Context, repository string, tag string, auth *models.AuthConfig, progress ProgressFunc) error {
+	// The daemon expects the header even when pushing anonymously
+	if auth == nil {
+		auth = &models.AuthConfig{}
+	}
+	headers, err := registryAuthHeaders(auth)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	urlEndpoint := withQuery(fmt.Sprintf("%s/images/%s/push", s.DockerEndpoint, repository), query)
+	httpResponse, err := s.HttpClient.Stream(ctx, "POST", urlEndpoint,
+		headers,
+		nil) // No body needed
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST "+
+			"on %s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+
+		// As with pulls, the daemon answers 200 as soon as the push starts and errors come later on in the stream
+		daemonError, err := readJSONMessages(httpResponse.Body, progress)
+		if err != nil {
+			return err
+		}
+		if daemonError != "" {
+			return &DockerAPIError{StatusCode: httpResponse.StatusCode, Method: "POST", Endpoint: urlEndpoint,
+				Message: daemonError, Err: ErrImagePushFailed}
+		}
+		return nil
+	case 401:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrRegistryUnauthorized)
+	case 404:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+	default:
+		return newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* BuildImage builds an image given the directory holding the build context and the build options. The directory is sent
+as a tar archive, leaving out the paths its .dockerignore file excludes. progress is called for every message of the build
+output (it can be nil). Errors reported in the middle of the build (e.g. a failing RUN step) are returned as ErrImageBuildFailed.
+It returns the ID of the image built */
+func (s *SimpleDocker) BuildImage(ctx context.Context, contextDir string, options BuildOptions, progress ProgressFunc) (string, error) {
+	query, err := options.query()
+	if err != nil {
+		return "", fmt.Errorf("json marshalling issue when building image - %s", err)
+	}
+	dockerfile := options.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	buildContext, err := archiveBuildContext(contextDir, dockerfile)
+	if err != nil {
+		return "", err
+	}
+	defer buildContext.Close()
+
+	urlEndpoint := withQuery(s.DockerEndpoint+"/build", query)
+	httpResponse, err := s.HttpClient.Stream(ctx, "POST", urlEndpoint,
+		map[string]string{"Content-Type": "application/x-tar"},
+		buildContext)
+	if err != nil {
+		return "", fmt.Errorf("there was an issue with HTTP client when performing POST "+
+			"on %s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+
+		// The ID of the image built comes as auxiliary data of the stream
+		var imageID string
+		daemonError, err := readJSONMessages(httpResponse.Body, func(message models.JSONMessage) {
+			var aux struct{ ID string }
+			if len(message.Aux) > 0 && json.Unmarshal(message.Aux, &aux) == nil && aux.ID != "" {
+				imageID = aux.ID
+			}
+			if progress != nil {
+				progress(message)
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+		if daemonError != "" {
+			return "", &DockerAPIError{StatusCode: httpResponse.StatusCode, Method: "POST", Endpoint: urlEndpoint,
+				Message: daemonError, Err: ErrImageBuildFailed}
+		}
+		return imageID, nil
+	case 400:
+		return "", newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return "", newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
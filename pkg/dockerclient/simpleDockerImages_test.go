@@ -0,0 +1,532 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+func TestSimpleDocker_ListImages(t *testing.T) {
+	// The images listed depend on the daemon, so it only runs against the fake one
+	dockerClient, server := newFakeDockerClient(t)
+
+	oldID := server.AddImage("myapp", "1.0")
+	newID := server.AddImage("myapp", "2.0")
+	otherID := server.AddImage("other", "latest")
+	server.SetImageLabels(otherID, map[string]string{"team": "infra"})
+
+	// Moving the 1.0 tag to the newest image leaves the oldest one dangling
+	if err := dockerClient.TagImage(context.Background(), newID, "myapp", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		options   ListImagesOptions
+		want      []string
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name:    "List every image",
+			options: ListImagesOptions{},
+			want:    []string{otherID, newID, oldID},
+		},
+		{
+			name:    "List dangling images",
+			options: ListImagesOptions{Filters: Filters{"dangling": {"true"}}},
+			want:    []string{oldID},
+		},
+		{
+			name:    "List images by reference",
+			options: ListImagesOptions{Filters: Filters{"reference": {"myapp"}}},
+			want:    []string{newID},
+		},
+		{
+			name:    "List images by label",
+			options: ListImagesOptions{Filters: Filters{"label": {"team=infra"}}},
+			want:    []string{otherID},
+		},
+		{
+			name:      "List images with an invalid filter",
+			options:   ListImagesOptions{Filters: Filters{"status": {"running"}}},
+			wantErr:   true,
+			wantErrIs: ErrBadParameter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images, err := dockerClient.ListImages(context.Background(), tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.ListImages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got []string
+			for _, image := range images {
+				got = append(got, image.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SimpleDocker.ListImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_InspectImage(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to inspect
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		image   string
+		wantErr bool
+	}{
+		{
+			name:    "Inspect an existing image",
+			image:   "ubuntu:20.04",
+			wantErr: false,
+		},
+		{
+			name:    "Inspect a non existing image",
+			image:   "fakeimage:fake",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.InspectImage(context.Background(), tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.InspectImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrImageDoesNotExist) {
+					t.Errorf("SimpleDocker.InspectImage() error = %v, want %v", err, ErrImageDoesNotExist)
+				}
+				return
+			}
+			if got.ID == "" || got.Architecture == "" || got.Os == "" || len(got.RepoDigests) == 0 || len(got.RootFS.Layers) == 0 {
+				t.Errorf("SimpleDocker.InspectImage() = %+v, want ID, architecture, OS, digests and layers", got)
+			}
+			found := false
+			for _, tag := range got.RepoTags {
+				found = found || tag == tt.image
+			}
+			if !found {
+				t.Errorf("SimpleDocker.InspectImage() RepoTags = %v, want %s among them", got.RepoTags, tt.image)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_TagImage(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to tag
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		image      string
+		repository string
+		tag        string
+		wantErr    bool
+		wantErrIs  error
+	}{
+		{
+			name:       "Tag an existing image",
+			image:      "ubuntu:20.04",
+			repository: "dockermanager/tagged",
+			tag:        "v1",
+			wantErr:    false,
+		},
+		{
+			name:       "Tag an image with an uppercase repository",
+			image:      "ubuntu:20.04",
+			repository: "DockerManager",
+			tag:        "v1",
+			wantErr:    true,
+			wantErrIs:  ErrBadParameter,
+		},
+		{
+			name:       "Tag a non existing image",
+			image:      "fakeimage:fake",
+			repository: "dockermanager/tagged",
+			tag:        "v2",
+			wantErr:    true,
+			wantErrIs:  ErrImageDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dockerClient.TagImage(context.Background(), tt.image, tt.repository, tt.tag)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.TagImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Removing the new reference only untags the image, since it is still referenced as ubuntu:20.04
+	got, err := dockerClient.RemoveImage(context.Background(), "dockermanager/tagged:v1", RemoveImageOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ImageDeleteResponseItem{{Untagged: "dockermanager/tagged:v1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SimpleDocker.RemoveImage() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSimpleDocker_RemoveImage(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image and tag it, so it can be removed without removing ubuntu:20.04
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dockerClient.TagImage(context.Background(), "ubuntu:20.04", "dockermanager/removed", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A container using the image prevents its removal
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubunturmi", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveContainerContext(context.Background(), containerID)
+
+	tests := []struct {
+		name      string
+		image     string
+		options   RemoveImageOptions
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name:    "Remove one of the references of an image",
+			image:   "dockermanager/removed:v1",
+			options: RemoveImageOptions{},
+			wantErr: false,
+		},
+		{
+			name:      "Remove an image used by a container",
+			image:     "ubuntu:20.04",
+			options:   RemoveImageOptions{NoPrune: true},
+			wantErr:   true,
+			wantErrIs: ErrImageConflict,
+		},
+		{
+			name:      "Remove a non existing image",
+			image:     "fakeimage:fake",
+			options:   RemoveImageOptions{Force: true},
+			wantErr:   true,
+			wantErrIs: ErrImageDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dockerClient.RemoveImage(context.Background(), tt.image, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.RemoveImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_ImageHistory(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to read the history of
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := dockerClient.InspectImage(context.Background(), "ubuntu:20.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := dockerClient.ImageHistory(context.Background(), "ubuntu:20.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) == 0 || history[0].ID != image.ID {
+		t.Errorf("SimpleDocker.ImageHistory() = %+v, want the image %s first", history, image.ID)
+	}
+
+	_, err = dockerClient.ImageHistory(context.Background(), "fakeimage:fake")
+	if !errors.Is(err, ErrImageDoesNotExist) {
+		t.Errorf("SimpleDocker.ImageHistory() error = %v, want %v", err, ErrImageDoesNotExist)
+	}
+}
+
+func TestSimpleDocker_PruneImages(t *testing.T) {
+	// Pruning removes every unused image of the daemon, so it only runs against the fake one
+	dockerClient, server := newFakeDockerClient(t)
+
+	danglingID := server.AddImage("myapp", "1.0")
+	usedID := server.AddImage("myapp", "2.0")
+	unusedID := server.AddImage("other", "latest")
+
+	// Moving the 1.0 tag leaves the image dangling
+	if err := dockerClient.TagImage(context.Background(), usedID, "myapp", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dockerClient.CreateContainerWithOptions(context.Background(), "myapp", ContainerOptions{Image: "myapp:2.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		filters       Filters
+		wantDeleted   []string
+		wantReclaimed uint64
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name:      "Prune with an invalid filter",
+			filters:   Filters{"reference": {"myapp"}},
+			wantErr:   true,
+			wantErrIs: ErrBadParameter,
+		},
+		{
+			name:          "Prune dangling images",
+			filters:       nil,
+			wantDeleted:   []string{danglingID},
+			wantReclaimed: 1024,
+		},
+		{
+			name:          "Prune every unused image",
+			filters:       Filters{"dangling": {"false"}},
+			wantDeleted:   []string{unusedID},
+			wantReclaimed: 1024,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.PruneImages(context.Background(), tt.filters)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.PruneImages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			// Layers are reported as deleted alongside the images, so only the images expected are looked for
+			deleted := make(map[string]bool)
+			for _, item := range got.ImagesDeleted {
+				deleted[item.Deleted] = true
+			}
+			for _, id := range tt.wantDeleted {
+				if !deleted[id] {
+					t.Errorf("SimpleDocker.PruneImages() = %+v, want %s deleted", got.ImagesDeleted, id)
+				}
+			}
+			if got.SpaceReclaimed != tt.wantReclaimed {
+				t.Errorf("SimpleDocker.PruneImages() SpaceReclaimed = %d, want %d", got.SpaceReclaimed, tt.wantReclaimed)
+			}
+		})
+	}
+
+	// The image used by a container must have been kept
+	if _, err := dockerClient.InspectImage(context.Background(), usedID); err != nil {
+		t.Errorf("SimpleDocker.InspectImage() error = %v, want the used image kept", err)
+	}
+}
+
+func TestSimpleDocker_PushImage(t *testing.T) {
+	// Pushing needs a registry the tests can write to, so it only runs against the fake daemon
+	dockerClient, server := newFakeDockerClient(t)
+	server.AddImage("registry.example.com/team/app", "1.0")
+	server.SetRegistryCredentials("registry.example.com", "user", "secret")
+
+	tests := []struct {
+		name       string
+		repository string
+		tag        string
+		auth       *models.AuthConfig
+		wantPushed bool // This flag tells if a "Pushed" message must be received
+		wantErr    bool
+		wantErrIs  error
+	}{
+		{
+			name:       "Push without credentials",
+			repository: "registry.example.com/team/app",
+			tag:        "1.0",
+			auth:       nil,
+			wantErr:    true,
+			wantErrIs:  ErrImagePushFailed,
+		},
+		{
+			name:       "Push with the right credentials",
+			repository: "registry.example.com/team/app",
+			tag:        "1.0",
+			auth:       &models.AuthConfig{Username: "user", Password: "secret"},
+			wantPushed: true,
+		},
+		{
+			name:       "Push a non existing image",
+			repository: "registry.example.com/team/app",
+			tag:        "2.0",
+			auth:       &models.AuthConfig{Username: "user", Password: "secret"},
+			wantErr:    true,
+			wantErrIs:  ErrImageDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pushed bool
+			err := dockerClient.PushImage(context.Background(), tt.repository, tt.tag, tt.auth,
+				func(message models.JSONMessage) {
+					pushed = pushed || message.Status == "Pushed"
+				})
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.PushImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if pushed != tt.wantPushed {
+				t.Errorf("SimpleDocker.PushImage() pushed = %v, want %v", pushed, tt.wantPushed)
+			}
+		})
+	}
+
+	// The image pushed gets a digest and can be pulled back once removed
+	image, err := dockerClient.InspectImage(context.Background(), "registry.example.com/team/app:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(image.RepoDigests) != 1 {
+		t.Errorf("SimpleDocker.InspectImage() RepoDigests = %v, want the digest of the push", image.RepoDigests)
+	}
+	if _, err := dockerClient.RemoveImage(context.Background(), "registry.example.com/team/app:1.0", RemoveImageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	err = dockerClient.PullImageWithAuth(context.Background(), "registry.example.com/team/app", "1.0", "x86-64",
+		&models.AuthConfig{Username: "user", Password: "secret"}, nil)
+	if err != nil {
+		t.Errorf("SimpleDocker.PullImageWithAuth() error = %v, want the pushed image pulled", err)
+	}
+}
+
+func TestSimpleDocker_BuildImage(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download the base image of the builds
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":         "FROM ubuntu:20.04 AS base\nARG GREETING=hello\nLABEL stage=base\nRUN echo $GREETING\nCOPY app.txt /app/\n\nFROM base\nCMD [\"cat\", \"/app/app.txt\"]\n",
+		"failing.Dockerfile": "FROM ubuntu:20.04\nRUN false\n",
+		"ignored.Dockerfile": "FROM ubuntu:20.04\nCOPY secret.txt /\n",
+		".dockerignore":      "secret.txt\n",
+		"app.txt":            "app\n",
+		"secret.txt":         "secret\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		options   BuildOptions
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "Build an image with tag, build args and labels",
+			options: BuildOptions{Tags: []string{"dockermanager/built:v1"}, BuildArgs: map[string]string{"GREETING": "hi"},
+				Labels: map[string]string{"team": "infra"}, NoCache: true},
+			wantErr: false,
+		},
+		{
+			name:    "Build the target stage of a multi-stage Dockerfile",
+			options: BuildOptions{Target: "base"},
+			wantErr: false,
+		},
+		{
+			name:      "Build a non existing target stage",
+			options:   BuildOptions{Target: "fake"},
+			wantErr:   true,
+			wantErrIs: ErrImageBuildFailed,
+		},
+		{
+			name:      "Build a Dockerfile with a failing step",
+			options:   BuildOptions{Dockerfile: "failing.Dockerfile"},
+			wantErr:   true,
+			wantErrIs: ErrImageBuildFailed,
+		},
+		{
+			name:      "Build a Dockerfile copying a file excluded by .dockerignore",
+			options:   BuildOptions{Dockerfile: "ignored.Dockerfile"},
+			wantErr:   true,
+			wantErrIs: ErrImageBuildFailed,
+		},
+		{
+			name:      "Build a non existing Dockerfile",
+			options:   BuildOptions{Dockerfile: "missing.Dockerfile"},
+			wantErr:   true,
+			wantErrIs: ErrDockerInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output strings.Builder
+			imageID, err := dockerClient.BuildImage(context.Background(), dir, tt.options, func(message models.JSONMessage) {
+				output.WriteString(message.Stream)
+			})
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.BuildImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if imageID == "" || !strings.Contains(output.String(), "Step 1/") {
+				t.Errorf("SimpleDocker.BuildImage() = %q with output %q, want an image ID and the build steps", imageID, output.String())
+			}
+			defer dockerClient.RemoveImage(context.Background(), imageID, RemoveImageOptions{Force: true})
+
+			image, err := dockerClient.InspectImage(context.Background(), imageID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range tt.options.Labels {
+				if image.Config.Labels[key] != value {
+					t.Errorf("SimpleDocker.InspectImage() Labels = %v, want %s=%s", image.Config.Labels, key, value)
+				}
+			}
+			if len(tt.options.Tags) > 0 && !reflect.DeepEqual(image.RepoTags, tt.options.Tags) {
+				t.Errorf("SimpleDocker.InspectImage() RepoTags = %v, want %v", image.RepoTags, tt.options.Tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package dockerclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+	"github.com/mikeletux/go-docker-manager/pkg/httpclient"
+)
+
+/* HijackedConn is a bidirectional stream with a process running inside a container.
+Writes go to the process stdin, and reads return its output (raw if a TTY is allocated, multiplexed otherwise) */
+type HijackedConn interface {
+	io.ReadWriteCloser
+
+	// CloseWrite closes the process stdin, while output can still be read
+	CloseWrite() error
+}
+
+/* StartInteractiveExecInstance starts an exec instance on a docker daemon given an exec ID, taking over the connection
+so stdin can be written to while the output is read. tty must match the Tty setting the exec instance was generated with.
+The connection is closed when ctx is done. It returns the connection, which must be closed */
+func (s *SimpleDocker) StartInteractiveExecInstance(ctx context.Context, execInstanceID string, tty bool) (HijackedConn, error) {
+	httpRequestBody := models.StartExecInstance{
+		Detach: false,
+		Tty:    tty,
+	}
+
+	jsonBodyRequest, err := json.Marshal(httpRequestBody)
+	if err != nil {
+		return nil, fmt.Errorf("json marshalling issue when starting exec instance - %s", err)
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/exec/%s/start", s.DockerEndpoint, execInstanceID)
+	hijackedResponse, err := s.HttpClient.Hijack(ctx, "POST", urlEndpoint,
+		map[string]string{"Content-Type": "application/json"},
+		string(jsonBodyRequest))
+
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	if hijackedResponse.Upgraded() {
+		return hijackedResponse, nil
+	}
+
+	httpResponse := &httpclient.HttpResponse{StatusCode: hijackedResponse.StatusCode, Body: hijackedResponse.Body}
+	switch httpResponse.StatusCode {
+	case 404:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrExecInstanceDoesNotExist)
+	case 409:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerIsStopped)
+	default:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// ResizeExecInstance resizes the TTY of an exec instance given an exec ID and the new height and width in characters
+func (s *SimpleDocker) ResizeExecInstance(ctx context.Context, execInstanceID string, height uint, width uint) error {
+	urlEndpoint := fmt.Sprintf("%s/exec/%s/resize?h=%d&w=%d", s.DockerEndpoint, execInstanceID, height, width)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint, nil, "")
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200, 201:
+		return nil
+	case 404:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrExecInstanceDoesNotExist)
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
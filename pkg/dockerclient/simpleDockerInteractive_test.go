@@ -0,0 +1,144 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSimpleDocker_StartInteractiveExecInstance(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create an exec instance that echoes back its stdin
+	execID, err := dockerClient.GenerateExecInstanceWithOptions(context.Background(), containerID,
+		ExecOptions{Cmd: []string{"cat"}, AttachStdin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := dockerClient.StartInteractiveExecInstance(context.Background(), execID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "hello from stdin\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	if _, err := StdCopy(&stdout, &stderr, conn); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "hello from stdin\n" {
+		t.Errorf("SimpleDocker.StartInteractiveExecInstance() output = %q, want %q", stdout.String(), "hello from stdin\n")
+	}
+
+	// Start an exec instance that doesn't exist
+	_, err = dockerClient.StartInteractiveExecInstance(context.Background(), "fakefakefakefake", false)
+	if !errors.Is(err, ErrExecInstanceDoesNotExist) {
+		t.Errorf("SimpleDocker.StartInteractiveExecInstance() error = %v, want %v", err, ErrExecInstanceDoesNotExist)
+	}
+
+	// Stop container
+	_, err = dockerClient.StopContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_ResizeExecInstance(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create exec instance
+	execID, err := dockerClient.GenerateExecInstanceWithOptions(context.Background(), containerID,
+		ExecOptions{Cmd: []string{"sleep", "10"}, Tty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		execID  string
+		wantErr bool
+	}{
+		{
+			name:    "Resize an existing exec instance",
+			execID:  execID,
+			wantErr: false,
+		},
+		{
+			name:    "Resize a non existing exec instance",
+			execID:  "fakefakefakefake",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := dockerClient.ResizeExecInstance(context.Background(), tt.execID, 40, 120); (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.ResizeExecInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Stop container
+	_, err = dockerClient.StopContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package dockerclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// RestartContainer stops a container, if it is running, and starts it again given a container ID and the stop options
+func (s *SimpleDocker) RestartContainer(ctx context.Context, containerID string, options StopOptions) error {
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s/restart", s.DockerEndpoint, containerID), options.query())
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 204:
+		return nil
+	case 400:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* KillContainer sends a signal to a running container given a container ID and the signal (SIGKILL if empty).
+Signals can be given by name (e.g. SIGHUP) or by number */
+func (s *SimpleDocker) KillContainer(ctx context.Context, containerID string, signal string) error {
+	query := url.Values{}
+	if signal != "" {
+		query.Set("signal", signal)
+	}
+
+	urlEndpoint := withQuery(fmt.Sprintf("%s/containers/%s/kill", s.DockerEndpoint, containerID), query)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 204:
+		return nil
+	case 400:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	case 409:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerIsStopped)
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* PauseContainer suspends every process of a running container given a container ID.
+It fails with ErrContainerStateConflict if the container is not running or is already paused */
+func (s *SimpleDocker) PauseContainer(ctx context.Context, containerID string) error {
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/pause", s.DockerEndpoint, containerID)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 204:
+		return nil
+	case 404:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	case 409:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerStateConflict)
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+// UnpauseContainer resumes every process of a paused container given a container ID
+func (s *SimpleDocker) UnpauseContainer(ctx context.Context, containerID string) error {
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/unpause", s.DockerEndpoint, containerID)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 204:
+		return nil
+	case 404:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	case 409:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerIsNotPaused)
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
@@ -0,0 +1,355 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSimpleDocker_KillContainer(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntukill", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cases run in order, since killing the container changes its state
+	tests := []struct {
+		name        string
+		containerID string
+		signal      string
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Kill a container with an invalid signal",
+			containerID: containerID,
+			signal:      "SIGFAKE",
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+		{
+			name:        "Kill a running container",
+			containerID: containerID,
+			signal:      "SIGKILL",
+			wantErr:     false,
+		},
+		{
+			name:        "Kill a stopped container",
+			containerID: containerID,
+			signal:      "",
+			wantErr:     true,
+			wantErrIs:   ErrContainerIsStopped,
+		},
+		{
+			name:        "Kill a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			signal:      "",
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dockerClient.KillContainer(context.Background(), tt.containerID, tt.signal)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.KillContainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A container killed by SIGKILL exits with code 128+9
+	exitCode, err := dockerClient.WaitContainer(context.Background(), containerID, WaitConditionNotRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 137 {
+		t.Errorf("SimpleDocker.WaitContainer() = %v, want %v", exitCode, 137)
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_StopContainerWithOptions(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntustop", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := 1
+	tests := []struct {
+		name        string
+		containerID string
+		options     StopOptions
+		want        bool
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Stop a container with an invalid signal",
+			containerID: containerID,
+			options:     StopOptions{Signal: "SIGFAKE"},
+			want:        false,
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+		{
+			name:        "Stop a running container with a signal and a timeout",
+			containerID: containerID,
+			options:     StopOptions{Timeout: &timeout, Signal: "SIGKILL"},
+			want:        true,
+			wantErr:     false,
+		},
+		{
+			name:        "Stop a stopped container",
+			containerID: containerID,
+			options:     StopOptions{Timeout: &timeout},
+			want:        false,
+			wantErr:     false,
+		},
+		{
+			name:        "Stop a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			options:     StopOptions{},
+			want:        false,
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.StopContainerWithOptions(context.Background(), tt.containerID, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.StopContainerWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SimpleDocker.StopContainerWithOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_RestartContainer(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubunturestart", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := dockerClient.InspectContainer(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := 0
+	tests := []struct {
+		name        string
+		containerID string
+		options     StopOptions
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Restart a running container",
+			containerID: containerID,
+			options:     StopOptions{Timeout: &timeout},
+			wantErr:     false,
+		},
+		{
+			name:        "Restart a container with an invalid signal",
+			containerID: containerID,
+			options:     StopOptions{Signal: "SIGFAKE"},
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+		{
+			name:        "Restart a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			options:     StopOptions{},
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dockerClient.RestartContainer(context.Background(), tt.containerID, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.RestartContainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The container must be running again, started after the restart
+	after, err := dockerClient.InspectContainer(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.State.Running || after.State.StartedAt == before.State.StartedAt {
+		t.Errorf("SimpleDocker.RestartContainer() state = %+v, want running since %s", after.State, before.State.StartedAt)
+	}
+
+	// Stop container
+	_, err = dockerClient.StopContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_PauseContainer(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntupause", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pausing a container that is not running fails
+	err = dockerClient.PauseContainer(context.Background(), containerID)
+	if !errors.Is(err, ErrContainerStateConflict) {
+		t.Errorf("SimpleDocker.PauseContainer() error = %v, want %v", err, ErrContainerStateConflict)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cases run in order, since pausing and unpausing the container changes its state
+	tests := []struct {
+		name       string
+		operation  func(ctx context.Context, containerID string) error
+		wantStatus string
+		wantErrIs  error
+	}{
+		{
+			name:       "Pause a running container",
+			operation:  dockerClient.PauseContainer,
+			wantStatus: "paused",
+		},
+		{
+			name:       "Pause a paused container",
+			operation:  dockerClient.PauseContainer,
+			wantStatus: "paused",
+			wantErrIs:  ErrContainerStateConflict,
+		},
+		{
+			name:       "Unpause a paused container",
+			operation:  dockerClient.UnpauseContainer,
+			wantStatus: "running",
+		},
+		{
+			name:       "Unpause a running container",
+			operation:  dockerClient.UnpauseContainer,
+			wantStatus: "running",
+			wantErrIs:  ErrContainerIsNotPaused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.operation(context.Background(), containerID)
+			if (err != nil) != (tt.wantErrIs != nil) || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("operation error = %v, want %v", err, tt.wantErrIs)
+				return
+			}
+			inspect, err := dockerClient.InspectContainer(context.Background(), containerID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inspect.State.Status != tt.wantStatus {
+				t.Errorf("container status = %v, want %v", inspect.State.Status, tt.wantStatus)
+			}
+		})
+	}
+
+	// Pausing or unpausing a container that doesn't exist fails
+	if err := dockerClient.PauseContainer(context.Background(), "fakefakefakefake"); !errors.Is(err, ErrContainerDoesNotExist) {
+		t.Errorf("SimpleDocker.PauseContainer() error = %v, want %v", err, ErrContainerDoesNotExist)
+	}
+	if err := dockerClient.UnpauseContainer(context.Background(), "fakefakefakefake"); !errors.Is(err, ErrContainerDoesNotExist) {
+		t.Errorf("SimpleDocker.UnpauseContainer() error = %v, want %v", err, ErrContainerDoesNotExist)
+	}
+
+	// Stop container
+	_, err = dockerClient.StopContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
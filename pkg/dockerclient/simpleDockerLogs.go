@@ -0,0 +1,41 @@
+package dockerclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+/* ContainerLogs writes the logs of a container given a container ID and the logs options. If the container has a TTY
+the whole output is written to stdout, otherwise stdout and stderr are demultiplexed into their own writers (they can be nil).
+When following the logs, it blocks until the container stops or ctx is done */
+func (s *SimpleDocker) ContainerLogs(ctx context.Context, containerID string, options LogsOptions, stdout io.Writer, stderr io.Writer) error {
+	// Logs of containers with a TTY are sent raw, so the container must be inspected to know how to read them
+	container, err := s.InspectContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/logs?%s", s.DockerEndpoint, containerID, options.query().Encode())
+	httpResponse, err := s.HttpClient.Stream(ctx, "GET", urlEndpoint, nil, nil)
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+		err = copyOutput(httpResponse.Body, container.Config.Tty, stdout, stderr)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err() // Cancelling is the way to stop following the logs, so the read error is not relevant
+		}
+		return err
+	case 400:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	default:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
@@ -0,0 +1,172 @@
+package dockerclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimpleDocker_ContainerLogs(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a container that writes a line to stdout and exits
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntulogs", "ubuntu", "20.04", []string{"echo", "hello from logs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container, following its logs until it exits
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dockerClient.ContainerLogs(context.Background(), containerID, LogsOptions{Follow: true}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oneLine, noLines := 1, 0
+	tests := []struct {
+		name        string
+		containerID string
+		options     LogsOptions
+		wantStdout  string // wantStdout is a regular expression
+		wantErr     error
+	}{
+		{
+			name:        "Get every stream",
+			containerID: containerID,
+			options:     LogsOptions{},
+			wantStdout:  "^hello from logs\n$",
+		},
+		{
+			name:        "Get stderr only",
+			containerID: containerID,
+			options:     LogsOptions{Stderr: true},
+			wantStdout:  "^$",
+		},
+		{
+			name:        "Get the last line with timestamps",
+			containerID: containerID,
+			options:     LogsOptions{Stdout: true, Tail: &oneLine, Timestamps: true},
+			wantStdout:  `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{9}Z hello from logs\n$`,
+		},
+		{
+			name:        "Get no lines",
+			containerID: containerID,
+			options:     LogsOptions{Tail: &noLines},
+			wantStdout:  "^$",
+		},
+		{
+			name:        "Get logs written since a time to come",
+			containerID: containerID,
+			options:     LogsOptions{Since: time.Now().Add(time.Hour)},
+			wantStdout:  "^$",
+		},
+		{
+			name:        "Get the logs of a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			wantErr:     ErrContainerDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			err := dockerClient.ContainerLogs(context.Background(), tt.containerID, tt.options, &stdout, &stderr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SimpleDocker.ContainerLogs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !regexp.MustCompile(tt.wantStdout).MatchString(stdout.String()) {
+				t.Errorf("SimpleDocker.ContainerLogs() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != "" {
+				t.Errorf("SimpleDocker.ContainerLogs() stderr = %q, want nothing", stderr.String())
+			}
+		})
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_ContainerLogsFollow(t *testing.T) {
+	// Create Docker client. The fake daemon is used to write logs on demand
+	dockerClient, server := newFakeDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.AddLogs(containerID, "before following\n", "")
+
+	// Follow the logs while new lines are written
+	stdoutReader, stdoutWriter := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- dockerClient.ContainerLogs(context.Background(), containerID, LogsOptions{Follow: true}, stdoutWriter, nil)
+		stdoutWriter.Close()
+	}()
+
+	lines := bufio.NewScanner(stdoutReader)
+	for _, want := range []string{"before following", "while following"} {
+		if !lines.Scan() {
+			t.Fatalf("SimpleDocker.ContainerLogs() stopped before %q was received - %v", want, lines.Err())
+		}
+		if lines.Text() != want {
+			t.Errorf("SimpleDocker.ContainerLogs() line = %q, want %q", lines.Text(), want)
+		}
+		server.AddLogs(containerID, "while following\n", "")
+	}
+
+	// Following ends when the container stops
+	go io.Copy(io.Discard, stdoutReader)
+	_, err = dockerClient.StopContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("SimpleDocker.ContainerLogs() error = %v, want nil once the container stopped", err)
+	}
+
+	// Following ends as well when ctx is cancelled
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = dockerClient.ContainerLogs(ctx, containerID, LogsOptions{Follow: true}, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SimpleDocker.ContainerLogs() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
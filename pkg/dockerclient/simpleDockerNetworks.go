@@ -0,0 +1,244 @@
+package dockerclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+/* CreateNetwork creates a network given its name and the network options. Names must be unique.
+It returns the ID of the new network */
+func (s *SimpleDocker) CreateNetwork(ctx context.Context, name string, options CreateNetworkOptions) (string, error) {
+	jsonBodyRequest, err := json.Marshal(options.body(name))
+	if err != nil {
+		return "", fmt.Errorf("json marshall issue when creating network - %s", err)
+	}
+
+	urlEndpoint := s.DockerEndpoint + "/networks/create"
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		map[string]string{"Content-Type": "application/json"},
+		string(jsonBodyRequest))
+	if err != nil {
+		return "", fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 201:
+		var responseBody models.CreateNetworkResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return "", fmt.Errorf("json unmarshalling issue when creating network - %s", err)
+		}
+		return responseBody.ID, nil
+	case 400, 403, 404:
+		// 403 reports a subnet overlapping another network, and 404 a missing driver plugin
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 409:
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrNetworkAlreadyExist)
+	default:
+		return "", newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* ListNetworks lists the networks given the filters (they can be nil). Keys supported are name, id, driver,
+label (key or key=value), scope and type (custom or builtin) */
+func (s *SimpleDocker) ListNetworks(ctx context.Context, filters Filters) ([]models.NetworkResource, error) {
+	query := url.Values{}
+	if len(filters) > 0 {
+		encoded, err := filters.encode()
+		if err != nil {
+			return nil, fmt.Errorf("json marshalling issue when listing networks - %s", err)
+		}
+		query.Set("filters", encoded)
+	}
+
+	urlEndpoint := withQuery(s.DockerEndpoint+"/networks", query)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var networks []models.NetworkResource
+		err = json.Unmarshal(httpResponse.Body, &networks)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when listing networks - %s", err)
+		}
+		return networks, nil
+	case 400:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* InspectNetwork returns low-level information about a network given its name or ID:
+driver, address pools and the containers attached to it */
+func (s *SimpleDocker) InspectNetwork(ctx context.Context, network string) (*models.NetworkResource, error) {
+	urlEndpoint := fmt.Sprintf("%s/networks/%s", s.DockerEndpoint, network)
+	httpResponse, err := s.HttpClient.GetContext(ctx, urlEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.NetworkResource
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when inspecting network - %s", err)
+		}
+		return &responseBody, nil
+	case 404:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrNetworkDoesNotExist)
+	default:
+		return nil, newDockerAPIError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* RemoveNetwork removes a network given its name or ID. Networks with containers attached
+and the predefined networks (bridge, host and none) cannot be removed */
+func (s *SimpleDocker) RemoveNetwork(ctx context.Context, network string) error {
+	urlEndpoint := fmt.Sprintf("%s/networks/%s", s.DockerEndpoint, network)
+	httpResponse, err := s.HttpClient.DeleteContext(ctx, urlEndpoint,
+		nil)
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing DELETE on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 204:
+		return nil
+	case 403:
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrNetworkInUse)
+	case 404:
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrNetworkDoesNotExist)
+	default:
+		return newDockerAPIError("DELETE", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* PruneNetworks removes the user-defined networks no container is attached to, given the filters (they can be nil).
+Keys supported are until (timestamp or duration, e.g. 24h), label (key or key=value) and label!.
+It returns the names of the networks removed */
+func (s *SimpleDocker) PruneNetworks(ctx context.Context, filters Filters) ([]string, error) {
+	query := url.Values{}
+	if len(filters) > 0 {
+		encoded, err := filters.encode()
+		if err != nil {
+			return nil, fmt.Errorf("json marshalling issue when pruning networks - %s", err)
+		}
+		query.Set("filters", encoded)
+	}
+
+	urlEndpoint := withQuery(s.DockerEndpoint+"/networks/prune", query)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		nil,
+		"")
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		var responseBody models.PruneNetworksResponseBody
+		err = json.Unmarshal(httpResponse.Body, &responseBody)
+		if err != nil {
+			return nil, fmt.Errorf("json unmarshalling issue when pruning networks - %s", err)
+		}
+		return responseBody.NetworksDeleted, nil
+	case 400:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return nil, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* ConnectNetwork attaches a container to a network given the network name or ID, the container ID and the settings of
+the container in the network (aliases and static addresses). Running containers get their address right away */
+func (s *SimpleDocker) ConnectNetwork(ctx context.Context, network string, containerID string, options EndpointOptions) error {
+	jsonBodyRequest, err := json.Marshal(models.ConnectNetworkBody{Container: containerID, EndpointConfig: options.settings()})
+	if err != nil {
+		return fmt.Errorf("json marshall issue when connecting container to network - %s", err)
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/networks/%s/connect", s.DockerEndpoint, network)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		map[string]string{"Content-Type": "application/json"},
+		string(jsonBodyRequest))
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		return nil
+	case 400, 403:
+		// 403 reports a container already attached to the network, or a network that does not accept containers
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return s.networkNotFoundError(ctx, network, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist))
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* DisconnectNetwork detaches a container from a network given the network name or ID and the container ID.
+force disconnects the container even if the network is no longer available */
+func (s *SimpleDocker) DisconnectNetwork(ctx context.Context, network string, containerID string, force bool) error {
+	jsonBodyRequest, err := json.Marshal(models.DisconnectNetworkBody{Container: containerID, Force: force})
+	if err != nil {
+		return fmt.Errorf("json marshall issue when disconnecting container from network - %s", err)
+	}
+
+	urlEndpoint := fmt.Sprintf("%s/networks/%s/disconnect", s.DockerEndpoint, network)
+	httpResponse, err := s.HttpClient.PostContext(ctx, urlEndpoint,
+		map[string]string{"Content-Type": "application/json"},
+		string(jsonBodyRequest))
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing POST on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		return nil
+	case 400, 403:
+		// 403 reports a container not attached to the network
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	case 404:
+		return s.networkNotFoundError(ctx, network, newDockerAPIError("POST", urlEndpoint, httpResponse, ErrContainerDoesNotExist))
+	default:
+		return newDockerAPIError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* networkNotFoundError tells apart the two reasons the network endpoints answer 404 with: a missing network or a missing
+container. apiErr reports the container missing, and is changed if the network is */
+func (s *SimpleDocker) networkNotFoundError(ctx context.Context, network string, apiErr *DockerAPIError) error {
+	if _, err := s.InspectNetwork(ctx, network); errors.Is(err, ErrNetworkDoesNotExist) {
+		apiErr.Err = ErrNetworkDoesNotExist
+	}
+	return apiErr
+}
+
+// isUserNetwork tells whether a container network mode names a network rather than a predefined mode (bridge, host, none or container:<id>)
+func isUserNetwork(networkMode string) bool {
+	switch networkMode {
+	case "", "default", "bridge", "host", "none":
+		return false
+	}
+	return !strings.HasPrefix(networkMode, "container:")
+}
@@ -0,0 +1,269 @@
+package dockerclient
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSimpleDocker_CreateNetwork(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Create a network to clash with
+	existingID, err := dockerClient.CreateNetwork(context.Background(), "dockermanagerexisting", CreateNetworkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveNetwork(context.Background(), existingID)
+
+	tests := []struct {
+		name        string
+		networkName string
+		options     CreateNetworkOptions
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Create a network with a subnet",
+			networkName: "dockermanagernet",
+			options: CreateNetworkOptions{Subnet: "10.213.0.0/24", Gateway: "10.213.0.254", Internal: true,
+				Labels: map[string]string{"app": "dockermanager"}},
+			wantErr: false,
+		},
+		{
+			name:        "Create a network with the name of an existing one",
+			networkName: "dockermanagerexisting",
+			options:     CreateNetworkOptions{},
+			wantErr:     true,
+			wantErrIs:   ErrNetworkAlreadyExist,
+		},
+		{
+			name:        "Create a network with an invalid subnet",
+			networkName: "dockermanagerbadnet",
+			options:     CreateNetworkOptions{Subnet: "10.213.1.0/33"},
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networkID, err := dockerClient.CreateNetwork(context.Background(), tt.networkName, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.CreateNetwork() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			defer dockerClient.RemoveNetwork(context.Background(), networkID)
+
+			network, err := dockerClient.InspectNetwork(context.Background(), tt.networkName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if network.ID != networkID || network.Driver != "bridge" || network.Internal != tt.options.Internal ||
+				!reflect.DeepEqual(network.Labels, tt.options.Labels) {
+				t.Errorf("SimpleDocker.InspectNetwork() = %+v, want network %s with the options %+v", network, networkID, tt.options)
+			}
+			if len(network.IPAM.Config) != 1 || network.IPAM.Config[0].Subnet != tt.options.Subnet ||
+				network.IPAM.Config[0].Gateway != tt.options.Gateway {
+				t.Errorf("SimpleDocker.InspectNetwork() IPAM = %+v, want subnet %s and gateway %s", network.IPAM, tt.options.Subnet, tt.options.Gateway)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_ListRemovePruneNetworks(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Create two networks, one of them to be pruned
+	kept, err := dockerClient.CreateNetwork(context.Background(), "dockermanagerkept", CreateNetworkOptions{
+		Labels: map[string]string{"dockermanager": "kept"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveNetwork(context.Background(), kept)
+	pruned, err := dockerClient.CreateNetwork(context.Background(), "dockermanagerpruned", CreateNetworkOptions{
+		Labels: map[string]string{"dockermanager": "pruned"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveNetwork(context.Background(), pruned)
+
+	networks, err := dockerClient.ListNetworks(context.Background(), Filters{"label": {"dockermanager"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 2 || networks[0].ID != kept || networks[1].ID != pruned {
+		t.Errorf("SimpleDocker.ListNetworks() = %+v, want the networks %s and %s", networks, kept, pruned)
+	}
+	networks, err = dockerClient.ListNetworks(context.Background(), Filters{"type": {"builtin"}, "name": {"bridge"}})
+	if err != nil || len(networks) != 1 || networks[0].Name != "bridge" {
+		t.Errorf("SimpleDocker.ListNetworks() = %+v, %v, want the bridge network", networks, err)
+	}
+	_, err = dockerClient.ListNetworks(context.Background(), Filters{"fake": {"fake"}})
+	if !errors.Is(err, ErrBadParameter) {
+		t.Errorf("SimpleDocker.ListNetworks() error = %v, want %v", err, ErrBadParameter)
+	}
+
+	deleted, err := dockerClient.PruneNetworks(context.Background(), Filters{"label": {"dockermanager=pruned"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"dockermanagerpruned"}) {
+		t.Errorf("SimpleDocker.PruneNetworks() = %v, want [dockermanagerpruned]", deleted)
+	}
+
+	tests := []struct {
+		name      string
+		network   string
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name:    "Remove a network",
+			network: "dockermanagerkept",
+			wantErr: false,
+		},
+		{
+			name:      "Remove a predefined network",
+			network:   "bridge",
+			wantErr:   true,
+			wantErrIs: ErrNetworkInUse,
+		},
+		{
+			name:      "Remove a non existing network",
+			network:   "dockermanagerpruned",
+			wantErr:   true,
+			wantErrIs: ErrNetworkDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dockerClient.RemoveNetwork(context.Background(), tt.network)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.RemoveNetwork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_ConnectDisconnectNetwork(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Create a network and a container attached to it with a static address, and another one on the default bridge
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	networkID, err := dockerClient.CreateNetwork(context.Background(), "dockermanagerapps", CreateNetworkOptions{Subnet: "10.214.0.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveNetwork(context.Background(), networkID)
+
+	databaseID, err := dockerClient.CreateContainerWithOptions(context.Background(), "ubuntudatabase", ContainerOptions{
+		Image:           "ubuntu:20.04",
+		Cmd:             []string{"sleep", "infinity"},
+		NetworkMode:     "dockermanagerapps",
+		NetworkEndpoint: EndpointOptions{Aliases: []string{"db"}, IPv4Address: "10.214.0.10"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveContainerWithOptions(context.Background(), databaseID, RemoveContainerOptions{Force: true})
+	webID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntuweb", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveContainerWithOptions(context.Background(), webID, RemoveContainerOptions{Force: true})
+
+	// The static address is given once the container runs
+	if err := dockerClient.RunContainerContext(context.Background(), databaseID); err != nil {
+		t.Fatal(err)
+	}
+	container, err := dockerClient.InspectContainer(context.Background(), databaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint := container.NetworkSettings.Networks["dockermanagerapps"]; endpoint.NetworkID != networkID || endpoint.IPAddress != "10.214.0.10" {
+		t.Errorf("SimpleDocker.InspectContainer() networks = %+v, want 10.214.0.10 in network %s", container.NetworkSettings.Networks, networkID)
+	}
+
+	tests := []struct {
+		name        string
+		network     string
+		containerID string
+		options     EndpointOptions
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Connect a container with an alias",
+			network:     "dockermanagerapps",
+			containerID: webID,
+			options:     EndpointOptions{Aliases: []string{"web"}},
+			wantErr:     false,
+		},
+		{
+			name:        "Connect a container already connected",
+			network:     "dockermanagerapps",
+			containerID: webID,
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+		{
+			name:        "Connect a container with an address out of the subnet",
+			network:     "dockermanagerapps",
+			containerID: databaseID,
+			options:     EndpointOptions{IPv4Address: "10.215.0.10"},
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+		{
+			name:        "Connect to a non existing network",
+			network:     "fakenetwork",
+			containerID: webID,
+			wantErr:     true,
+			wantErrIs:   ErrNetworkDoesNotExist,
+		},
+		{
+			name:        "Connect a non existing container",
+			network:     "dockermanagerapps",
+			containerID: "fakecontainer",
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dockerClient.ConnectNetwork(context.Background(), tt.network, tt.containerID, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.ConnectNetwork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Networks with containers attached cannot be removed until they are disconnected
+	err = dockerClient.RemoveNetwork(context.Background(), networkID)
+	if !errors.Is(err, ErrNetworkInUse) {
+		t.Errorf("SimpleDocker.RemoveNetwork() error = %v, want %v", err, ErrNetworkInUse)
+	}
+	if err := dockerClient.DisconnectNetwork(context.Background(), "dockermanagerapps", webID, false); err != nil {
+		t.Errorf("SimpleDocker.DisconnectNetwork() error = %v", err)
+	}
+	err = dockerClient.DisconnectNetwork(context.Background(), "dockermanagerapps", webID, false)
+	if !errors.Is(err, ErrBadParameter) {
+		t.Errorf("SimpleDocker.DisconnectNetwork() error = %v, want %v", err, ErrBadParameter)
+	}
+
+	// Containers cannot be created on a non existing network
+	_, err = dockerClient.CreateContainerWithOptions(context.Background(), "", ContainerOptions{Image: "ubuntu:20.04", NetworkMode: "fakenetwork"})
+	if !errors.Is(err, ErrNetworkDoesNotExist) {
+		t.Errorf("SimpleDocker.CreateContainerWithOptions() error = %v, want %v", err, ErrNetworkDoesNotExist)
+	}
+}
@@ -0,0 +1,146 @@
+package dockerclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+/* SaveImages writes to output a tar archive holding the images given by reference or ID, with their layers,
+configurations and references, the way docker save does. The archive is streamed, so it is never held in memory */
+func (s *SimpleDocker) SaveImages(ctx context.Context, images []string, output io.Writer) error {
+	query := url.Values{"names": images}
+	urlEndpoint := withQuery(s.DockerEndpoint+"/images/get", query)
+	httpResponse, err := s.HttpClient.Stream(ctx, "GET", urlEndpoint, nil, nil)
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+		if _, err := io.Copy(output, httpResponse.Body); err != nil {
+			return fmt.Errorf("cannot write the images saved - %w", err)
+		}
+		return nil
+	case 404:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrImageDoesNotExist)
+	default:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* LoadImage loads the images of a tar archive made by SaveImages (or docker save), read from input as it is sent.
+progress is called for every progress message sent by the daemon (it can be nil). Errors reported in the middle
+of the load are returned as ErrImageLoadFailed. It returns the references (or IDs, for untagged images) loaded */
+func (s *SimpleDocker) LoadImage(ctx context.Context, input io.Reader, progress ProgressFunc) ([]string, error) {
+	urlEndpoint := s.DockerEndpoint + "/images/load"
+	httpResponse, err := s.HttpClient.Stream(ctx, "POST", urlEndpoint,
+		map[string]string{"Content-Type": "application/x-tar"},
+		input)
+	if err != nil {
+		return nil, fmt.Errorf("there was an issue with HTTP client when performing POST "+
+			"on %s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+
+		// The daemon reports every image loaded as "Loaded image: <reference>" or "Loaded image ID: <ID>"
+		var loaded []string
+		daemonError, err := readJSONMessages(httpResponse.Body, func(message models.JSONMessage) {
+			for _, prefix := range []string{"Loaded image: ", "Loaded image ID: "} {
+				if strings.HasPrefix(message.Stream, prefix) {
+					loaded = append(loaded, strings.TrimSpace(strings.TrimPrefix(message.Stream, prefix)))
+				}
+			}
+			if progress != nil {
+				progress(message)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if daemonError != "" {
+			return nil, &DockerAPIError{StatusCode: httpResponse.StatusCode, Method: "POST", Endpoint: urlEndpoint,
+				Message: daemonError, Err: ErrImageLoadFailed}
+		}
+		return loaded, nil
+	case 400:
+		return nil, newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return nil, newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* ExportContainer writes to output the filesystem of a container given its ID as a tar archive, the way docker export does.
+The archive is streamed, so it is never held in memory */
+func (s *SimpleDocker) ExportContainer(ctx context.Context, containerID string, output io.Writer) error {
+	urlEndpoint := fmt.Sprintf("%s/containers/%s/export", s.DockerEndpoint, containerID)
+	httpResponse, err := s.HttpClient.Stream(ctx, "GET", urlEndpoint, nil, nil)
+	if err != nil {
+		return fmt.Errorf("there was an issue with HTTP client when performing GET on "+
+			"%s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+		if _, err := io.Copy(output, httpResponse.Body); err != nil {
+			return fmt.Errorf("cannot write the container exported - %w", err)
+		}
+		return nil
+	case 404:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrContainerDoesNotExist)
+	default:
+		return newDockerAPIStreamError("GET", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
+
+/* ImportImage creates an image from a filesystem tar archive (such as the ones made by ExportContainer) read from input
+as it is sent, given the import options. progress is called for every progress message sent by the daemon (it can be nil).
+Errors reported in the middle of the import are returned as ErrImageLoadFailed. It returns the ID of the new image */
+func (s *SimpleDocker) ImportImage(ctx context.Context, input io.Reader, options ImportImageOptions, progress ProgressFunc) (string, error) {
+	urlEndpoint := withQuery(s.DockerEndpoint+"/images/create", options.query())
+	httpResponse, err := s.HttpClient.Stream(ctx, "POST", urlEndpoint,
+		map[string]string{"Content-Type": "application/x-tar"},
+		input)
+	if err != nil {
+		return "", fmt.Errorf("there was an issue with HTTP client when performing POST "+
+			"on %s - %w", urlEndpoint, err)
+	}
+
+	switch httpResponse.StatusCode {
+	case 200:
+		defer httpResponse.Body.Close()
+
+		// The ID of the new image is the status of the last message
+		var imageID string
+		daemonError, err := readJSONMessages(httpResponse.Body, func(message models.JSONMessage) {
+			if strings.HasPrefix(message.Status, "sha256:") {
+				imageID = message.Status
+			}
+			if progress != nil {
+				progress(message)
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+		if daemonError != "" {
+			return "", &DockerAPIError{StatusCode: httpResponse.StatusCode, Method: "POST", Endpoint: urlEndpoint,
+				Message: daemonError, Err: ErrImageLoadFailed}
+		}
+		return imageID, nil
+	case 400:
+		return "", newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrBadParameter)
+	default:
+		return "", newDockerAPIStreamError("POST", urlEndpoint, httpResponse, ErrDockerInternalServerError)
+	}
+}
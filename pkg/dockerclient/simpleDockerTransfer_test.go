@@ -0,0 +1,194 @@
+package dockerclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tarEntries returns the contents of the entries of a tar archive by name
+func tarEntries(t *testing.T, archive io.Reader) map[string][]byte {
+	entries := make(map[string][]byte)
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[header.Name] = content
+	}
+}
+
+func TestSimpleDocker_SaveImages(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to save
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		images       []string
+		wantRepoTags []string
+		wantErr      bool
+		wantErrIs    error
+	}{
+		{
+			name:         "Save an existing image",
+			images:       []string{"ubuntu:20.04"},
+			wantRepoTags: []string{"ubuntu:20.04"},
+			wantErr:      false,
+		},
+		{
+			name:      "Save a non existing image",
+			images:    []string{"ubuntu:20.04", "fakeimage:fake"},
+			wantErr:   true,
+			wantErrIs: ErrImageDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive bytes.Buffer
+			err := dockerClient.SaveImages(context.Background(), tt.images, &archive)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.SaveImages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var manifest []struct {
+				Config   string
+				RepoTags []string
+				Layers   []string
+			}
+			if err := json.Unmarshal(tarEntries(t, &archive)["manifest.json"], &manifest); err != nil {
+				t.Fatalf("SimpleDocker.SaveImages() manifest.json cannot be read - %s", err)
+			}
+			if len(manifest) != 1 || !reflect.DeepEqual(manifest[0].RepoTags, tt.wantRepoTags) || len(manifest[0].Layers) == 0 {
+				t.Errorf("SimpleDocker.SaveImages() manifest = %+v, want a single image tagged %v", manifest, tt.wantRepoTags)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_LoadImage(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Save a tagged image, and remove the tag so loading the archive brings it back
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dockerClient.TagImage(context.Background(), "ubuntu:20.04", "dockermanager/saved", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := dockerClient.SaveImages(context.Background(), []string{"dockermanager/saved:v1"}, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dockerClient.RemoveImage(context.Background(), "dockermanager/saved:v1", RemoveImageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   io.Reader
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "Load an archive made by SaveImages",
+			input:   &archive,
+			want:    []string{"dockermanager/saved:v1"},
+			wantErr: false,
+		},
+		{
+			name:    "Load something that is not an image archive",
+			input:   strings.NewReader("not a tar archive"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.LoadImage(context.Background(), tt.input, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.LoadImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SimpleDocker.LoadImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := dockerClient.RemoveImage(context.Background(), "dockermanager/saved:v1", RemoveImageOptions{}); err != nil {
+		t.Errorf("SimpleDocker.RemoveImage() error = %v, want the loaded image", err)
+	}
+}
+
+func TestSimpleDocker_ExportImportContainer(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Create a container to export
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntuexport", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveContainerContext(context.Background(), containerID)
+
+	// The export is streamed straight into the import through a pipe, without holding the archive
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(dockerClient.ExportContainer(context.Background(), containerID, writer))
+	}()
+	options := ImportImageOptions{Repository: "dockermanager/imported", Tag: "v1", Changes: []string{`CMD ["cat", "/etc/os-release"]`},
+		Message: "imported from ubuntuexport"}
+	imageID, err := dockerClient.ImportImage(context.Background(), reader, options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dockerClient.RemoveImage(context.Background(), imageID, RemoveImageOptions{Force: true})
+
+	image, err := dockerClient.InspectImage(context.Background(), "dockermanager/imported:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.ID != imageID || !reflect.DeepEqual(image.Config.Cmd, []string{"cat", "/etc/os-release"}) {
+		t.Errorf("SimpleDocker.InspectImage() = %s with Cmd %v, want %s with the Cmd of the changes", image.ID, image.Config.Cmd, imageID)
+	}
+
+	err = dockerClient.ExportContainer(context.Background(), "fakecontainer", io.Discard)
+	if !errors.Is(err, ErrContainerDoesNotExist) {
+		t.Errorf("SimpleDocker.ExportContainer() error = %v, want %v", err, ErrContainerDoesNotExist)
+	}
+
+	_, err = dockerClient.ImportImage(context.Background(), bytes.NewReader(nil), ImportImageOptions{Changes: []string{"RUN true"}}, nil)
+	if !errors.Is(err, ErrBadParameter) {
+		t.Errorf("SimpleDocker.ImportImage() error = %v, want %v", err, ErrBadParameter)
+	}
+}
@@ -1,22 +1,55 @@
 package dockerclient
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/dockertest"
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
 	"github.com/mikeletux/go-docker-manager/pkg/httpclient"
 )
 
-const DockerEndpoint = "http://localhost:2375"
+/* DockerEndpointEnvVar is the env var that points the tests to a real docker daemon (e.g. http://localhost:2375).
+If not set, tests run against an in-process fake daemon */
+const DockerEndpointEnvVar = "DOCKER_MANAGER_TEST_ENDPOINT"
 
-func TestSimpleDocker_CheckIfImageAlreadyExists(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
+// newTestDockerClient returns a SimpleDocker client ready to be used in tests, either against a real or a fake daemon
+func newTestDockerClient(t *testing.T) *SimpleDocker {
+	if dockerEndpoint, present := os.LookupEnv(DockerEndpointEnvVar); present {
+		httpClient, baseURL, err := httpclient.NewSimpleHttpClientForEndpoint(dockerEndpoint, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewSimpeDocker(baseURL, httpClient)
+	}
+
+	dockerClient, _ := newFakeDockerClient(t)
+	return dockerClient
+}
+
+/* newFakeDockerClient returns a SimpleDocker client against an in-process fake daemon, alongside the fake itself.
+It is meant for tests that need to set up situations that cannot be reproduced on demand with a real daemon */
+func newFakeDockerClient(t *testing.T) (*SimpleDocker, *dockertest.Server) {
+	server := dockertest.NewServer()
+	t.Cleanup(server.Close)
+	server.AddRegistryImage("ubuntu", "20.04")
+
+	return NewSimpeDocker(server.URL, httpclient.NewSimpleHttpClient()), server
+}
 
+func TestSimpleDocker_CheckIfImageAlreadyExists(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to make sure that exists
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -54,24 +87,21 @@ func TestSimpleDocker_CheckIfImageAlreadyExists(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := dockerClient.CheckIfImageAlreadyExists(tt.args.dockerImage, tt.args.tag)
+			got, err := dockerClient.CheckIfImageAlreadyExistsContext(context.Background(), tt.args.dockerImage, tt.args.tag)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.CheckIfImageAlreadyExists() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("SimpleDocker.CheckIfImageAlreadyExistsContext() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("SimpleDocker.CheckIfImageAlreadyExists() = %v, want %v", got, tt.want)
+				t.Errorf("SimpleDocker.CheckIfImageAlreadyExistsContext() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
 func TestSimpleDocker_PullImageFromRegistry(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
-
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	type args struct {
 		dockerImage string
@@ -104,22 +134,153 @@ func TestSimpleDocker_PullImageFromRegistry(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := dockerClient.PullImageFromRegistry(tt.args.dockerImage, tt.args.tag, tt.args.arch); (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.PullImageFromRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			if err := dockerClient.PullImageFromRegistryContext(context.Background(), tt.args.dockerImage, tt.args.tag, tt.args.arch); (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.PullImageFromRegistryContext() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func TestSimpleDocker_CreateContainer(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
+func TestSimpleDocker_PullImageWithProgress(t *testing.T) {
+	// Create Docker client
+	dockerClient, server := newFakeDockerClient(t)
+	server.SetPullError("ubuntu", "21.04", "received unexpected HTTP status: 503 Service Unavailable")
+
+	type args struct {
+		dockerImage string
+		tag         string
+		arch        string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantLayerDone bool // This flag tells if a "Pull complete" message must be received
+		wantErr       error
+	}{
+		{
+			name: "Pulling an image that actually exists",
+			args: args{
+				dockerImage: "ubuntu",
+				tag:         "20.04",
+				arch:        "x86-64",
+			},
+			wantLayerDone: true,
+			wantErr:       nil,
+		},
+		{
+			name: "Pulling an image that fails in the middle of the stream",
+			args: args{
+				dockerImage: "ubuntu",
+				tag:         "21.04",
+				arch:        "x86-64",
+			},
+			wantLayerDone: false,
+			wantErr:       ErrImagePullFailed,
+		},
+		{
+			name: "Pulling an image that doesn't exists",
+			args: args{
+				dockerImage: "ubuntu",
+				tag:         "20.07",
+				arch:        "x86-64",
+			},
+			wantLayerDone: false,
+			wantErr:       ErrImageDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var layerDone bool
+			err := dockerClient.PullImageWithProgress(context.Background(), tt.args.dockerImage, tt.args.tag, tt.args.arch,
+				func(message models.JSONMessage) {
+					if message.ID != "" && message.Status == "Pull complete" {
+						layerDone = true
+					}
+				})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SimpleDocker.PullImageWithProgress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if layerDone != tt.wantLayerDone {
+				t.Errorf("SimpleDocker.PullImageWithProgress() layer done = %v, want %v", layerDone, tt.wantLayerDone)
+			}
+		})
+	}
+
+	exists, err := dockerClient.CheckIfImageAlreadyExistsContext(context.Background(), "ubuntu", "21.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Errorf("image ubuntu:21.04 exists after a failed pull")
+	}
+}
+
+func TestSimpleDocker_PullImageWithAuth(t *testing.T) {
+	// Private registries cannot be set up on demand, so it only runs against the fake daemon
+	dockerClient, server := newFakeDockerClient(t)
+	server.AddRegistryImage("registry.example.com/team/app", "1.0")
+	server.SetRegistryCredentials("registry.example.com", "user", "secret")
+	server.AddRegistryImage("tokens.example.com/app", "1.0")
+	server.SetRegistryIdentityToken("tokens.example.com", "refresh-token")
+
+	tests := []struct {
+		name    string
+		image   string
+		auth    *models.AuthConfig
+		wantErr error
+	}{
+		{
+			name:    "Pulling with the right credentials",
+			image:   "registry.example.com/team/app",
+			auth:    &models.AuthConfig{Username: "user", Password: "secret", ServerAddress: "registry.example.com"},
+			wantErr: nil,
+		},
+		{
+			name:    "Pulling with an identity token",
+			image:   "tokens.example.com/app",
+			auth:    &models.AuthConfig{IdentityToken: "refresh-token"},
+			wantErr: nil,
+		},
+		{
+			name:    "Pulling with the wrong password",
+			image:   "registry.example.com/team/app",
+			auth:    &models.AuthConfig{Username: "user", Password: "wrong"},
+			wantErr: ErrRegistryUnauthorized,
+		},
+		{
+			name:    "Pulling anonymously from a private registry",
+			image:   "registry.example.com/team/app",
+			auth:    nil,
+			wantErr: ErrRegistryUnauthorized,
+		},
+		{
+			name:    "Pulling from a public registry with credentials",
+			image:   "ubuntu",
+			auth:    &models.AuthConfig{Username: "user", Password: "secret"},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := "1.0"
+			if tt.image == "ubuntu" {
+				tag = "20.04"
+			}
+			err := dockerClient.PullImageWithAuth(context.Background(), tt.image, tag, "x86-64", tt.auth, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SimpleDocker.PullImageWithAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
 
+func TestSimpleDocker_CreateContainer(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -172,42 +333,162 @@ func TestSimpleDocker_CreateContainer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			got, err := dockerClient.CreateContainer(tt.args.containerName, tt.args.image, tt.args.tag, tt.args.cmd)
+			got, err := dockerClient.CreateContainerContext(context.Background(), tt.args.containerName, tt.args.image, tt.args.tag, tt.args.cmd)
 			if len(got) > 0 {
 				containerID = got
 			}
 			if (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.CreateContainer() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("SimpleDocker.CreateContainerContext() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if err == nil && len(got) == 0 { // If the len of the ID is > 0, we can consider that it returns an ID
-				t.Errorf("SimpleDocker.CreateContainer() = %v, want len(ID) > 0 ", got)
+				t.Errorf("SimpleDocker.CreateContainerContext() = %v, want len(ID) > 0 ", got)
 			}
 		})
 	}
 
 	// Remove container
-	err = dockerClient.RemoveContainer(containerID)
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
-func TestSimpleDocker_RunContainer(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
+func TestSimpleDocker_CreateContainerWithOptions(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create containers from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := ContainerOptions{
+		Image:         "ubuntu:20.04",
+		Cmd:           []string{"infinity"},
+		Entrypoint:    []string{"sleep"},
+		Env:           []string{"GREETING=hello"},
+		Labels:        map[string]string{"dockermanager.test": "options"},
+		WorkingDir:    "/tmp",
+		User:          "nobody",
+		Hostname:      "optionshost",
+		ExposedPorts:  []string{"53/udp"},
+		Ports:         []PortMapping{{ContainerPort: "80", HostIP: "127.0.0.1", HostPort: "18080"}},
+		Binds:         []string{"/tmp:/hosttmp:ro"},
+		Volumes:       []string{"/cache"},
+		RestartPolicy: models.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+		NetworkMode:   "bridge",
+		Memory:        64 * 1024 * 1024,
+		CPUShares:     512,
+		PidsLimit:     100,
+	}
+
+	tests := []struct {
+		name          string
+		containerName string
+		options       ContainerOptions
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name:          "Create a container with options",
+			containerName: "ubuntuoptions",
+			options:       options,
+			wantErr:       false,
+		},
+		{
+			name:          "Create a container with an invalid port",
+			containerName: "ubuntuinvalidport",
+			options:       ContainerOptions{Image: "ubuntu:20.04", ExposedPorts: []string{"http"}},
+			wantErr:       true,
+		},
+		{
+			name:          "Create a container with an invalid restart policy",
+			containerName: "ubuntuinvalidpolicy",
+			options:       ContainerOptions{Image: "ubuntu:20.04", RestartPolicy: models.RestartPolicy{Name: "sometimes"}},
+			wantErr:       true,
+			wantErrIs:     ErrBadParameter,
+		},
+		{
+			name:          "Create a container from an image that doesn't exist",
+			containerName: "fakeoptions",
+			options:       ContainerOptions{Image: "fakefakefake:fake"},
+			wantErr:       true,
+			wantErrIs:     ErrImageDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := dockerClient.CreateContainerWithOptions(context.Background(), tt.containerName, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.CreateContainerWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			defer dockerClient.RemoveContainerContext(context.Background(), id)
+
+			// Every option must be found when inspecting the container
+			got, err := dockerClient.InspectContainer(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := models.ContainerConfig{
+				Hostname:     "optionshost",
+				User:         "nobody",
+				ExposedPorts: map[string]struct{}{"53/udp": {}, "80/tcp": {}},
+				Env:          []string{"GREETING=hello"},
+				Cmd:          []string{"infinity"},
+				Image:        "ubuntu:20.04",
+				Volumes:      map[string]struct{}{"/cache": {}},
+				WorkingDir:   "/tmp",
+				Entrypoint:   []string{"sleep"},
+				Labels:       map[string]string{"dockermanager.test": "options"},
+			}
+			// Real daemons append the image env vars (e.g. PATH) to the ones given
+			if len(got.Config.Env) > 1 {
+				got.Config.Env = got.Config.Env[:1]
+			}
+			if !reflect.DeepEqual(got.Config, want) {
+				t.Errorf("SimpleDocker.InspectContainer() config = %+v, want %+v", got.Config, want)
+			}
+			wantPortBindings := map[string][]models.PortBinding{"80/tcp": {{HostIP: "127.0.0.1", HostPort: "18080"}}}
+			if !reflect.DeepEqual(got.HostConfig.PortBindings, wantPortBindings) ||
+				!reflect.DeepEqual(got.HostConfig.Binds, options.Binds) ||
+				got.HostConfig.RestartPolicy != options.RestartPolicy || got.HostConfig.NetworkMode != "bridge" ||
+				got.HostConfig.Memory != options.Memory || got.HostConfig.CPUShares != options.CPUShares ||
+				got.HostConfig.PidsLimit != options.PidsLimit {
+				t.Errorf("SimpleDocker.InspectContainer() host config = %+v, want the options given", got.HostConfig)
+			}
+
+			// Labels can be used to find the container
+			containers, err := dockerClient.ListContainers(context.Background(),
+				ListContainersOptions{All: true, Filters: Filters{"label": {"dockermanager.test=options"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(containers) != 1 || containers[0].ID != id {
+				t.Errorf("SimpleDocker.ListContainers() = %+v, want only the container with options", containers)
+			}
+		})
+	}
+}
 
+func TestSimpleDocker_RunContainer(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Create container
-	id, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"free", "-h"})
+	id, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"free", "-h"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -235,46 +516,137 @@ func TestSimpleDocker_RunContainer(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := dockerClient.RunContainer(tt.containerID); (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.RunContainer() error = %v, wantErr %v", err, tt.wantErr)
+			if err := dockerClient.RunContainerContext(context.Background(), tt.containerID); (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.RunContainerContext() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 
 	// Stop container
-	_, err = dockerClient.StopContainer(id)
+	_, err = dockerClient.StopContainerContext(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Remove container
-	err = dockerClient.RemoveContainer(id)
+	err = dockerClient.RemoveContainerContext(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
-func TestSimpleDocker_CheckIfContainerIsReady(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
+func TestSimpleDocker_WaitContainer(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create containers from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a container that exits with code 3
+	id, err := dockerClient.CreateContainerContext(context.Background(), "ubuntuwait", "ubuntu", "20.04", []string{"sh", "-c", "exit 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		containerID string
+		condition   WaitCondition
+		timeout     time.Duration
+		want        int
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Wait for the container to exit",
+			containerID: id,
+			condition:   "",
+			timeout:     30 * time.Second,
+			want:        3,
+		},
+		{
+			name:        "Wait for a stopped container to exit again",
+			containerID: id,
+			condition:   WaitConditionNextExit,
+			timeout:     100 * time.Millisecond,
+			wantErr:     true,
+		},
+		{
+			name:        "Wait with an invalid condition",
+			containerID: id,
+			condition:   "restarted",
+			timeout:     30 * time.Second,
+			wantErr:     true,
+			wantErrIs:   ErrBadParameter,
+		},
+		{
+			name:        "Wait for a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			condition:   WaitConditionNotRunning,
+			timeout:     30 * time.Second,
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			got, err := dockerClient.WaitContainer(ctx, tt.containerID, tt.condition)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.WaitContainer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SimpleDocker.WaitContainer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Wait for the container to be removed
+	removed := make(chan error)
+	go func() {
+		_, err := dockerClient.WaitContainer(context.Background(), id, WaitConditionRemoved)
+		removed <- err
+	}()
+	time.Sleep(100 * time.Millisecond) // Give the wait query time to reach the daemon before removing the container
+	err = dockerClient.RemoveContainerContext(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-removed; err != nil {
+		t.Errorf("SimpleDocker.WaitContainer() error = %v, want nil once the container is removed", err)
+	}
+}
 
+func TestSimpleDocker_CheckIfContainerIsReady(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Create container
-	id, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	id, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Run container
-	err = dockerClient.RunContainer(id)
+	err = dockerClient.RunContainerContext(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -312,168 +684,365 @@ func TestSimpleDocker_CheckIfContainerIsReady(t *testing.T) {
 	for _, tt := range tests {
 		if !tt.isRunning {
 			// Stop container
-			_, err = dockerClient.StopContainer(id)
+			_, err = dockerClient.StopContainerContext(context.Background(), id)
 			if err != nil {
 				t.Fatal(err)
 			}
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := dockerClient.CheckIfContainerIsReady(tt.containerID)
+			got, err := dockerClient.CheckIfContainerIsReadyContext(context.Background(), tt.containerID)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.CheckIfContainerIsReady() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("SimpleDocker.CheckIfContainerIsReadyContext() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("SimpleDocker.CheckIfContainerIsReady() = %v, want %v", got, tt.want)
+				t.Errorf("SimpleDocker.CheckIfContainerIsReadyContext() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 
 	// Remove container
-	err = dockerClient.RemoveContainer(id)
+	err = dockerClient.RemoveContainerContext(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
-func TestSimpleDocker_GenerateExecInstance(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
-
+func TestSimpleDocker_InspectContainer(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Create container
-	id, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	id, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Run container
-	err = dockerClient.RunContainer(id)
+	// Check the configuration of the container
+	container, err := dockerClient.InspectContainer(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	type args struct {
-		containerID string
-		commands    []string
-		isRunning   bool // This flag allows to keep running or stopping the container for testing purposes
+	if container.ID != id || container.Name != "/ubuntu2004" || container.Path != "sleep" ||
+		!reflect.DeepEqual(container.Args, []string{"infinity"}) || container.Config.Image != "ubuntu:20.04" ||
+		!reflect.DeepEqual(container.Config.Cmd, []string{"sleep", "infinity"}) || container.Created.IsZero() {
+		t.Errorf("SimpleDocker.InspectContainer() = %+v, want the configuration of ubuntu2004", container)
 	}
+
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
+		name        string
+		containerID string
+		start       bool // this will flag if the container must be started before inspecting it
+		wantStatus  string
+		wantRunning bool
+		wantErr     error
 	}{
 		{
-			name: "Create exec instance in a running container",
-			args: args{
-				containerID: id,
-				commands:    []string{"free", "-h"},
-				isRunning:   true,
-			},
-			wantErr: false,
+			name:        "Inspect a created container",
+			containerID: id,
+			wantStatus:  "created",
 		},
 		{
-			name: "Try to create an exec instance in a stopped container",
-			args: args{
-				containerID: id,
-				commands:    []string{"free", "-h"},
-				isRunning:   false,
-			},
-			wantErr: true,
+			name:        "Inspect a running container",
+			containerID: id,
+			start:       true,
+			wantStatus:  "running",
+			wantRunning: true,
 		},
 		{
-			name: "Try to create an exec instance in a non existent container",
-			args: args{
-				containerID: "fakefakefakefake",
-				commands:    []string{"free", "-h"},
-				isRunning:   true,
-			},
-			wantErr: true,
+			name:        "Inspect a container that doesn't exist",
+			containerID: "fakefakefakefake",
+			wantErr:     ErrContainerDoesNotExist,
 		},
 	}
+
 	for _, tt := range tests {
-		if !tt.args.isRunning {
-			// Stop container
-			_, err = dockerClient.StopContainer(id)
+		if tt.start {
+			// Run container
+			err = dockerClient.RunContainerContext(context.Background(), id)
 			if err != nil {
 				t.Fatal(err)
 			}
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := dockerClient.GenerateExecInstance(tt.args.containerID, tt.args.commands)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.GenerateExecInstance() error = %v, wantErr %v", err, tt.wantErr)
+			got, err := dockerClient.InspectContainer(context.Background(), tt.containerID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SimpleDocker.InspectContainer() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if err == nil && len(got) == 0 {
-				t.Errorf("SimpleDocker.GenerateExecInstance() = %v, want len(ID) > 0 ", got)
+			if err != nil {
+				return
+			}
+			if got.State.Status != tt.wantStatus || got.State.Running != tt.wantRunning {
+				t.Errorf("SimpleDocker.InspectContainer() state = %+v, want status %s and running %v", got.State, tt.wantStatus, tt.wantRunning)
+			}
+			if tt.wantRunning && (got.State.Pid == 0 || got.State.StartedAt.IsZero() || got.NetworkSettings.IPAddress == "") {
+				t.Errorf("SimpleDocker.InspectContainer() = %+v, want a pid, a start time and an IP address", got)
 			}
 		})
 	}
 
+	// Stop container, its finish time must be set
+	_, err = dockerClient.StopContainerContext(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container, err = dockerClient.InspectContainer(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.State.Status != "exited" || container.State.FinishedAt.Before(container.State.StartedAt) {
+		t.Errorf("SimpleDocker.InspectContainer() state = %+v, want an exited container", container.State)
+	}
+
 	// Remove container
-	err = dockerClient.RemoveContainer(id)
+	err = dockerClient.RemoveContainerContext(context.Background(), id)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
-func TestSimpleDocker_StartExecInstance(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
-
+func TestSimpleDocker_ListContainers(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
-	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	// Download an image to create containers from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Create container
-	containerID, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	// Create a running container and a container that is never started
+	runningID, err := dockerClient.CreateContainerContext(context.Background(), "listrunning", "ubuntu", "20.04", []string{"sleep", "infinity"})
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// Run container
-	err = dockerClient.RunContainer(containerID)
+	err = dockerClient.RunContainerContext(context.Background(), runningID)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// Create exec instance
-	execId, err := dockerClient.GenerateExecInstance(containerID, []string{"uname"})
+	createdID, err := dockerClient.CreateContainerContext(context.Background(), "listcreated", "ubuntu", "20.04", []string{"sleep", "infinity"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	type args struct {
-		execInstanceID     string
-		isContainerRunning bool // This flag allows to keep running or stopping the container for testing purposes
-	}
+	// Every query filters by name, so containers not created by this test are left out on real daemons
 	tests := []struct {
 		name    string
-		args    args
-		want    string
-		wantErr bool
+		options ListContainersOptions
+		want    []string
+		wantErr error
 	}{
 		{
-			name: "Start a exec from an existing exec instace in a running container",
-			args: args{
-				execInstanceID:     execId,
-				isContainerRunning: true,
-			},
-			want:    "Linux\r\n",
+			name:    "List running containers",
+			options: ListContainersOptions{Filters: Filters{"name": {"^/list"}}},
+			want:    []string{runningID},
+		},
+		{
+			name:    "List all containers",
+			options: ListContainersOptions{All: true, Filters: Filters{"name": {"^/list"}}},
+			want:    []string{createdID, runningID},
+		},
+		{
+			name:    "List containers by status",
+			options: ListContainersOptions{Filters: Filters{"name": {"^/list"}, "status": {"created"}}},
+			want:    []string{createdID},
+		},
+		{
+			name:    "List containers by ancestor",
+			options: ListContainersOptions{All: true, Filters: Filters{"name": {"^/listrunning$"}, "ancestor": {"ubuntu:20.04"}}},
+			want:    []string{runningID},
+		},
+		{
+			name:    "List the last container created",
+			options: ListContainersOptions{Limit: 1, Filters: Filters{"name": {"^/list"}}},
+			want:    []string{createdID},
+		},
+		{
+			name:    "List containers with an invalid status",
+			options: ListContainersOptions{Filters: Filters{"status": {"sleeping"}}},
+			wantErr: ErrBadParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.ListContainers(context.Background(), tt.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SimpleDocker.ListContainers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotIDs []string
+			for _, container := range got {
+				gotIDs = append(gotIDs, container.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.want) {
+				t.Errorf("SimpleDocker.ListContainers() = %v, want %v", gotIDs, tt.want)
+			}
+		})
+	}
+
+	// Check the summary of the running container
+	containers, err := dockerClient.ListContainers(context.Background(), ListContainersOptions{Filters: Filters{"name": {"^/listrunning$"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 1 || containers[0].State != "running" || containers[0].Image != "ubuntu:20.04" ||
+		!reflect.DeepEqual(containers[0].Names, []string{"/listrunning"}) || !strings.HasPrefix(containers[0].Status, "Up") {
+		t.Errorf("SimpleDocker.ListContainers() = %+v, want the running container listrunning", containers)
+	}
+
+	// Remove containers
+	_, err = dockerClient.StopContainerContext(context.Background(), runningID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{runningID, createdID} {
+		err = dockerClient.RemoveContainerContext(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSimpleDocker_GenerateExecInstance(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	id, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		containerID string
+		commands    []string
+		isRunning   bool // This flag allows to keep running or stopping the container for testing purposes
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Create exec instance in a running container",
+			args: args{
+				containerID: id,
+				commands:    []string{"free", "-h"},
+				isRunning:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Try to create an exec instance in a stopped container",
+			args: args{
+				containerID: id,
+				commands:    []string{"free", "-h"},
+				isRunning:   false,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Try to create an exec instance in a non existent container",
+			args: args{
+				containerID: "fakefakefakefake",
+				commands:    []string{"free", "-h"},
+				isRunning:   true,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		if !tt.args.isRunning {
+			// Stop container
+			_, err = dockerClient.StopContainerContext(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.GenerateExecInstanceContext(context.Background(), tt.args.containerID, tt.args.commands)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.GenerateExecInstanceContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && len(got) == 0 {
+				t.Errorf("SimpleDocker.GenerateExecInstanceContext() = %v, want len(ID) > 0 ", got)
+			}
+		})
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_StartExecInstance(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create exec instance
+	execId, err := dockerClient.GenerateExecInstanceContext(context.Background(), containerID, []string{"uname"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		execInstanceID     string
+		isContainerRunning bool // This flag allows to keep running or stopping the container for testing purposes
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Start a exec from an existing exec instace in a running container",
+			args: args{
+				execInstanceID:     execId,
+				isContainerRunning: true,
+			},
+			want:    "Linux\r\n",
 			wantErr: false,
 		},
 		{
@@ -497,59 +1066,296 @@ func TestSimpleDocker_StartExecInstance(t *testing.T) {
 	for _, tt := range tests {
 		if !tt.args.isContainerRunning {
 			// Create new exec instance
-			execId, err := dockerClient.GenerateExecInstance(containerID, []string{"uname"})
+			execId, err := dockerClient.GenerateExecInstanceContext(context.Background(), containerID, []string{"uname"})
 			if err != nil {
 				t.Fatal(err)
 			}
 			tt.args.execInstanceID = execId
 
 			// Stop container
-			_, err = dockerClient.StopContainer(containerID)
+			_, err = dockerClient.StopContainerContext(context.Background(), containerID)
 			if err != nil {
 				t.Fatal(err)
 			}
 
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := dockerClient.StartExecInstance(tt.args.execInstanceID)
+			got, err := dockerClient.StartExecInstanceContext(context.Background(), tt.args.execInstanceID)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.StartExecInstance() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("SimpleDocker.StartExecInstanceContext() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("SimpleDocker.StartExecInstance() = %v, want %v", got, tt.want)
+				t.Errorf("SimpleDocker.StartExecInstanceContext() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 
 	// Remove container
-	err = dockerClient.RemoveContainer(containerID)
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
-func TestSimpleDocker_StopContainer(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
+func TestSimpleDocker_StartExecInstanceWithWriters(t *testing.T) {
+	// Create Docker client
+	dockerClient, server := newFakeDockerClient(t)
+	server.ExecHandler = func(containerID string, cmd []string, stdin string) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "out\n", Stderr: "err\n"}
+	}
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create and run container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		tty        bool
+		fakeExecID bool // This flag starts an exec instance that doesn't exist
+		wantStdout string
+		wantStderr string
+		wantErr    bool
+	}{
+		{
+			name:       "Start an exec instance without TTY",
+			tty:        false,
+			wantStdout: "out\n",
+			wantStderr: "err\n",
+			wantErr:    false,
+		},
+		{
+			name:       "Start an exec instance with TTY",
+			tty:        true,
+			wantStdout: "out\r\nerr\r\n",
+			wantStderr: "",
+			wantErr:    false,
+		},
+		{
+			name:       "Start a non existing exec instance",
+			fakeExecID: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execID := "fakefakefakefake"
+			if !tt.fakeExecID {
+				var err error
+				execID, err = dockerClient.GenerateExecInstanceWithOptions(context.Background(), containerID,
+					ExecOptions{Cmd: []string{"whatever"}, Tty: tt.tty})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var stdout, stderr strings.Builder
+			err := dockerClient.StartExecInstanceWithWriters(context.Background(), execID, tt.tty, &stdout, &stderr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.StartExecInstanceWithWriters() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if stdout.String() != tt.wantStdout || stderr.String() != tt.wantStderr {
+				t.Errorf("SimpleDocker.StartExecInstanceWithWriters() = %q %q, want %q %q",
+					stdout.String(), stderr.String(), tt.wantStdout, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_RunCommand(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		containerID  string
+		cmd          []string
+		wantStdout   string
+		wantExitCode int
+		wantErr      bool
+	}{
+		{
+			name:         "Run a command that succeeds",
+			containerID:  containerID,
+			cmd:          []string{"uname"},
+			wantStdout:   "Linux\n",
+			wantExitCode: 0,
+			wantErr:      false,
+		},
+		{
+			name:         "Run a command that fails",
+			containerID:  containerID,
+			cmd:          []string{"false"},
+			wantStdout:   "",
+			wantExitCode: 1,
+			wantErr:      false,
+		},
+		{
+			name:         "Run a command that doesn't exist",
+			containerID:  containerID,
+			cmd:          []string{"/bin/sh", "-c", "nonexistentcommand"},
+			wantStdout:   "",
+			wantExitCode: 127,
+			wantErr:      false,
+		},
+		{
+			name:        "Run a command in a non existing container",
+			containerID: "fakefakefakefake",
+			cmd:         []string{"uname"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.RunCommand(context.Background(), tt.containerID, ExecOptions{Cmd: tt.cmd})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.RunCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Stdout != tt.wantStdout || got.ExitCode != tt.wantExitCode) {
+				t.Errorf("SimpleDocker.RunCommand() = %q (exit code %d), want %q (exit code %d)",
+					got.Stdout, got.ExitCode, tt.wantStdout, tt.wantExitCode)
+			}
+		})
+	}
+
+	// Inspect an exec instance that doesn't exist
+	_, err = dockerClient.InspectExecInstance(context.Background(), "fakefakefakefake")
+	if !errors.Is(err, ErrExecInstanceDoesNotExist) {
+		t.Errorf("SimpleDocker.InspectExecInstance() error = %v, want %v", err, ErrExecInstanceDoesNotExist)
+	}
+
+	// Stop container
+	_, err = dockerClient.StopContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove container
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleDocker_Stats(t *testing.T) {
+	// Create Docker client
+	dockerClient, server := newFakeDockerClient(t)
+	server.StatsInterval = 10 * time.Millisecond
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
 
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ContainerStats{
+		CPUPercentage:    dockertest.FakeCPUPercentage,
+		MemoryUsage:      dockertest.FakeMemoryUsage,
+		MemoryLimit:      dockertest.FakeMemoryLimit,
+		MemoryPercentage: float64(dockertest.FakeMemoryUsage) / dockertest.FakeMemoryLimit * 100,
+		NetworkRx:        dockertest.FakeNetworkRx,
+		NetworkTx:        dockertest.FakeNetworkTx,
+		BlockRead:        dockertest.FakeBlockRead,
+		BlockWrite:       dockertest.FakeBlockWrite,
+		PIDs:             1,
+	}
+
+	// One-shot stats
+	got, err := dockerClient.Stats(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Read = time.Time{} // The time of the sample is not known upfront
+	if *got != want {
+		t.Errorf("SimpleDocker.Stats() = %+v, want %+v", *got, want)
+	}
+
+	// Streaming stats, until a few samples are received
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var samples int
+	err = dockerClient.StreamStats(ctx, containerID, func(stats ContainerStats) {
+		samples++
+		if stats.CPUPercentage != want.CPUPercentage {
+			t.Errorf("SimpleDocker.StreamStats() CPU = %v, want %v", stats.CPUPercentage, want.CPUPercentage)
+		}
+		if samples == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) || samples != 3 {
+		t.Errorf("SimpleDocker.StreamStats() error = %v after %d samples, want %v after 3 samples", err, samples, context.Canceled)
+	}
+
+	// Stats of a container that doesn't exist
+	_, err = dockerClient.Stats(context.Background(), "fakefakefakefake")
+	if !errors.Is(err, ErrContainerDoesNotExist) {
+		t.Errorf("SimpleDocker.Stats() error = %v, want %v", err, ErrContainerDoesNotExist)
+	}
+}
+
+func TestSimpleDocker_StopContainer(t *testing.T) {
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Create container
-	containerID, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Run container
-	err = dockerClient.RunContainer(containerID)
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -581,45 +1387,42 @@ func TestSimpleDocker_StopContainer(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := dockerClient.StopContainer(tt.containerID)
+			got, err := dockerClient.StopContainerContext(context.Background(), tt.containerID)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.StopContainer() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("SimpleDocker.StopContainerContext() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("SimpleDocker.StopContainer() = %v, want %v", got, tt.want)
+				t.Errorf("SimpleDocker.StopContainerContext() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 
 	// Remove container
-	err = dockerClient.RemoveContainer(containerID)
+	err = dockerClient.RemoveContainerContext(context.Background(), containerID)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
 func TestSimpleDocker_RemoveContainer(t *testing.T) {
-	// Create HTTP client
-	httpClient := httpclient.NewSimpleHttpClient()
-
 	// Create Docker client
-	dockerClient := NewSimpeDocker(DockerEndpoint, httpClient)
+	dockerClient := newTestDockerClient(t)
 
 	// Download an image to create a container from
-	err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64")
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Create container
-	containerID, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Run container
-	err = dockerClient.RunContainer(containerID)
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -661,15 +1464,254 @@ func TestSimpleDocker_RemoveContainer(t *testing.T) {
 	for _, tt := range tests {
 		if !tt.args.isContainerRunning {
 			// Stop container
-			_, err = dockerClient.StopContainer(containerID)
+			_, err = dockerClient.StopContainerContext(context.Background(), containerID)
 			if err != nil {
 				t.Fatal(err)
 			}
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			if err := dockerClient.RemoveContainer(tt.args.containerID); (err != nil) != tt.wantErr {
-				t.Errorf("SimpleDocker.RemoveContainer() error = %v, wantErr %v", err, tt.wantErr)
+			if err := dockerClient.RemoveContainerContext(context.Background(), tt.args.containerID); (err != nil) != tt.wantErr {
+				t.Errorf("SimpleDocker.RemoveContainerContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_RemoveContainerWithOptions(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// Download an image to create a container from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create container
+	containerID, err := dockerClient.CreateContainerContext(context.Background(), "ubuntuforce", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run container
+	err = dockerClient.RunContainerContext(context.Background(), containerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cases run in order, since the container is removed by the last successful one
+	tests := []struct {
+		name        string
+		containerID string
+		options     RemoveContainerOptions
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "Try to remove a running container without force",
+			containerID: containerID,
+			options:     RemoveContainerOptions{RemoveVolumes: true},
+			wantErr:     true,
+			wantErrIs:   ErrContainerIsRunning,
+		},
+		{
+			name:        "Force the removal of a running container alongside its volumes",
+			containerID: containerID,
+			options:     RemoveContainerOptions{Force: true, RemoveVolumes: true},
+			wantErr:     false,
+		},
+		{
+			name:        "Force the removal of a non existing container",
+			containerID: "fakefakefakefake",
+			options:     RemoveContainerOptions{Force: true},
+			wantErr:     true,
+			wantErrIs:   ErrContainerDoesNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dockerClient.RemoveContainerWithOptions(context.Background(), tt.containerID, tt.options)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.RemoveContainerWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_PruneContainers(t *testing.T) {
+	// Pruning removes every stopped container of the daemon, so it only runs against the fake one
+	dockerClient, server := newFakeDockerClient(t)
+
+	// Download an image to create containers from
+	err := dockerClient.PullImageFromRegistryContext(context.Background(), "ubuntu", "20.04", "x86-64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a running container, which is never pruned, and three stopped ones with different labels and sizes
+	running, err := dockerClient.CreateContainerContext(context.Background(), "ubunturunning", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dockerClient.RunContainerContext(context.Background(), running); err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(map[string]string)
+	for name, label := range map[string]string{"ubuntudev": "dev", "ubuntuprod": "prod", "ubuntutest": "test"} {
+		id, err := dockerClient.CreateContainerWithOptions(context.Background(), name,
+			ContainerOptions{Image: "ubuntu:20.04", Labels: map[string]string{"env": label}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.SetContainerSize(id, 1000)
+		stopped[label] = id
+	}
+
+	tests := []struct {
+		name          string
+		filters       Filters
+		wantDeleted   []string
+		wantReclaimed uint64
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name:      "Prune with an invalid filter",
+			filters:   Filters{"status": {"exited"}},
+			wantErr:   true,
+			wantErrIs: ErrBadParameter,
+		},
+		{
+			name:          "Prune the containers created more than an hour ago",
+			filters:       Filters{"until": {"1h"}},
+			wantDeleted:   []string{},
+			wantReclaimed: 0,
+		},
+		{
+			name:          "Prune the containers with a label",
+			filters:       Filters{"label": {"env=dev"}},
+			wantDeleted:   []string{stopped["dev"]},
+			wantReclaimed: 1000,
+		},
+		{
+			name:          "Prune the containers without a label",
+			filters:       Filters{"label!": {"env=prod"}},
+			wantDeleted:   []string{stopped["test"]},
+			wantReclaimed: 1000,
+		},
+		{
+			name:          "Prune every stopped container",
+			filters:       nil,
+			wantDeleted:   []string{stopped["prod"]},
+			wantReclaimed: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerClient.PruneContainers(context.Background(), tt.filters)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("SimpleDocker.PruneContainers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.ContainersDeleted, tt.wantDeleted) || got.SpaceReclaimed != tt.wantReclaimed {
+				t.Errorf("SimpleDocker.PruneContainers() = %+v, want %v reclaiming %d", got, tt.wantDeleted, tt.wantReclaimed)
 			}
 		})
 	}
+
+	// The running container must have been kept
+	if status, _ := server.ContainerStatus(running); status != "running" {
+		t.Errorf("running container status = %q, want running", status)
+	}
+}
+
+func TestSimpleDocker_ContextErrors(t *testing.T) {
+	// A daemon that never answers, so queries only finish when their context is done
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	dockerClient := NewSimpeDocker(server.URL, httpclient.NewSimpleHttpClient())
+
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		cancel    bool
+		call      func(ctx context.Context) error
+		wantErrIs error
+	}{
+		{
+			name:    "Inspect a container past the deadline",
+			timeout: 50 * time.Millisecond,
+			call: func(ctx context.Context) error {
+				_, err := dockerClient.InspectContainer(ctx, "ubuntu2004")
+				return err
+			},
+			wantErrIs: context.DeadlineExceeded,
+		},
+		{
+			name:    "Pull an image past the deadline",
+			timeout: 50 * time.Millisecond,
+			call: func(ctx context.Context) error {
+				return dockerClient.PullImageWithProgress(ctx, "ubuntu", "20.04", "x86-64", nil)
+			},
+			wantErrIs: context.DeadlineExceeded,
+		},
+		{
+			name:    "List containers with a cancelled context",
+			timeout: time.Minute,
+			cancel:  true,
+			call: func(ctx context.Context) error {
+				_, err := dockerClient.ListContainers(ctx, ListContainersOptions{})
+				return err
+			},
+			wantErrIs: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			err := tt.call(ctx)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func TestSimpleDocker_ContextFreeVariants(t *testing.T) {
+	// Create Docker client
+	dockerClient := newTestDockerClient(t)
+
+	// The methods without context run the whole lifecycle of a container the same way their Context variants do
+	if err := dockerClient.PullImageFromRegistry("ubuntu", "20.04", "x86-64"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := dockerClient.CheckIfImageAlreadyExists("ubuntu", "20.04"); err != nil || !exists {
+		t.Fatalf("SimpleDocker.CheckIfImageAlreadyExists() = %v, %v, want true", exists, err)
+	}
+	id, err := dockerClient.CreateContainer("ubuntu2004", "ubuntu", "20.04", []string{"sleep", "infinity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dockerClient.RunContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	if ready, err := dockerClient.CheckIfContainerIsReady(id); err != nil || !ready {
+		t.Errorf("SimpleDocker.CheckIfContainerIsReady() = %v, %v, want true", ready, err)
+	}
+	if stopped, err := dockerClient.StopContainer(id); err != nil || !stopped {
+		t.Errorf("SimpleDocker.StopContainer() = %v, %v, want true", stopped, err)
+	}
+	if err := dockerClient.RemoveContainer(id); err != nil {
+		t.Errorf("SimpleDocker.RemoveContainer() error = %v", err)
+	}
 }
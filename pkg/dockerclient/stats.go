@@ -0,0 +1,99 @@
+package dockerclient
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+// ContainerStats is the resource usage of a container, computed from a daemon stats sample the way `docker stats` does
+type ContainerStats struct {
+	// Read is the time the sample was taken
+	Read time.Time
+	// CPUPercentage is the CPU usage since the previous sample. 100% means one whole core
+	CPUPercentage float64
+	// MemoryUsage is the memory used by the container in bytes, page cache excluded
+	MemoryUsage uint64
+	// MemoryLimit is the memory limit of the container in bytes
+	MemoryLimit uint64
+	// MemoryPercentage is MemoryUsage against MemoryLimit
+	MemoryPercentage float64
+	// NetworkRx is the amount of bytes received on every interface
+	NetworkRx uint64
+	// NetworkTx is the amount of bytes sent on every interface
+	NetworkTx uint64
+	// BlockRead is the amount of bytes read from block devices
+	BlockRead uint64
+	// BlockWrite is the amount of bytes written to block devices
+	BlockWrite uint64
+	// PIDs is the amount of processes running in the container
+	PIDs uint64
+}
+
+// StatsFunc is called for every stats sample received when streaming the stats of a container
+type StatsFunc func(stats ContainerStats)
+
+// calculateStats computes the resource usage of a container from the raw sample sent by the daemon
+func calculateStats(sample *models.StatsResponseBody) ContainerStats {
+	stats := ContainerStats{
+		Read:          sample.Read,
+		CPUPercentage: cpuPercentage(sample),
+		MemoryUsage:   memoryUsage(sample),
+		MemoryLimit:   sample.MemoryStats.Limit,
+		PIDs:          sample.PidsStats.Current,
+	}
+
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercentage = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+
+	for _, network := range sample.Networks {
+		stats.NetworkRx += network.RxBytes
+		stats.NetworkTx += network.TxBytes
+	}
+
+	for _, entry := range sample.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+
+	return stats
+}
+
+// cpuPercentage computes the CPU usage between the previous and the current sample
+func cpuPercentage(sample *models.StatsResponseBody) float64 {
+	// Counters may go backwards (e.g. container restarted), so deltas are only computed when they grow
+	if sample.CPUStats.CPUUsage.TotalUsage <= sample.PreCPUStats.CPUUsage.TotalUsage ||
+		sample.CPUStats.SystemUsage <= sample.PreCPUStats.SystemUsage {
+		return 0
+	}
+	cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage - sample.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(sample.CPUStats.SystemUsage - sample.PreCPUStats.SystemUsage)
+
+	onlineCPUs := float64(sample.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 { // Older daemons only report per CPU usage
+		onlineCPUs = float64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// memoryUsage returns the memory used by the container without the page cache, which the kernel can reclaim anytime
+func memoryUsage(sample *models.StatsResponseBody) uint64 {
+	usage := sample.MemoryStats.Usage
+
+	// cgroup v1 reports total_inactive_file, while cgroup v2 reports inactive_file
+	cache, ok := sample.MemoryStats.Stats["total_inactive_file"]
+	if !ok {
+		cache = sample.MemoryStats.Stats["inactive_file"]
+	}
+	if cache < usage {
+		return usage - cache
+	}
+	return usage
+}
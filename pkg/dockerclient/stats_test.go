@@ -0,0 +1,85 @@
+package dockerclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mikeletux/go-docker-manager/pkg/dockerclient/models"
+)
+
+func TestCalculateStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   ContainerStats
+	}{
+		{
+			name: "cgroup v1 sample",
+			sample: `{
+				"cpu_stats": {"cpu_usage": {"total_usage": 300000000, "percpu_usage": [150000000, 150000000, 0, 0]}, "system_cpu_usage": 8000000000},
+				"precpu_stats": {"cpu_usage": {"total_usage": 100000000}, "system_cpu_usage": 4000000000},
+				"memory_stats": {"usage": 1000, "limit": 4000, "stats": {"total_inactive_file": 200, "inactive_file": 100}},
+				"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+				"blkio_stats": {"io_service_bytes_recursive": [{"op": "Read", "value": 30}, {"op": "Write", "value": 40}, {"op": "Total", "value": 70}]},
+				"pids_stats": {"current": 3}
+			}`,
+			want: ContainerStats{
+				CPUPercentage:    20, // 0.2s out of 4s on 4 CPUs
+				MemoryUsage:      800,
+				MemoryLimit:      4000,
+				MemoryPercentage: 20,
+				NetworkRx:        11,
+				NetworkTx:        22,
+				BlockRead:        30,
+				BlockWrite:       40,
+				PIDs:             3,
+			},
+		},
+		{
+			name: "cgroup v2 sample",
+			sample: `{
+				"cpu_stats": {"cpu_usage": {"total_usage": 500000000}, "system_cpu_usage": 2000000000, "online_cpus": 2},
+				"precpu_stats": {"cpu_usage": {"total_usage": 0}, "system_cpu_usage": 1000000000},
+				"memory_stats": {"usage": 1000, "limit": 2000, "stats": {"inactive_file": 500}},
+				"blkio_stats": {"io_service_bytes_recursive": [{"op": "read", "value": 5}, {"op": "write", "value": 6}]}
+			}`,
+			want: ContainerStats{
+				CPUPercentage:    100, // 0.5s out of 1s on 2 CPUs
+				MemoryUsage:      500,
+				MemoryLimit:      2000,
+				MemoryPercentage: 25,
+				BlockRead:        5,
+				BlockWrite:       6,
+			},
+		},
+		{
+			name: "First sample without previous CPU usage",
+			sample: `{
+				"cpu_stats": {"cpu_usage": {"total_usage": 500000000}, "system_cpu_usage": 2000000000, "online_cpus": 2},
+				"memory_stats": {"usage": 1000, "limit": 2000}
+			}`,
+			want: ContainerStats{
+				CPUPercentage:    50, // Measured since the container started
+				MemoryUsage:      1000,
+				MemoryLimit:      2000,
+				MemoryPercentage: 50,
+			},
+		},
+		{
+			name:   "Stopped container sample",
+			sample: `{"cpu_stats": {"cpu_usage": {"total_usage": 0}}, "precpu_stats": {"cpu_usage": {"total_usage": 0}}, "memory_stats": {}}`,
+			want:   ContainerStats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sample models.StatsResponseBody
+			if err := json.Unmarshal([]byte(tt.sample), &sample); err != nil {
+				t.Fatal(err)
+			}
+			if got := calculateStats(&sample); got != tt.want {
+				t.Errorf("calculateStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package dockerclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StdType is the stream identifier found in the header of every multiplexed stream frame
+type StdType byte
+
+// Stream identifiers used by docker to multiplex several streams on a single connection
+const (
+	Stdin     StdType = 0
+	Stdout    StdType = 1
+	Stderr    StdType = 2
+	Systemerr StdType = 3 // Systemerr frames carry errors from the daemon itself
+)
+
+// stdHeaderSize is the size of the header of every frame: stream type, 3 bytes of padding and the frame size (uint32 big endian)
+const stdHeaderSize = 8
+
+/* StdCopy demultiplexes the stream docker sends when no TTY is allocated (exec, logs, attach), writing
+stdout frames to dstout and stderr frames to dsterr until src is exhausted. It returns the amount of bytes written.
+It is compatible with github.com/docker/docker/pkg/stdcopy.StdCopy */
+func StdCopy(dstout io.Writer, dsterr io.Writer, src io.Reader) (int64, error) {
+	var written int64
+	header := make([]byte, stdHeaderSize)
+
+	for {
+		_, err := io.ReadFull(src, header)
+		if errors.Is(err, io.EOF) {
+			return written, nil // The stream finished in between two frames
+		}
+		if err != nil {
+			return written, fmt.Errorf("cannot read stream frame header - %w", err)
+		}
+
+		frameSize := int64(binary.BigEndian.Uint32(header[4:]))
+
+		var dst io.Writer
+		switch StdType(header[0]) {
+		case Stdin, Stdout:
+			dst = dstout
+		case Stderr:
+			dst = dsterr
+		case Systemerr:
+			message, err := io.ReadAll(io.LimitReader(src, frameSize))
+			if err != nil {
+				return written, fmt.Errorf("cannot read error sent by the docker daemon - %w", err)
+			}
+			return written, fmt.Errorf("error from docker daemon in stream: %s", message)
+		default:
+			return written, fmt.Errorf("unrecognized stream type %d in frame header", header[0])
+		}
+
+		if dst == nil {
+			dst = io.Discard
+		}
+		n, err := io.CopyN(dst, src, frameSize)
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("cannot copy stream frame of %d bytes - %w", frameSize, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package dockerclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// frame builds a multiplexed stream frame
+func frame(stream StdType, data string) []byte {
+	header := make([]byte, stdHeaderSize)
+	header[0] = byte(stream)
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func TestStdCopy(t *testing.T) {
+	tests := []struct {
+		name       string
+		stream     []byte
+		wantStdout string
+		wantStderr string
+		wantErr    bool
+	}{
+		{
+			name:       "Interleaved stdout and stderr frames",
+			stream:     bytes.Join([][]byte{frame(Stdout, "out 1\n"), frame(Stderr, "err 1\n"), frame(Stdout, "out 2\n")}, nil),
+			wantStdout: "out 1\nout 2\n",
+			wantStderr: "err 1\n",
+			wantErr:    false,
+		},
+		{
+			name:       "Empty stream",
+			stream:     nil,
+			wantStdout: "",
+			wantStderr: "",
+			wantErr:    false,
+		},
+		{
+			name:       "Error sent by the daemon",
+			stream:     bytes.Join([][]byte{frame(Stdout, "out\n"), frame(Systemerr, "boom")}, nil),
+			wantStdout: "out\n",
+			wantStderr: "",
+			wantErr:    true,
+		},
+		{
+			name:       "Truncated frame",
+			stream:     frame(Stdout, "truncated")[:stdHeaderSize+3],
+			wantStdout: "tru",
+			wantStderr: "",
+			wantErr:    true,
+		},
+		{
+			name:       "Unknown stream type",
+			stream:     frame(StdType(7), "data"),
+			wantStdout: "",
+			wantStderr: "",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			written, err := StdCopy(&stdout, &stderr, bytes.NewReader(tt.stream))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StdCopy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if stdout.String() != tt.wantStdout || stderr.String() != tt.wantStderr {
+				t.Errorf("StdCopy() = %q %q, want %q %q", stdout.String(), stderr.String(), tt.wantStdout, tt.wantStderr)
+			}
+			if written != int64(stdout.Len()+stderr.Len()) {
+				t.Errorf("StdCopy() written = %d, want %d", written, stdout.Len()+stderr.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+/* Package units formats quantities the way the docker CLI and daemon show them to people, so the fake daemon
+and the command line print the same values */
+package units
+
+import (
+	"fmt"
+	"time"
+)
+
+// HumanDuration formats d the way docker does (e.g. "2 minutes", "About an hour")
+func HumanDuration(d time.Duration) string {
+	switch seconds := int(d.Seconds()); {
+	case seconds < 1:
+		return "Less than a second"
+	case seconds == 1:
+		return "1 second"
+	case seconds < 60:
+		return fmt.Sprintf("%d seconds", seconds)
+	case d.Minutes() < 2:
+		return "About a minute"
+	case d.Minutes() < 60:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d.Hours() < 2:
+		return "About an hour"
+	case d.Hours() < 48:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	case d.Hours() < 24*7*2:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	case d.Hours() < 24*30*2:
+		return fmt.Sprintf("%d weeks", int(d.Hours()/24/7))
+	case d.Hours() < 24*365*2:
+		return fmt.Sprintf("%d months", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%d years", int(d.Hours()/24/365))
+	}
+}
@@ -0,0 +1,33 @@
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		want     string
+	}{
+		{name: "Less than a second", duration: 500 * time.Millisecond, want: "Less than a second"},
+		{name: "One second", duration: time.Second, want: "1 second"},
+		{name: "Seconds", duration: 42 * time.Second, want: "42 seconds"},
+		{name: "About a minute", duration: 90 * time.Second, want: "About a minute"},
+		{name: "Minutes", duration: 5 * time.Minute, want: "5 minutes"},
+		{name: "About an hour", duration: 90 * time.Minute, want: "About an hour"},
+		{name: "Hours", duration: 30 * time.Hour, want: "30 hours"},
+		{name: "Days", duration: 3 * 24 * time.Hour, want: "3 days"},
+		{name: "Weeks", duration: 21 * 24 * time.Hour, want: "3 weeks"},
+		{name: "Months", duration: 90 * 24 * time.Hour, want: "3 months"},
+		{name: "Years", duration: 3 * 365 * 24 * time.Hour, want: "3 years"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HumanDuration(tt.duration); got != tt.want {
+				t.Errorf("HumanDuration() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
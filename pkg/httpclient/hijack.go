@@ -0,0 +1,233 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxHijackErrorBodySize caps how much body is read when the server refuses to upgrade a connection
+const maxHijackErrorBodySize = 64 * 1024
+
+/* HijackedResponse is the response of a query which connection has been taken over once the server upgraded it.
+Reading and writing go straight through the raw connection, so it can be used for bidirectional streams */
+type HijackedResponse struct {
+	// Code is the response code from the HTTP request
+	StatusCode int
+	// Header holds the response headers
+	Header http.Header
+	// Body holds the response body when the server did not upgrade the connection. Empty otherwise
+	Body []byte
+
+	conn      net.Conn
+	reader    *bufio.Reader // reader may hold data already received after the response headers
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Upgraded tells if the server upgraded the connection, so it can be read from and written to
+func (h *HijackedResponse) Upgraded() bool {
+	return h.conn != nil
+}
+
+// Read reads from the raw connection
+func (h *HijackedResponse) Read(p []byte) (int, error) {
+	if h.conn == nil {
+		return 0, io.EOF
+	}
+	return h.reader.Read(p)
+}
+
+// Write writes to the raw connection
+func (h *HijackedResponse) Write(p []byte) (int, error) {
+	if h.conn == nil {
+		return 0, errors.New("the connection was not upgraded")
+	}
+	return h.conn.Write(p)
+}
+
+// CloseWrite shuts down the writing side of the connection, telling the server no more data will be sent
+func (h *HijackedResponse) CloseWrite() error {
+	if closeWriter, ok := h.conn.(interface{ CloseWrite() error }); ok {
+		return closeWriter.CloseWrite()
+	}
+	return errors.New("the connection does not support half close")
+}
+
+// Close closes the connection
+func (h *HijackedResponse) Close() error {
+	var err error
+	h.closeOnce.Do(func() {
+		if h.closed != nil {
+			close(h.closed)
+		}
+		if h.conn != nil {
+			err = h.conn.Close()
+		}
+	})
+	return err
+}
+
+/* Hijack performs a HTTP query using method against an urlEndpoint using HTTP headers and a body, asking the server
+to upgrade the connection to a raw TCP stream. The connection is closed as soon as ctx is done.
+It returns a HijackedResponse which must be closed */
+func (s *SimpleHttpClient) Hijack(ctx context.Context, method string, urlEndpoint string, headers map[string]string, body string) (*HijackedResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlEndpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	conn, err := s.dial(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Until the connection is upgraded, ctx bounds every read and write on it, the TLS handshake included
+	stopWatching := watchContext(ctx, conn)
+	if req.URL.Scheme == "https" {
+		if conn, err = s.handshake(conn, req.URL); err != nil {
+			stopWatching()
+			return nil, contextError(ctx, err)
+		}
+	}
+
+	if err := req.Write(conn); err != nil {
+		stopWatching()
+		conn.Close()
+		return nil, contextError(ctx, err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		stopWatching()
+		conn.Close()
+		return nil, contextError(ctx, err)
+	}
+
+	// Only 101 switches protocols: any other answer, 200 included, is a plain response the connection ends with
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer conn.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxHijackErrorBodySize)) // A partial body still gives a useful message
+		stopWatching()
+		return &HijackedResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}, nil
+	}
+	stopWatching()
+
+	hijackedResponse := &HijackedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		conn:       conn,
+		reader:     reader,
+		closed:     make(chan struct{}),
+	}
+
+	// The connection outlives the request, so it has to be closed by hand when ctx is done
+	go func() {
+		select {
+		case <-ctx.Done():
+			hijackedResponse.Close()
+		case <-hijackedResponse.closed:
+		}
+	}()
+
+	return hijackedResponse, nil
+}
+
+// transport returns the transport of the HTTP client, whose settings the hijacked connections follow
+func (s *SimpleHttpClient) transport() *http.Transport {
+	transport, ok := s.HttpClient.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	return transport
+}
+
+/* dial opens a connection to the server in u, the same way the HTTP client transport would (unix socket or TCP).
+Connections to https servers still need the TLS handshake */
+func (s *SimpleHttpClient) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	dialContext := s.transport().DialContext
+	if dialContext == nil {
+		var dialer net.Dialer
+		dialContext = dialer.DialContext
+	}
+	return dialContext(ctx, "tcp", hostAddress(u))
+}
+
+// hostAddress returns the host:port address of the server in u, the port defaulting to the one of the scheme
+func hostAddress(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// handshake runs the TLS handshake with the server in u over conn, using the TLS settings of the transport. conn is closed if it fails
+func (s *SimpleHttpClient) handshake(conn net.Conn, u *url.URL) (net.Conn, error) {
+	transport := s.transport()
+	tlsConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with %s failed - %w", hostAddress(u), err)
+	}
+	return tlsConn, nil
+}
+
+/* watchContext binds the reads and writes on conn to ctx until the function returned is called: the deadline of ctx
+applies to them, and they fail as soon as ctx is done. The function returned clears the deadline of conn */
+func watchContext(ctx context.Context, conn net.Conn) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Unix(1, 0)) // A deadline in the past makes pending reads and writes fail right away
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+		conn.SetDeadline(time.Time{})
+	}
+}
+
+// contextError returns the error of ctx if it is done, since it is what made the I/O error err happen, or err otherwise
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSimpleHttpClient_Hijack(t *testing.T) {
+	// The server upgrades the connection and echoes back everything it receives until the client half closes it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "tcp" {
+			w.WriteHeader(400)
+			w.Write([]byte(`{"message":"upgrade required"}`))
+			return
+		}
+
+		conn, buffer, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buffer.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		buffer.Flush()
+		io.Copy(conn, buffer)
+	}))
+	defer server.Close()
+
+	httpClient := NewSimpleHttpClient()
+
+	resp, err := httpClient.Hijack(context.Background(), "POST", server.URL, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+
+	if resp.StatusCode != 101 || !resp.Upgraded() {
+		t.Fatalf("SimpleHttpClient.Hijack() = %d (upgraded %v), want 101 (upgraded true)", resp.StatusCode, resp.Upgraded())
+	}
+
+	if _, err := resp.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+
+	echo, err := io.ReadAll(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(echo) != "hello" {
+		t.Errorf("SimpleHttpClient.Hijack() echo = %q, want %q", echo, "hello")
+	}
+}
+
+func TestSimpleHttpClient_HijackRefused(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+	}{
+		{name: "Not found", statusCode: 404, body: `{"message":"No such exec instance"}`},
+		{name: "OK without upgrade", statusCode: 200, body: `{"message":"not upgraded"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			httpClient := NewSimpleHttpClient()
+
+			resp, err := httpClient.Hijack(context.Background(), "POST", server.URL, nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Close()
+
+			if resp.StatusCode != tt.statusCode || resp.Upgraded() || string(resp.Body) != tt.body {
+				t.Errorf("SimpleHttpClient.Hijack() = %d %q (upgraded %v), want %d %q (upgraded false)",
+					resp.StatusCode, resp.Body, resp.Upgraded(), tt.statusCode, tt.body)
+			}
+		})
+	}
+}
+
+func TestSimpleHttpClient_HijackStalled(t *testing.T) {
+	// The server accepts connections but never answers, neither the TLS handshake nor the upgrade request
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		name      string
+		scheme    string
+		cancel    bool
+		wantErrIs error
+	}{
+		{name: "TLS handshake past the deadline", scheme: "https", wantErrIs: context.DeadlineExceeded},
+		{name: "TLS handshake cancelled", scheme: "https", cancel: true, wantErrIs: context.Canceled},
+		{name: "Upgrade request past the deadline", scheme: "http", wantErrIs: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			_, err := NewSimpleHttpClient().Hijack(ctx, "POST", tt.scheme+"://"+listener.Addr().String(), nil, "")
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("SimpleHttpClient.Hijack() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("SimpleHttpClient.Hijack() returned after %v, want it to return once ctx is done", elapsed)
+			}
+		})
+	}
+}
@@ -1,19 +1,63 @@
 package httpclient
 
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
 type HttpResponse struct {
 	// Code is the response code from the HTTP request
 	StatusCode int
+	// Header holds the response headers
+	Header http.Header
 	// Body is the returned body from the HTTP query
 	Body []byte
 }
 
+/* HttpStreamResponse is the response of a streaming query. The body is not read upfront, so it
+can be consumed as it arrives. Body must always be closed by the caller */
+type HttpStreamResponse struct {
+	// Code is the response code from the HTTP request
+	StatusCode int
+	// Header holds the response headers
+	Header http.Header
+	// Body is the stream of the returned body
+	Body io.ReadCloser
+}
+
 type HttpClient interface {
 	// Get performs a HTTP GET method agains an urlEndpoint using HTTP headers. It returns an HttpResponse
 	Get(urlEndpoint string, headers map[string]string) (*HttpResponse, error)
 
+	// GetContext is Get bound to ctx, so the request can be cancelled or given a deadline
+	GetContext(ctx context.Context, urlEndpoint string, headers map[string]string) (*HttpResponse, error)
+
 	// Post performs a HTTP POST method agains an urlEndpoint using HTTP headers and a body. It returns an HttpResponse
 	Post(urlEndpoint string, headers map[string]string, body string) (*HttpResponse, error)
 
+	// PostContext is Post bound to ctx, so the request can be cancelled or given a deadline
+	PostContext(ctx context.Context, urlEndpoint string, headers map[string]string, body string) (*HttpResponse, error)
+
 	// Delete performs a HTTP DELETE method agains an urlEndpoint using HTTP headers. It returns an HttpResponse
 	Delete(urlEndpoint string, headers map[string]string) (*HttpResponse, error)
+
+	// DeleteContext is Delete bound to ctx, so the request can be cancelled or given a deadline
+	DeleteContext(ctx context.Context, urlEndpoint string, headers map[string]string) (*HttpResponse, error)
+
+	/* Put performs a HTTP PUT method agains an urlEndpoint using HTTP headers and a body, which is streamed as it is read
+	so large uploads are not held in memory. The request is bound to ctx. It returns an HttpResponse */
+	Put(ctx context.Context, urlEndpoint string, headers map[string]string, body io.Reader) (*HttpResponse, error)
+
+	// Head performs a HTTP HEAD method agains an urlEndpoint using HTTP headers. The request is bound to ctx. It returns an HttpResponse with no body
+	Head(ctx context.Context, urlEndpoint string, headers map[string]string) (*HttpResponse, error)
+
+	/* Stream performs a HTTP query using method against an urlEndpoint using HTTP headers and a body (it can be nil).
+	The request is bound to ctx, and so is reading the response body. It returns an HttpStreamResponse which body must be closed */
+	Stream(ctx context.Context, method string, urlEndpoint string, headers map[string]string, body io.Reader) (*HttpStreamResponse, error)
+
+	/* Hijack performs a HTTP query using method against an urlEndpoint using HTTP headers and a body, asking the server
+	to upgrade the connection to a raw TCP stream. The connection is closed as soon as ctx is done.
+	It returns a HijackedResponse which must be closed */
+	Hijack(ctx context.Context, method string, urlEndpoint string, headers map[string]string, body string) (*HijackedResponse, error)
 }
@@ -1,6 +1,7 @@
 package httpclient
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -15,35 +16,62 @@ func NewSimpleHttpClient() *SimpleHttpClient {
 	return &SimpleHttpClient{HttpClient: &http.Client{}}
 }
 
-// Get performs a HTTP GET method agains an urlEndpoint using HTTP headers. It returns an HttpResponse
+// Get is GetContext with a background context, which cannot be cancelled
 func (s *SimpleHttpClient) Get(urlEndpoint string, headers map[string]string) (*HttpResponse, error) {
-	return s.runRequest(urlEndpoint, "GET", headers, "")
+	return s.GetContext(context.Background(), urlEndpoint, headers)
 }
 
-// Post performs a HTTP POST method agains an urlEndpoint using HTTP headers and a body. It returns an HttpResponse
+// GetContext performs a HTTP GET method agains an urlEndpoint using HTTP headers. The request is bound to ctx. It returns an HttpResponse
+func (s *SimpleHttpClient) GetContext(ctx context.Context, urlEndpoint string, headers map[string]string) (*HttpResponse, error) {
+	return s.runRequest(ctx, urlEndpoint, "GET", headers, nil)
+}
+
+// Post is PostContext with a background context, which cannot be cancelled
 func (s *SimpleHttpClient) Post(urlEndpoint string, headers map[string]string, body string) (*HttpResponse, error) {
-	return s.runRequest(urlEndpoint, "POST", headers, body)
+	return s.PostContext(context.Background(), urlEndpoint, headers, body)
+}
+
+// PostContext performs a HTTP POST method agains an urlEndpoint using HTTP headers and a body. The request is bound to ctx. It returns an HttpResponse
+func (s *SimpleHttpClient) PostContext(ctx context.Context, urlEndpoint string, headers map[string]string, body string) (*HttpResponse, error) {
+	return s.runRequest(ctx, urlEndpoint, "POST", headers, strings.NewReader(body))
 }
 
-// Delete performs a HTTP DELETE method agains an urlEndpoint using HTTP headers. It returns an HttpResponse
+// Delete is DeleteContext with a background context, which cannot be cancelled
 func (s *SimpleHttpClient) Delete(urlEndpoint string, headers map[string]string) (*HttpResponse, error) {
-	return s.runRequest(urlEndpoint, "DELETE", headers, "")
+	return s.DeleteContext(context.Background(), urlEndpoint, headers)
 }
 
-func (s *SimpleHttpClient) runRequest(urlEndpoint string, method string, headers map[string]string, body string) (*HttpResponse, error) {
-	// Create the HTTP Request
-	req, err := http.NewRequest(method, urlEndpoint, strings.NewReader(body))
+// DeleteContext performs a HTTP DELETE method agains an urlEndpoint using HTTP headers. The request is bound to ctx. It returns an HttpResponse
+func (s *SimpleHttpClient) DeleteContext(ctx context.Context, urlEndpoint string, headers map[string]string) (*HttpResponse, error) {
+	return s.runRequest(ctx, urlEndpoint, "DELETE", headers, nil)
+}
+
+/* Put performs a HTTP PUT method agains an urlEndpoint using HTTP headers and a body, which is streamed as it is read
+so large uploads are not held in memory. The request is bound to ctx. It returns an HttpResponse */
+func (s *SimpleHttpClient) Put(ctx context.Context, urlEndpoint string, headers map[string]string, body io.Reader) (*HttpResponse, error) {
+	return s.runRequest(ctx, urlEndpoint, "PUT", headers, body)
+}
+
+// Head performs a HTTP HEAD method agains an urlEndpoint using HTTP headers. The request is bound to ctx. It returns an HttpResponse with no body
+func (s *SimpleHttpClient) Head(ctx context.Context, urlEndpoint string, headers map[string]string) (*HttpResponse, error) {
+	return s.runRequest(ctx, urlEndpoint, "HEAD", headers, nil)
+}
+
+/* Stream performs a HTTP query using method against an urlEndpoint using HTTP headers and a body (it can be nil).
+The request is bound to ctx, and so is reading the response body. It returns an HttpStreamResponse which body must be closed */
+func (s *SimpleHttpClient) Stream(ctx context.Context, method string, urlEndpoint string, headers map[string]string, body io.Reader) (*HttpStreamResponse, error) {
+	resp, err := s.do(ctx, method, urlEndpoint, headers, body)
 	if err != nil {
 		return nil, err
 	}
 
-	// Add the headers to the query
-	for k, v := range headers {
-		req.Header.Add(k, v)
-	}
+	return &HttpStreamResponse{StatusCode: resp.StatusCode,
+		Header: resp.Header,
+		Body:   resp.Body}, nil
+}
 
-	// Perform the query
-	resp, err := s.HttpClient.Do(req)
+func (s *SimpleHttpClient) runRequest(ctx context.Context, urlEndpoint string, method string, headers map[string]string, body io.Reader) (*HttpResponse, error) {
+	resp, err := s.do(ctx, method, urlEndpoint, headers, body)
 	if err != nil {
 		return nil, err
 	}
@@ -56,5 +84,23 @@ func (s *SimpleHttpClient) runRequest(urlEndpoint string, method string, headers
 	}
 
 	return &HttpResponse{StatusCode: resp.StatusCode,
-		Body: respBody}, nil
+		Header: resp.Header,
+		Body:   respBody}, nil
+}
+
+// do builds the HTTP request and performs it, returning the response with its body unread
+func (s *SimpleHttpClient) do(ctx context.Context, method string, urlEndpoint string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	// Create the HTTP Request
+	req, err := http.NewRequestWithContext(ctx, method, urlEndpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the headers to the query
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+
+	// Perform the query
+	return s.HttpClient.Do(req)
 }
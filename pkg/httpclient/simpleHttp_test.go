@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimpleHttpClient_ContextCancellation(t *testing.T) {
+	// The server hangs until the test finishes, the way an unresponsive daemon would
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	httpClient := NewSimpleHttpClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := httpClient.GetContext(ctx, server.URL, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SimpleHttpClient.Get() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSimpleHttpClient_Stream(t *testing.T) {
+	// The server sends a first chunk and waits before sending the rest, so the body can only be
+	// read progressively if the client does not buffer it
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte(r.Method + " " + string(body) + "\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("done\n"))
+	}))
+	defer server.Close()
+
+	httpClient := NewSimpleHttpClient()
+
+	resp, err := httpClient.Stream(context.Background(), "POST", server.URL, nil, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("SimpleHttpClient.Stream() = %d %q, want 200 \"application/json\"", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "POST payload\n" {
+		t.Errorf("SimpleHttpClient.Stream() first line = %q, want %q", line, "POST payload\n")
+	}
+
+	close(release)
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "done\n" {
+		t.Errorf("SimpleHttpClient.Stream() rest = %q, want %q", rest, "done\n")
+	}
+}
+
+func TestSimpleHttpClient_PutHead(t *testing.T) {
+	// The server echoes the method and body in a header, the way the docker daemon describes paths of the archive endpoints
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo", r.Method+":"+string(body))
+		w.WriteHeader(200)
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	httpClient := NewSimpleHttpClient()
+
+	resp, err := httpClient.Put(context.Background(), server.URL, nil, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || resp.Header.Get("X-Echo") != "PUT:payload" || string(resp.Body) != "body" {
+		t.Errorf("SimpleHttpClient.Put() = %d %q %q, want 200 \"PUT:payload\" \"body\"", resp.StatusCode, resp.Header.Get("X-Echo"), resp.Body)
+	}
+
+	resp, err = httpClient.Head(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || resp.Header.Get("X-Echo") != "HEAD:" || len(resp.Body) != 0 {
+		t.Errorf("SimpleHttpClient.Head() = %d %q %q, want 200 \"HEAD:\" and no body", resp.StatusCode, resp.Header.Get("X-Echo"), resp.Body)
+	}
+}
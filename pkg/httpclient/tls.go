@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Default file names used by docker inside DOCKER_CERT_PATH
+const (
+	DefaultCAFile   = "ca.pem"
+	DefaultCertFile = "cert.pem"
+	DefaultKeyFile  = "key.pem"
+)
+
+// TLSOptions gathers the certificates needed to talk to a docker daemon over TLS
+type TLSOptions struct {
+	// CAFile is the path to the CA certificate used to verify the daemon. If empty, the system roots are used
+	CAFile string
+	// CertFile is the path to the client certificate used for mutual TLS. Optional
+	CertFile string
+	// KeyFile is the path to the client key used for mutual TLS. Optional, but required if CertFile is set
+	KeyFile string
+	// InsecureSkipVerify disables the verification of the daemon certificate
+	InsecureSkipVerify bool
+}
+
+/* NewTLSOptionsFromCertPath returns the TLSOptions docker would use given a DOCKER_CERT_PATH like directory.
+The client certificate and key are only set if both files exist */
+func NewTLSOptionsFromCertPath(certPath string, verify bool) *TLSOptions {
+	tlsOptions := &TLSOptions{InsecureSkipVerify: !verify}
+
+	if caFile := filepath.Join(certPath, DefaultCAFile); fileExists(caFile) {
+		tlsOptions.CAFile = caFile
+	}
+
+	certFile, keyFile := filepath.Join(certPath, DefaultCertFile), filepath.Join(certPath, DefaultKeyFile)
+	if fileExists(certFile) && fileExists(keyFile) {
+		tlsOptions.CertFile = certFile
+		tlsOptions.KeyFile = keyFile
+	}
+
+	return tlsOptions
+}
+
+// NewTLSHttpClient returns a SimpleHttpClient that talks HTTPS using the certificates given
+func NewTLSHttpClient(tlsOptions *TLSOptions) (*SimpleHttpClient, error) {
+	tlsConfig, err := tlsOptions.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &SimpleHttpClient{HttpClient: &http.Client{Transport: transport}}, nil
+}
+
+func (t *TLSOptions) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		caPEM, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA certificate %s - %s", t.CAFile, err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate %s and key %s - %s", t.CertFile, t.KeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generateCertificate creates a certificate signed by parent (self signed if parent is nil) and returns it alongside its key
+func generateCertificate(t *testing.T, commonName string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path string, blockType string, bytes []byte) {
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewTLSHttpClient(t *testing.T) {
+	// Client certificates are signed by their own CA
+	clientCA, clientCAKey := generateCertificate(t, "client-ca", true, nil, nil)
+	clientCert, clientKey := generateCertificate(t, "client", false, clientCA, clientCAKey)
+	clientCAPool := x509.NewCertPool()
+	clientCAPool.AddCert(clientCA)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAPool}
+	server.StartTLS()
+	defer server.Close()
+
+	// Lay the certificates out the way DOCKER_CERT_PATH expects them
+	certPath := t.TempDir()
+	writePEM(t, filepath.Join(certPath, DefaultCAFile), "CERTIFICATE", server.Certificate().Raw)
+	writePEM(t, filepath.Join(certPath, DefaultCertFile), "CERTIFICATE", clientCert.Raw)
+	keyBytes, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(certPath, DefaultKeyFile), "EC PRIVATE KEY", keyBytes)
+
+	tests := []struct {
+		name       string
+		tlsOptions *TLSOptions
+		wantErr    bool
+	}{
+		{
+			name:       "Mutual TLS with CA verification",
+			tlsOptions: NewTLSOptionsFromCertPath(certPath, true),
+			wantErr:    false,
+		},
+		{
+			name:       "Mutual TLS without CA verification",
+			tlsOptions: &TLSOptions{CertFile: filepath.Join(certPath, DefaultCertFile), KeyFile: filepath.Join(certPath, DefaultKeyFile), InsecureSkipVerify: true},
+			wantErr:    false,
+		},
+		{
+			name:       "Missing client certificate",
+			tlsOptions: &TLSOptions{CAFile: filepath.Join(certPath, DefaultCAFile)},
+			wantErr:    true,
+		},
+		{
+			name:       "Unknown daemon CA",
+			tlsOptions: &TLSOptions{CertFile: filepath.Join(certPath, DefaultCertFile), KeyFile: filepath.Join(certPath, DefaultKeyFile)},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient, err := NewTLSHttpClient(tt.tlsOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = httpClient.GetContext(context.Background(), server.URL+"/_ping", nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SimpleHttpClient.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// UnixSocketScheme is the prefix used by endpoints that point to a unix domain socket
+	UnixSocketScheme = "unix://"
+
+	// UnixSocketBaseURL is the base URL used to build queries when talking through a unix domain socket.
+	// The host part is never resolved, since every connection is dialed against the socket
+	UnixSocketBaseURL = "http://docker"
+
+	// DefaultUnixSocketPath is the path where a stock docker install listens to
+	DefaultUnixSocketPath = "/var/run/docker.sock"
+)
+
+// NewUnixSocketHttpClient returns a SimpleHttpClient that sends every request through the unix domain socket given
+func NewUnixSocketHttpClient(socketPath string) *SimpleHttpClient {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &SimpleHttpClient{HttpClient: &http.Client{Transport: transport}}
+}
+
+/* NewSimpleHttpClientForEndpoint returns a SimpleHttpClient able to reach the endpoint given
+(unix:///path/to/socket, tcp://host:port, http://host:port or https://host:port), alongside the base URL
+queries must be built with. If tlsOptions is not nil, tcp:// endpoints are reached over HTTPS, and http:// endpoints
+are rejected rather than reached in plain text */
+func NewSimpleHttpClientForEndpoint(endpoint string, tlsOptions *TLSOptions) (*SimpleHttpClient, string, error) {
+	if strings.HasPrefix(endpoint, UnixSocketScheme) {
+		socketPath := strings.TrimPrefix(endpoint, UnixSocketScheme)
+		if socketPath == "" {
+			return nil, "", fmt.Errorf("no socket path given in endpoint %s", endpoint)
+		}
+		return NewUnixSocketHttpClient(socketPath), UnixSocketBaseURL, nil
+	}
+
+	parsedURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot parse endpoint %s - %s", endpoint, err)
+	}
+
+	if parsedURL.Scheme == "tcp" {
+		parsedURL.Scheme = "http"
+		if tlsOptions != nil {
+			parsedURL.Scheme = "https"
+		}
+	}
+	baseURL := strings.TrimSuffix(parsedURL.String(), "/")
+
+	switch parsedURL.Scheme {
+	case "http":
+		if tlsOptions != nil {
+			return nil, "", fmt.Errorf("endpoint %s does not use TLS, use https:// or tcp:// instead", endpoint)
+		}
+		return NewSimpleHttpClient(), baseURL, nil
+	case "https":
+		if tlsOptions == nil {
+			tlsOptions = &TLSOptions{} // Verify against the system roots
+		}
+		httpClient, err := NewTLSHttpClient(tlsOptions)
+		if err != nil {
+			return nil, "", err
+		}
+		return httpClient, baseURL, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported scheme %q in endpoint %s", parsedURL.Scheme, endpoint)
+	}
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewUnixSocketHttpClient(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	httpClient, baseURL, err := NewSimpleHttpClientForEndpoint(UnixSocketScheme+socketPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := httpClient.GetContext(context.Background(), baseURL+"/_ping", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "GET /_ping" {
+		t.Errorf("SimpleHttpClient.Get() = %d %q, want 200 \"GET /_ping\"", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestNewSimpleHttpClientForEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    string
+		tlsOptions  *TLSOptions
+		wantBaseURL string
+		wantErr     bool
+	}{
+		{
+			name:        "Unix socket endpoint",
+			endpoint:    "unix:///var/run/docker.sock",
+			wantBaseURL: UnixSocketBaseURL,
+			wantErr:     false,
+		},
+		{
+			name:        "HTTP endpoint",
+			endpoint:    "http://localhost:2375/",
+			wantBaseURL: "http://localhost:2375",
+			wantErr:     false,
+		},
+		{
+			name:       "HTTP endpoint with TLS",
+			endpoint:   "http://localhost:2376",
+			tlsOptions: &TLSOptions{CAFile: "/etc/docker/ca.pem"},
+			wantErr:    true,
+		},
+		{
+			name:        "TCP endpoint",
+			endpoint:    "tcp://localhost:2375",
+			wantBaseURL: "http://localhost:2375",
+			wantErr:     false,
+		},
+		{
+			name:        "TCP endpoint with TLS",
+			endpoint:    "tcp://localhost:2376",
+			tlsOptions:  &TLSOptions{},
+			wantBaseURL: "https://localhost:2376",
+			wantErr:     false,
+		},
+		{
+			name:        "HTTPS endpoint",
+			endpoint:    "https://localhost:2376",
+			wantBaseURL: "https://localhost:2376",
+			wantErr:     false,
+		},
+		{
+			name:       "HTTPS endpoint with a missing CA file",
+			endpoint:   "https://localhost:2376",
+			tlsOptions: &TLSOptions{CAFile: "/nonexistent/ca.pem"},
+			wantErr:    true,
+		},
+		{
+			name:     "Unix socket endpoint without path",
+			endpoint: "unix://",
+			wantErr:  true,
+		},
+		{
+			name:     "Unsupported scheme",
+			endpoint: "ftp://localhost:2375",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := NewSimpleHttpClientForEndpoint(tt.endpoint, tt.tlsOptions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSimpleHttpClientForEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.wantBaseURL {
+				t.Errorf("NewSimpleHttpClientForEndpoint() = %v, want %v", got, tt.wantBaseURL)
+			}
+		})
+	}
+}